@@ -3,6 +3,8 @@ package aftership
 import (
 	"errors"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // Config is the config of AfterShip SDK client
@@ -18,14 +20,47 @@ type Config struct {
 	// if AuthenticationType is AES, use aes api secret
 	APISecret string
 
-	// BaseURL is the base URL of AfterShip API. Defaults to 'https://api.aftership.com/v4'
+	// BaseURL is the base URL of AfterShip API. Defaults to 'https://api.aftership.com/v4',
+	// or 'https://api.aftership.com/tracking/<version>' with a date-versioned APIVersion.
 	BaseURL string
 
+	// APIVersion is the version of the tracking API, APIVersionV4 or a date version such as APIVersion202407.
+	// Defaults to APIVersionV4. The date-versioned APIs use the same methods and types as v4:
+	// their paths, headers, pagination and tracking fields are mapped by the client.
+	// They only accept tracking IDs in paths, so the first call given a SlugTrackingNumber looks up its ID
+	// with GetTrackings, costing one more request and rate limit token, and the client keeps the ID for the next calls.
+	// Pass a TrackingID when it is known.
+	APIVersion APIVersion
+
 	// UserAgentPrefix is the prefix of User-Agent in headers. Defaults to 'aftership-sdk-go'
 	UserAgentPrefix string
 
 	// HTTPClient is the HTTP client to use when making requests. Defaults to http.DefaultClient.
 	HTTPClient *http.Client
+
+	// ValidateTrackingNumbers enables the check digit validation of tracking numbers in CreateTracking.
+	// Only trackings with a Slug supported by the checkdigit package are checked.
+	ValidateTrackingNumbers bool
+
+	// ValidateContacts enables the validation and normalization of emails and SMS phone numbers
+	// in CreateTracking, UpdateTracking, AddNotification and SetNotification.
+	ValidateContacts bool
+
+	// DefaultPhoneRegion is the ISO Alpha-3 country code of SMS phone numbers given without country code,
+	// e.g. "USA". Only international numbers are accepted when empty.
+	DefaultPhoneRegion string
+
+	// CoalesceReads makes the concurrent identical GET requests, such as GetTracking or GetLastCheckpoint calls
+	// with the same identifier and params, share one HTTP request. Every caller gets its own copy of the result.
+	CoalesceReads bool
+
+	// StaleIfError keeps the last successful responses of GetTracking, GetTrackings, GetLastCheckpoint and GetCouriers.
+	// When the API returns a 5xx status, times out, cannot be reached or is rate limited, these calls return
+	// the stored response with a *StaleResponseError telling how old it is. Store write errors are ignored.
+	StaleIfError ResponseStore
+
+	// MaxStale is the age beyond which stored responses are not returned by StaleIfError. Defaults to no limit.
+	MaxStale time.Duration
 }
 
 // Client is the client for all AfterShip API calls
@@ -36,6 +71,11 @@ type Client struct {
 	httpClient *http.Client
 	// Rate limit
 	rateLimit *RateLimit
+	// The GET requests in flight, shared when CoalesceReads is set
+	reads *requestGroup
+	// The IDs of the trackings looked up by slug and tracking number with the date-versioned APIs
+	trackingIDsMu sync.Mutex
+	trackingIDs   map[SlugTrackingNumber]string
 }
 
 // NewClient returns the AfterShip client
@@ -50,8 +90,15 @@ func NewClient(cfg Config) (*Client, error) {
 		}
 	}
 
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = APIVersionV4
+	}
+	if err := cfg.APIVersion.validate(); err != nil {
+		return nil, err
+	}
+
 	if cfg.BaseURL == "" {
-		cfg.BaseURL = "https://api.aftership.com/v4"
+		cfg.BaseURL = cfg.APIVersion.baseURL()
 	}
 
 	if cfg.UserAgentPrefix == "" {
@@ -59,9 +106,11 @@ func NewClient(cfg Config) (*Client, error) {
 	}
 
 	client := &Client{
-		Config:     cfg,
-		rateLimit:  &RateLimit{},
-		httpClient: http.DefaultClient,
+		Config:      cfg,
+		rateLimit:   &RateLimit{},
+		httpClient:  http.DefaultClient,
+		reads:       newRequestGroup(),
+		trackingIDs: make(map[SlugTrackingNumber]string),
 	}
 
 	if cfg.HTTPClient != nil {
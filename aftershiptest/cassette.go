@@ -0,0 +1,335 @@
+package aftershiptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RecorderMode is the mode of a Recorder
+type RecorderMode int
+
+// Recorder modes
+const (
+	// ModeReplay replays the interactions of the cassette, and fails the requests without a recorded interaction.
+	ModeReplay RecorderMode = iota
+
+	// ModeRecord sends the requests and records the interactions, replacing the cassette on Stop.
+	ModeRecord
+
+	// ModeReplayOrRecord replays the cassette when the file exists, and records it otherwise.
+	ModeReplayOrRecord
+)
+
+// Redacted replaces the scrubbed values in cassettes
+const Redacted = "REDACTED"
+
+// DefaultIgnoredHeaders are the request headers which change on every call, and are ignored when matching requests
+var DefaultIgnoredHeaders = []string{"request-id", "date", "as-signature-hmac-sha256"}
+
+// DefaultScrubFields are the JSON fields and query parameters holding personal data, scrubbed from cassettes:
+// the contacts, notification receivers and devices, customer name and destination address of the trackings.
+// Tracking.CustomerName is read from custom_name, and the date-versioned APIs hold the contacts and name
+// of the customers in customers.
+var DefaultScrubFields = []string{
+	"emails", "smses", "subscribed_emails", "subscribed_smses", "ios", "android",
+	"customer_name", "custom_name", "customers", "destination_raw_location",
+}
+
+// secretHeaders are the request headers holding credentials, always scrubbed from cassettes
+var secretHeaders = []string{"aftership-api-key", "as-api-key", "as-signature-hmac-sha256", "as-signature-rsa-sha256"}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of a cassette
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response of a cassette
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers"`
+	Body       string      `json:"body"`
+}
+
+// cassette is the file format of a Recorder
+type cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// RecorderOptions are the options of NewRecorder
+type RecorderOptions struct {
+	// Mode defaults to ModeReplay.
+	Mode RecorderMode
+
+	// Transport sends the requests in record mode. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// ScrubFields are the JSON fields and query parameters replaced with Redacted in the cassette,
+	// at any depth of the request and response bodies. Defaults to DefaultScrubFields.
+	ScrubFields []string
+
+	// IgnoreHeaders are the request headers ignored when matching requests, in addition to DefaultIgnoredHeaders.
+	IgnoreHeaders []string
+}
+
+// Recorder is an http.RoundTripper recording the requests and responses of an http.Client to a cassette file,
+// and replaying them later without network access. API keys and the ScrubFields are scrubbed from the cassette.
+//
+// Requests match a recorded interaction on their method, URL, headers and JSON body, once scrubbed.
+// Each interaction is replayed once, in recording order, so a request sent twice replays two responses.
+type Recorder struct {
+	path          string
+	mode          RecorderMode
+	transport     http.RoundTripper
+	scrubFields   map[string]bool
+	ignoreHeaders map[string]bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// NewRecorder returns a Recorder of the cassette file at path. The cassette is read in replay mode.
+func NewRecorder(path string, options RecorderOptions) (*Recorder, error) {
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+	if options.ScrubFields == nil {
+		options.ScrubFields = DefaultScrubFields
+	}
+
+	r := &Recorder{
+		path:          path,
+		mode:          options.Mode,
+		transport:     options.Transport,
+		scrubFields:   make(map[string]bool),
+		ignoreHeaders: make(map[string]bool),
+	}
+	for _, field := range options.ScrubFields {
+		r.scrubFields[field] = true
+	}
+	for _, header := range append(DefaultIgnoredHeaders, options.IgnoreHeaders...) {
+		r.ignoreHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+
+	if r.mode == ModeReplayOrRecord {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode != ModeReplay {
+		return r, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading cassette")
+	}
+	var c cassette
+	if err := json.Unmarshal(contents, &c); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshalling cassette %s", path)
+	}
+	r.interactions = c.Interactions
+	r.replayed = make([]bool, len(c.Interactions))
+	return r, nil
+}
+
+// Client returns an http.Client using the recorder, for Config.HTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Recording returns true when the recorder sends the requests and records them.
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// RoundTrip replays the response of req, or sends req and records the interaction in record mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading request body")
+		}
+	}
+	recorded := r.scrubRequest(req, body)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	sent := req.Clone(req.Context())
+	if body != nil {
+		sent.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.transport.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading response body")
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       r.scrubBody(responseBody),
+		},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Stop writes the cassette in record mode. It does nothing in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	contents, err := json.MarshalIndent(cassette{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "error marshalling cassette")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return errors.Wrap(err, "error creating cassette directory")
+	}
+	return errors.Wrap(ioutil.WriteFile(r.path, append(contents, '\n'), 0644), "error writing cassette")
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.replayed[i] || !r.match(interaction.Request, recorded) {
+			continue
+		}
+		r.replayed[i] = true
+
+		response := interaction.Response
+		return &http.Response{
+			Status:        http.StatusText(response.StatusCode),
+			StatusCode:    response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        response.Headers.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(response.Body)),
+			ContentLength: int64(len(response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.Errorf("cassette %s has no interaction left for %s %s", r.path, recorded.Method, recorded.URL)
+}
+
+// match returns true when the scrubbed requests are the same, but for the ignored headers
+func (r *Recorder) match(a RecordedRequest, b RecordedRequest) bool {
+	if a.Method != b.Method || a.URL != b.URL || a.Body != b.Body {
+		return false
+	}
+	return r.matchHeaders(a.Headers, b.Headers) && r.matchHeaders(b.Headers, a.Headers)
+}
+
+func (r *Recorder) matchHeaders(a http.Header, b http.Header) bool {
+	for key, values := range a {
+		key = http.CanonicalHeaderKey(key)
+		if r.ignoreHeaders[key] {
+			continue
+		}
+		if strings.Join(values, ",") != strings.Join(b[key], ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// scrubRequest returns req as recorded in the cassette
+func (r *Recorder) scrubRequest(req *http.Request, body []byte) RecordedRequest {
+	headers := req.Header.Clone()
+	for _, header := range secretHeaders {
+		if headers.Get(header) != "" {
+			headers.Set(header, Redacted)
+		}
+	}
+
+	u := *req.URL
+	if query := u.Query(); len(query) > 0 {
+		for key := range query {
+			if r.scrubFields[key] {
+				query.Set(key, Redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return RecordedRequest{
+		Method:  req.Method,
+		URL:     u.String(),
+		Headers: headers,
+		Body:    r.scrubBody(body),
+	}
+}
+
+// scrubBody returns the JSON body with the ScrubFields redacted, re-encoded with sorted keys.
+// Bodies which are not JSON are returned as is.
+func (r *Recorder) scrubBody(body []byte) string {
+	var v interface{}
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return string(body)
+	}
+
+	scrubbed, err := json.Marshal(r.scrubValue(v, false))
+	if err != nil {
+		return string(body)
+	}
+	return string(scrubbed)
+}
+
+// scrubValue redacts the strings of v when redact is true, and the values of the ScrubFields of its objects
+func (r *Recorder) scrubValue(v interface{}, redact bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			value[key] = r.scrubValue(field, redact || r.scrubFields[key])
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = r.scrubValue(item, redact)
+		}
+	case string:
+		if redact && value != "" {
+			return Redacted
+		}
+	}
+	return v
+}
@@ -0,0 +1,208 @@
+package aftershiptest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const cassetteSecret = "aftershiptest-api-secret"
+
+// cassetteCalls makes the calls recorded and replayed by the tests
+func cassetteCalls(t *testing.T, client *aftership.Client) (aftership.Tracking, aftership.Tracking) {
+	ctx := context.Background()
+	created, err := client.CreateTracking(ctx, aftership.CreateTrackingParams{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+		Emails:         []string{"customer@example.com"},
+		SMSes:          []string{"+85291234567"},
+		CustomerName:   "Jane Doe",
+	})
+	assert.Nil(t, err)
+
+	_, err = client.UpdateTracking(ctx, aftership.TrackingID(created.ID), aftership.UpdateTrackingParams{Title: "Order #1001"})
+	assert.Nil(t, err)
+
+	tracking, err := client.GetTracking(ctx, aftership.TrackingID(created.ID), aftership.GetTrackingParams{})
+	assert.Nil(t, err)
+	return created, tracking
+}
+
+func newCassetteClient(t *testing.T, baseURL string, recorder *Recorder) *aftership.Client {
+	client, err := aftership.NewClient(aftership.Config{
+		APIKey:             APIKey,
+		APISecret:          cassetteSecret,
+		AuthenticationType: aftership.AES,
+		BaseURL:            baseURL,
+		HTTPClient:         recorder.Client(),
+	})
+	assert.Nil(t, err)
+	return client
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aftershiptest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassettes", "trackings.json")
+
+	// Record against a fake server
+	server := NewServer()
+	recorder, err := NewRecorder(path, RecorderOptions{Mode: ModeReplayOrRecord})
+	assert.Nil(t, err)
+	assert.True(t, recorder.Recording())
+	created, recorded := cassetteCalls(t, newCassetteClient(t, server.URL, recorder))
+	assert.Equal(t, "Order #1001", recorded.Title)
+	assert.Equal(t, "Jane Doe", recorded.CustomerName) // Only the cassette is scrubbed
+	assert.Nil(t, recorder.Stop())
+	server.Close()
+
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	for _, secret := range []string{APIKey, "customer@example.com", "+85291234567", "Jane Doe"} {
+		assert.False(t, strings.Contains(string(contents), secret), "cassette contains %q", secret)
+	}
+	assert.True(t, strings.Contains(string(contents), Redacted))
+
+	// Replay without the server. The request ids, dates and signatures differ from the recording.
+	recorder, err = NewRecorder(path, RecorderOptions{Mode: ModeReplayOrRecord})
+	assert.Nil(t, err)
+	assert.False(t, recorder.Recording())
+	client := newCassetteClient(t, server.URL, recorder)
+	replayedCreated, replayed := cassetteCalls(t, client)
+	assert.Equal(t, created.ID, replayedCreated.ID)
+	assert.Equal(t, recorded.Title, replayed.Title)
+	assert.Equal(t, Redacted, replayed.CustomerName)
+	assert.Equal(t, []string{Redacted}, replayed.Emails)
+	assert.Nil(t, recorder.Stop())
+
+	// Every interaction is replayed once
+	_, err = client.GetTracking(context.Background(), aftership.TrackingID(created.ID), aftership.GetTrackingParams{})
+	assert.NotNil(t, err)
+}
+
+// trackingFixture is a tracking response with every field holding personal data
+const trackingFixture = `{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed",
+	"slug": "ups", "tracking_number": "1Z999AA10123456784", "title": "Order #1001",
+	"emails": ["customer@example.com"], "smses": ["+85291234567"],
+	"subscribed_emails": ["subscriber@example.com"], "subscribed_smses": ["+85298765432"],
+	"ios": ["ios-device-id"], "android": ["android-device-id"],
+	"custom_name": "Jane Doe", "destination_raw_location": "1 Main Street, Springfield"}}}`
+
+// trackingFixturePII is the personal data of trackingFixture
+var trackingFixturePII = []string{
+	"customer@example.com", "+85291234567", "subscriber@example.com", "+85298765432",
+	"ios-device-id", "android-device-id", "Jane Doe", "1 Main Street",
+}
+
+// recordFixture records the response body of a GetTracking call and returns the cassette
+func recordFixture(t *testing.T, body string, config aftership.Config) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "aftershiptest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tracking.json")
+
+	recorder, err := NewRecorder(path, RecorderOptions{Mode: ModeRecord})
+	assert.Nil(t, err)
+	config.APIKey = APIKey
+	config.BaseURL = server.URL
+	config.HTTPClient = recorder.Client()
+	client, err := aftership.NewClient(config)
+	assert.Nil(t, err)
+
+	_, err = client.GetTracking(context.Background(), aftership.TrackingID("5b74f4958776db0e00b6f5ed"), aftership.GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Nil(t, recorder.Stop())
+
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	return string(contents)
+}
+
+func TestRecorderScrubsTracking(t *testing.T) {
+	contents := recordFixture(t, trackingFixture, aftership.Config{})
+	for _, pii := range trackingFixturePII {
+		assert.False(t, strings.Contains(contents, pii), "cassette contains %q", pii)
+	}
+	assert.True(t, strings.Contains(contents, "Order #1001"))
+
+	// The date-versioned APIs return the contacts and name in customers
+	dated := `{"meta": {"code": 200}, "data": {"id": "5b74f4958776db0e00b6f5ed", "title": "Order #1001",
+		"customers": [{"role": "buyer", "name": "Jane Doe", "email": "customer@example.com", "phone_number": "+85291234567"}]}}`
+	contents = recordFixture(t, dated, aftership.Config{APIVersion: aftership.APIVersion202407})
+	for _, pii := range []string{"Jane Doe", "customer@example.com", "+85291234567"} {
+		assert.False(t, strings.Contains(contents, pii), "cassette contains %q", pii)
+	}
+	assert.True(t, strings.Contains(contents, "Order #1001"))
+}
+
+func TestRecorderMatch(t *testing.T) {
+	recorder, err := NewRecorder("", RecorderOptions{Mode: ModeRecord, IgnoreHeaders: []string{"User-Agent"}})
+	assert.Nil(t, err)
+
+	request := func(method string, target string, body string, headers map[string]string) RecordedRequest {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return recorder.scrubRequest(req, []byte(body))
+	}
+
+	recorded := request(http.MethodPost, "https://api.aftership.com/v4/trackings?keyword=a",
+		`{"tracking":{"slug":"ups","emails":["a@example.com"]}}`,
+		map[string]string{"aftership-api-key": "key-1", "request-id": "1", "User-Agent": "sdk/1", "Content-Type": "application/json"})
+
+	tests := []struct {
+		name    string
+		request RecordedRequest
+		match   bool
+	}{
+		{
+			"Volatile headers, API key, PII and key order",
+			request(http.MethodPost, "https://api.aftership.com/v4/trackings?keyword=a",
+				`{"tracking":{"emails":["b@example.com"],"slug":"ups"}}`,
+				map[string]string{"aftership-api-key": "key-2", "request-id": "2", "User-Agent": "sdk/2", "Content-Type": "application/json"}),
+			true,
+		},
+		{
+			"Different body",
+			request(http.MethodPost, "https://api.aftership.com/v4/trackings?keyword=a",
+				`{"tracking":{"slug":"fedex","emails":["a@example.com"]}}`,
+				map[string]string{"aftership-api-key": "key-1", "request-id": "1", "Content-Type": "application/json"}),
+			false,
+		},
+		{
+			"Different query",
+			request(http.MethodPost, "https://api.aftership.com/v4/trackings?keyword=b",
+				`{"tracking":{"slug":"ups","emails":["a@example.com"]}}`,
+				map[string]string{"aftership-api-key": "key-1", "Content-Type": "application/json"}),
+			false,
+		},
+		{
+			"Missing header",
+			request(http.MethodPost, "https://api.aftership.com/v4/trackings?keyword=a",
+				`{"tracking":{"slug":"ups","emails":["a@example.com"]}}`,
+				map[string]string{"aftership-api-key": "key-1"}),
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, recorder.match(recorded, tt.request))
+		})
+	}
+}
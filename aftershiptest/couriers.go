@@ -0,0 +1,232 @@
+package aftershiptest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+// DefaultCouriers are the couriers of a new Server
+var DefaultCouriers = []aftership.Courier{
+	{
+		Slug:                   "ups",
+		Name:                   "UPS",
+		Phone:                  "+1 800 742 5877",
+		OtherName:              "United Parcel Service",
+		WebURL:                 "https://www.ups.com",
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{},
+		ServiceFromCountryISO3: []string{"USA", "CAN", "GBR", "DEU"},
+	},
+	{
+		Slug:                   "usps",
+		Name:                   "USPS",
+		Phone:                  "+1 800-275-8777",
+		OtherName:              "United States Postal Service",
+		WebURL:                 "https://www.usps.com",
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{},
+		ServiceFromCountryISO3: []string{"USA"},
+	},
+	{
+		Slug:                   "fedex",
+		Name:                   "FedEx",
+		Phone:                  "+1 800 247 4747",
+		OtherName:              "Federal Express",
+		WebURL:                 "https://www.fedex.com",
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{},
+		ServiceFromCountryISO3: []string{"USA"},
+	},
+	{
+		Slug:                   "dhl",
+		Name:                   "DHL Express",
+		Phone:                  "+1 800 225 5345",
+		OtherName:              "DHL International",
+		WebURL:                 "https://www.dhl.com",
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{"de", "es", "fr", "zh"},
+		ServiceFromCountryISO3: []string{"DEU", "USA", "GBR", "HKG"},
+	},
+	{
+		Slug:                   "royal-mail",
+		Name:                   "Royal Mail",
+		Phone:                  "+44 1752387112",
+		WebURL:                 "https://www.royalmail.com",
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{},
+		ServiceFromCountryISO3: []string{"GBR"},
+	},
+	{
+		Slug:                   "hong-kong-post",
+		Name:                   "Hong Kong Post",
+		Phone:                  "+852 2921 2222",
+		OtherName:              "Hongkong Post",
+		WebURL:                 "https://www.hongkongpost.hk",
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{"zh-Hant"},
+		ServiceFromCountryISO3: []string{"HKG"},
+	},
+	{
+		Slug:                   "xq-express",
+		Name:                   "XQ Express",
+		WebURL:                 "https://www.xqkjwl.com",
+		RequiredFields:         []string{"tracking_postal_code"},
+		DefaultLanguage:        "en",
+		SupportedLanguages:     []string{},
+		ServiceFromCountryISO3: []string{"CHN"},
+	},
+}
+
+// detector detects the couriers of the tracking numbers, among the active couriers of the server
+var detector, _ = aftership.NewOfflineDetector(nil)
+
+// predictionTransitDays is the number of days from the pickup to the delivery of the default prediction
+const predictionTransitDays = 3
+
+// SetCouriers replaces the couriers of the server with all. Only the couriers with activeSlugs are active,
+// or all of them when none is given. Trackings without a slug are detected among the active couriers.
+func (s *Server) SetCouriers(all []aftership.Courier, activeSlugs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.couriers = append([]aftership.Courier(nil), all...)
+	s.activeSlugs = make(map[string]bool, len(all))
+	if len(activeSlugs) == 0 {
+		for _, courier := range all {
+			activeSlugs = append(activeSlugs, courier.Slug)
+		}
+	}
+	for _, slug := range activeSlugs {
+		s.activeSlugs[slug] = true
+	}
+}
+
+func (s *Server) serveCouriers(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch strings.Join(segments, "/") {
+	case "":
+		if allowMethod(w, r, http.MethodGet) {
+			writeCourierList(w, s.activeCouriers())
+		}
+	case "all":
+		if allowMethod(w, r, http.MethodGet) {
+			writeCourierList(w, s.couriers)
+		}
+	case "detect":
+		if allowMethod(w, r, http.MethodPost) {
+			s.detectCouriers(w, r)
+		}
+	default:
+		writeNotFound(w)
+	}
+}
+
+func (s *Server) activeCouriers() []aftership.Courier {
+	couriers := make([]aftership.Courier, 0, len(s.activeSlugs))
+	for _, courier := range s.couriers {
+		if s.activeSlugs[courier.Slug] {
+			couriers = append(couriers, courier)
+		}
+	}
+	return couriers
+}
+
+func (s *Server) detectCouriers(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Tracking *aftership.CourierDetectionParams `json:"tracking"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if request.Tracking == nil {
+		writeError(w, http.StatusBadRequest, CodeTrackingRequired, "BadRequest", "`tracking` is required.")
+		return
+	}
+	if request.Tracking.TrackingNumber == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidValue, "BadRequest", "The value of `tracking_number` is invalid.")
+		return
+	}
+
+	writeCourierList(w, s.detect(*request.Tracking))
+}
+
+// detect returns the active couriers, or the couriers of params.Slug, matching the tracking number format
+func (s *Server) detect(params aftership.CourierDetectionParams) []aftership.Courier {
+	candidates := s.activeSlugs
+	if len(params.Slug) > 0 {
+		candidates = make(map[string]bool, len(params.Slug))
+		for _, slug := range params.Slug {
+			candidates[slug] = true
+		}
+	}
+
+	couriers := []aftership.Courier{}
+	for _, detected := range detector.Detect(params.TrackingNumber).Couriers {
+		if !candidates[detected.Slug] {
+			continue
+		}
+		for _, courier := range s.couriers {
+			if courier.Slug == detected.Slug {
+				couriers = append(couriers, courier)
+				break
+			}
+		}
+	}
+	return couriers
+}
+
+func writeCourierList(w http.ResponseWriter, couriers []aftership.Courier) {
+	if couriers == nil {
+		couriers = []aftership.Courier{}
+	}
+	writeData(w, http.StatusOK, aftership.CourierList{Total: len(couriers), Couriers: couriers})
+}
+
+func (s *Server) servePredictBatch(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		EstimatedDeliveryDates []aftership.EstimatedDeliveryDate `json:"estimated_delivery_dates"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if len(request.EstimatedDeliveryDates) == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidValue, "BadRequest",
+			"The value of `estimated_delivery_dates` is invalid.")
+		return
+	}
+
+	dates := make([]aftership.EstimatedDeliveryDate, len(request.EstimatedDeliveryDates))
+	for i, edd := range request.EstimatedDeliveryDates {
+		dates[i] = s.Predict(edd)
+	}
+	writeData(w, http.StatusOK, aftership.EstimatedDeliveryDates{Dates: dates})
+}
+
+// predict is the default Server.Predict
+func (s *Server) predict(edd aftership.EstimatedDeliveryDate) aftership.EstimatedDeliveryDate {
+	const layout = "2006-01-02"
+
+	pickupTime := edd.PickupTime
+	if pickupTime == "" && edd.EstimatedPickup != nil {
+		pickupTime = edd.EstimatedPickup.PickupTime
+		if pickupTime == "" {
+			pickupTime = edd.EstimatedPickup.OrderTime
+		}
+	}
+
+	pickup := s.now().UTC()
+	if len(pickupTime) >= len(layout) {
+		if t, err := time.Parse(layout, pickupTime[:len(layout)]); err == nil {
+			pickup = t
+		}
+	}
+
+	delivery := pickup.AddDate(0, 0, predictionTransitDays)
+	edd.EstimatedDeliveryDate = delivery.Format(layout)
+	edd.EstimatedDeliveryDateMin = delivery.AddDate(0, 0, -1).Format(layout)
+	edd.EstimatedDeliveryDateMax = delivery.AddDate(0, 0, 1).Format(layout)
+	edd.ConfidenceScore = 0.9
+	return edd
+}
@@ -0,0 +1,43 @@
+/*
+Package aftershiptest provides an in-memory fake of the AfterShip API for tests.
+
+A Server implements every endpoint used by aftership.Client on an in-memory store,
+with the meta codes, pagination and rate limit headers of the real API:
+
+	server := aftershiptest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	tracking, err := client.CreateTracking(ctx, aftership.CreateTrackingParams{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+	})
+
+Tests change the trackings behind the client's back with AddCheckpoint and UpdateTracking.
+
+The server also emulates the date-versioned tracking APIs under /tracking/<version>, with their unwrapped
+trackings, customers and cursor pagination, for the clients returned by DatedClient.
+
+Like the real API, a Server allows DefaultRateLimit requests per second, after which the client
+gets a TooManyRequestsError. Tests making many calls in a row disable it with SetRateLimit(0).
+
+Code depending on aftership.API, or on the interface of a single endpoint such as aftership.TrackingsAPI,
+is tested without any server with a Mock, which records the calls and returns scripted responses.
+
+A FaultInjector makes the responses misbehave on demand, with latency, rate limits, server errors,
+truncated bodies and connection resets, to test how the callers of the API recover from them.
+
+A Recorder records the calls of a client to a cassette file, against the fake server or the real API,
+and replays them later without network access:
+
+	recorder, err := aftershiptest.NewRecorder("testdata/trackings.json", aftershiptest.RecorderOptions{
+		Mode: aftershiptest.ModeReplayOrRecord,
+	})
+	defer recorder.Stop()
+
+	client, err := aftership.NewClient(aftership.Config{
+		APIKey:     os.Getenv("AFTERSHIP_API_KEY"),
+		HTTPClient: recorder.Client(),
+	})
+*/
+package aftershiptest
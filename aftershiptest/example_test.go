@@ -0,0 +1,53 @@
+package aftershiptest_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/aftership/aftership-sdk-go/v2/aftershiptest"
+)
+
+func ExampleServer() {
+	server := aftershiptest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	tracking, err := client.CreateTracking(context.Background(), aftership.CreateTrackingParams{
+		TrackingNumber: "1Z999AA10123456784",
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	server.AddCheckpoint(tracking.ID, aftership.Checkpoint{Tag: aftership.TagInTransit, Message: "Departed from facility"})
+
+	tracking, err = client.GetTracking(context.Background(), aftership.TrackingID(tracking.ID), aftership.GetTrackingParams{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(tracking.Slug, tracking.Tag)
+
+	// Output: ups InTransit
+}
+
+// refreshTag is code under test, depending on the trackings endpoint only
+func refreshTag(ctx context.Context, api aftership.TrackingsAPI, id string) (string, error) {
+	tracking, err := api.GetTracking(ctx, aftership.TrackingID(id), aftership.GetTrackingParams{Fields: "tag"})
+	return tracking.Tag, err
+}
+
+func ExampleMock() {
+	mock := &aftershiptest.Mock{}
+	mock.Respond("GetTracking", aftership.Tracking{Tag: aftership.TagDelivered}, nil)
+
+	tag, err := refreshTag(context.Background(), mock, "5b7658cec7c33c0e007de3c5")
+	fmt.Println(tag, err)
+	fmt.Println(mock.CallsTo("GetTracking")[0].Args[0])
+
+	// Output:
+	// Delivered <nil>
+	// 5b7658cec7c33c0e007de3c5
+}
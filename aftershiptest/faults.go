@@ -0,0 +1,299 @@
+package aftershiptest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultKind is a kind of Fault
+type FaultKind string
+
+// Fault kinds
+const (
+	FaultLatency         FaultKind = "latency"           // Delays the request by Fault.Delay
+	FaultTooManyRequests FaultKind = "too_many_requests" // Responds 429 with Fault.RateLimitReset
+	FaultServerError     FaultKind = "server_error"      // Responds Fault.StatusCode with an HTML body, as a proxy in front of the API would
+	FaultTruncatedJSON   FaultKind = "truncated_json"    // Cuts the response body of the API at Fault.TruncateAt bytes
+	FaultConnectionReset FaultKind = "connection_reset"  // Fails the request with a connection reset by peer error
+	FaultSlowBody        FaultKind = "slow_body"         // Sends the response body of the API in chunks of Fault.ChunkSize bytes, every Fault.Delay
+)
+
+// Defaults of Fault
+const (
+	DefaultFaultChunkSize = 16
+	defaultFaultStatus    = http.StatusInternalServerError
+)
+
+// Fault is a misbehavior injected by a FaultInjector
+type Fault struct {
+	Kind FaultKind
+
+	// Delay is the latency of FaultLatency, and the delay between the chunks of FaultSlowBody.
+	Delay time.Duration
+
+	// StatusCode is the status of FaultServerError, e.g. 502. Defaults to 500.
+	StatusCode int
+
+	// RateLimitReset is the x-ratelimit-reset time of FaultTooManyRequests. Defaults to the next second.
+	RateLimitReset time.Time
+
+	// TruncateAt is the length of the body of FaultTruncatedJSON. Defaults to half of the body.
+	TruncateAt int
+
+	// ChunkSize is the size of the chunks of FaultSlowBody. Defaults to DefaultFaultChunkSize.
+	ChunkSize int
+}
+
+// FaultRule injects a fault in the matching requests
+type FaultRule struct {
+	// Method is the method of the matching requests, any method when empty.
+	Method string
+
+	// Path is a path.Match pattern of the last segments of the matching request paths, any path when empty.
+	// For instance "/trackings/*" matches the requests of GetTracking by ID, whatever the base URL.
+	Path string
+
+	// Calls are the numbers of the matching requests to inject the fault in, starting from 1.
+	// The fault is injected in every matching request when empty.
+	Calls []int
+
+	Fault Fault
+}
+
+// InjectedFault is an entry of the log of a FaultInjector
+type InjectedFault struct {
+	Method string
+	Path   string
+	Call   int // The number of the request among the requests matching the rule
+	Fault  Fault
+}
+
+// FaultInjector is an http.RoundTripper injecting faults in the requests and responses of an http.Client,
+// to test how the callers of the API handle them. Requests without fault are sent with Transport.
+// It is safe for concurrent use.
+type FaultInjector struct {
+	// Transport sends the requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	rules    []FaultRule
+	calls    []int
+	injected []InjectedFault
+}
+
+// NewFaultInjector returns a FaultInjector sending the requests with transport, or http.DefaultTransport when nil.
+func NewFaultInjector(transport http.RoundTripper) *FaultInjector {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &FaultInjector{Transport: transport}
+}
+
+// Inject adds rule. When several rules inject a fault in the same request, the first one added wins.
+func (f *FaultInjector) Inject(rule FaultRule) *FaultInjector {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule)
+	f.calls = append(f.calls, 0)
+	return f
+}
+
+// Client returns an http.Client using the injector, for Config.HTTPClient.
+func (f *FaultInjector) Client() *http.Client {
+	return &http.Client{Transport: f}
+}
+
+// Injected returns the log of the injected faults, in request order.
+func (f *FaultInjector) Injected() []InjectedFault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]InjectedFault(nil), f.injected...)
+}
+
+// RoundTrip sends req, injecting the fault of the first matching rule.
+func (f *FaultInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, ok := f.fault(req)
+	if !ok {
+		return f.Transport.RoundTrip(req)
+	}
+
+	switch fault.Kind {
+	case FaultLatency:
+		if err := sleep(req.Context(), fault.Delay); err != nil {
+			return nil, err
+		}
+		return f.Transport.RoundTrip(req)
+
+	case FaultTooManyRequests:
+		reset := fault.RateLimitReset
+		if reset.IsZero() {
+			reset = time.Now().Truncate(time.Second).Add(time.Second)
+		}
+		resp := faultResponse(req, http.StatusTooManyRequests, "application/json",
+			`{"meta":{"code":429,"type":"TooManyRequests","message":"You have exceeded the API call rate limit. Default limit is 10 requests per second."},"data":{}}`)
+		resp.Header.Set("x-ratelimit-limit", strconv.Itoa(DefaultRateLimit))
+		resp.Header.Set("x-ratelimit-remaining", "0")
+		resp.Header.Set("x-ratelimit-reset", strconv.FormatInt(reset.Unix(), 10))
+		return resp, nil
+
+	case FaultServerError:
+		status := fault.StatusCode
+		if status == 0 {
+			status = defaultFaultStatus
+		}
+		text := fmt.Sprintf("%d %s", status, http.StatusText(status))
+		return faultResponse(req, status, "text/html",
+			fmt.Sprintf("<html>\r\n<head><title>%s</title></head>\r\n<body>\r\n<center><h1>%s</h1></center>\r\n</body>\r\n</html>\r\n", text, text)), nil
+
+	case FaultConnectionReset:
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+	case FaultTruncatedJSON:
+		resp, err := f.Transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		truncateAt := fault.TruncateAt
+		if truncateAt <= 0 || truncateAt > len(body) {
+			truncateAt = len(body) / 2
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body[:truncateAt]))
+		resp.ContentLength = int64(truncateAt)
+		resp.Header.Del("Content-Length")
+		return resp, nil
+
+	case FaultSlowBody:
+		resp, err := f.Transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		chunkSize := fault.ChunkSize
+		if chunkSize <= 0 {
+			chunkSize = DefaultFaultChunkSize
+		}
+		resp.Body = &slowBody{ReadCloser: resp.Body, ctx: req.Context(), delay: fault.Delay, chunkSize: chunkSize}
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("unknown fault kind %q", fault.Kind)
+}
+
+// fault counts req against the matching rules, and returns the fault to inject, if any
+func (f *FaultInjector) fault(req *http.Request) (Fault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var fault *InjectedFault
+	for i, rule := range f.rules {
+		if !matchRule(rule, req) {
+			continue
+		}
+		f.calls[i]++
+		if fault == nil && matchCall(rule.Calls, f.calls[i]) {
+			fault = &InjectedFault{Method: req.Method, Path: req.URL.Path, Call: f.calls[i], Fault: rule.Fault}
+		}
+	}
+	if fault == nil {
+		return Fault{}, false
+	}
+
+	f.injected = append(f.injected, *fault)
+	return fault.Fault, true
+}
+
+func matchRule(rule FaultRule, req *http.Request) bool {
+	if rule.Method != "" && rule.Method != req.Method {
+		return false
+	}
+	if rule.Path == "" {
+		return true
+	}
+
+	// Match the pattern against as many trailing segments of the path as it has
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	n := len(strings.Split(strings.Trim(rule.Path, "/"), "/"))
+	if n > len(segments) {
+		return false
+	}
+	matched, _ := path.Match(strings.Trim(rule.Path, "/"), strings.Join(segments[len(segments)-n:], "/"))
+	return matched
+}
+
+func matchCall(calls []int, call int) bool {
+	if len(calls) == 0 {
+		return true
+	}
+	for _, c := range calls {
+		if c == call {
+			return true
+		}
+	}
+	return false
+}
+
+func faultResponse(req *http.Request, status int, contentType string, body string) *http.Response {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// slowBody reads a response body in chunks, waiting before each chunk
+type slowBody struct {
+	io.ReadCloser
+	ctx       context.Context
+	delay     time.Duration
+	chunkSize int
+}
+
+func (b *slowBody) Read(p []byte) (int, error) {
+	if err := sleep(b.ctx, b.delay); err != nil {
+		return 0, err
+	}
+	if len(p) > b.chunkSize {
+		p = p[:b.chunkSize]
+	}
+	return b.ReadCloser.Read(p)
+}
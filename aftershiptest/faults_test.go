@@ -0,0 +1,98 @@
+package aftershiptest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFaultClient(t *testing.T, server *Server, injector *FaultInjector) *aftership.Client {
+	client, err := aftership.NewClient(aftership.Config{
+		APIKey:     APIKey,
+		BaseURL:    server.URL,
+		HTTPClient: injector.Client(),
+	})
+	assert.Nil(t, err)
+	return client
+}
+
+func TestFaultInjector(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetRateLimit(0)
+	tracking := server.AddTracking(aftership.Tracking{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+	id := aftership.TrackingID(tracking.ID)
+	ctx := context.Background()
+
+	reset := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	injector := NewFaultInjector(nil).
+		Inject(FaultRule{Path: "/couriers", Calls: []int{1}, Fault: Fault{Kind: FaultTooManyRequests, RateLimitReset: reset}}).
+		Inject(FaultRule{Path: "/couriers", Calls: []int{2}, Fault: Fault{Kind: FaultServerError, StatusCode: http.StatusBadGateway}}).
+		Inject(FaultRule{Method: http.MethodGet, Path: "/trackings/*", Calls: []int{1}, Fault: Fault{Kind: FaultTruncatedJSON}}).
+		Inject(FaultRule{Method: http.MethodGet, Path: "/trackings/*", Calls: []int{2}, Fault: Fault{Kind: FaultConnectionReset}})
+	client := newFaultClient(t, server, injector)
+
+	_, err := client.GetCouriers(ctx)
+	tooManyRequests, ok := err.(*aftership.TooManyRequestsError)
+	if assert.True(t, ok, "expected a TooManyRequestsError, got %v", err) {
+		assert.Equal(t, reset.Unix(), tooManyRequests.RateLimit.Reset)
+	}
+
+	_, err = client.GetCouriers(ctx)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "error unmarshalling the JSON response"), err.Error())
+
+	_, err = client.GetTracking(ctx, id, aftership.GetTrackingParams{})
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "error unmarshalling the JSON response"), err.Error())
+
+	_, err = client.GetTracking(ctx, id, aftership.GetTrackingParams{})
+	assert.True(t, errors.Is(err, syscall.ECONNRESET), "expected a connection reset, got %v", err)
+
+	// No fault left
+	_, err = client.GetTracking(ctx, id, aftership.GetTrackingParams{})
+	assert.Nil(t, err)
+	_, err = client.GetCouriers(ctx)
+	assert.Nil(t, err)
+
+	injected := injector.Injected()
+	if assert.Len(t, injected, 4) {
+		assert.Equal(t, FaultServerError, injected[1].Fault.Kind)
+		assert.Equal(t, 2, injected[1].Call)
+		assert.Equal(t, "/trackings/"+tracking.ID, injected[3].Path)
+	}
+}
+
+func TestFaultInjectorDelays(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetRateLimit(0)
+
+	injector := NewFaultInjector(nil).
+		Inject(FaultRule{Path: "/couriers", Fault: Fault{Kind: FaultLatency, Delay: 50 * time.Millisecond}}).
+		Inject(FaultRule{Path: "/couriers/all", Fault: Fault{Kind: FaultSlowBody, Delay: time.Millisecond, ChunkSize: 64}})
+	client := newFaultClient(t, server, injector)
+
+	start := time.Now()
+	_, err := client.GetCouriers(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.GetCouriers(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected a timeout, got %v", err)
+
+	list, err := client.GetAllCouriers(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, len(DefaultCouriers), list.Total)
+
+	assert.Len(t, injector.Injected(), 3)
+}
@@ -0,0 +1,357 @@
+package aftershiptest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+// Call is a call of a Mock method. Args are the arguments of the call, but for the context.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Response is a scripted response of a Mock method. Result must be of the result type of the method,
+// e.g. aftership.Tracking for GetTracking.
+type Response struct {
+	Result interface{}
+	Err    error
+}
+
+// Mock is a configurable aftership.API for unit tests. It is safe for concurrent use.
+//
+// Every call is recorded. A call returns the next scripted response of its method, added by Respond,
+// or the result of the function field of its method when no response is left, e.g. GetTrackingFunc.
+// It fails with an error when there is neither.
+type Mock struct {
+	CreateTrackingFunc          func(ctx context.Context, params aftership.CreateTrackingParams) (aftership.Tracking, error)
+	DeleteTrackingFunc          func(ctx context.Context, identifier aftership.TrackingIdentifier) (aftership.Tracking, error)
+	GetTrackingsFunc            func(ctx context.Context, params aftership.GetTrackingsParams) (aftership.PagedTrackings, error)
+	GetTrackingFunc             func(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.GetTrackingParams) (aftership.Tracking, error)
+	UpdateTrackingFunc          func(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.UpdateTrackingParams) (aftership.Tracking, error)
+	RetrackTrackingFunc         func(ctx context.Context, identifier aftership.TrackingIdentifier) (aftership.Tracking, error)
+	MarkTrackingAsCompletedFunc func(ctx context.Context, identifier aftership.TrackingIdentifier, status aftership.TrackingCompletedStatus) (aftership.Tracking, error)
+
+	GetLastCheckpointFunc func(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.GetCheckpointParams) (aftership.LastCheckpoint, error)
+
+	GetCouriersFunc    func(ctx context.Context) (aftership.CourierList, error)
+	GetAllCouriersFunc func(ctx context.Context) (aftership.CourierList, error)
+	DetectCouriersFunc func(ctx context.Context, params aftership.CourierDetectionParams) (aftership.CourierList, error)
+
+	GetNotificationFunc    func(ctx context.Context, identifier aftership.TrackingIdentifier) (aftership.Notification, error)
+	AddNotificationFunc    func(ctx context.Context, identifier aftership.TrackingIdentifier, notification aftership.Notification) (aftership.Notification, error)
+	RemoveNotificationFunc func(ctx context.Context, identifier aftership.TrackingIdentifier, notification aftership.Notification) (aftership.Notification, error)
+
+	BatchPredictEstimatedDeliveryDateFunc func(ctx context.Context, params []aftership.EstimatedDeliveryDate) (aftership.EstimatedDeliveryDates, error)
+
+	mu        sync.Mutex
+	calls     []Call
+	responses map[string][]Response
+}
+
+var _ aftership.API = (*Mock)(nil)
+
+// Respond scripts the next response of method, after the responses already scripted.
+func (m *Mock) Respond(method string, result interface{}, err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.responses == nil {
+		m.responses = make(map[string][]Response)
+	}
+	m.responses[method] = append(m.responses[method], Response{Result: result, Err: err})
+	return m
+}
+
+// Calls returns the calls of all methods, in call order.
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the calls of method, in call order.
+func (m *Mock) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the calls and the responses left.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.responses = nil
+}
+
+// call records a call of method and pops its next scripted response
+func (m *Mock) call(method string, args ...interface{}) (Response, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+	responses := m.responses[method]
+	if len(responses) == 0 {
+		return Response{}, false
+	}
+	m.responses[method] = responses[1:]
+	return responses[0], true
+}
+
+// errNotScripted is the error of a call without scripted response nor function
+func errNotScripted(method string) error {
+	return errors.Errorf("no response scripted for %s", method)
+}
+
+// errResultType is the error of a scripted response with a result of the wrong type
+func errResultType(method string, result interface{}) error {
+	return errors.Errorf("invalid result type %T scripted for %s", result, method)
+}
+
+// trackingResult returns the tracking of a scripted response
+func trackingResult(method string, response Response) (aftership.Tracking, error) {
+	if response.Result == nil {
+		return aftership.Tracking{}, response.Err
+	}
+	tracking, ok := response.Result.(aftership.Tracking)
+	if !ok {
+		return aftership.Tracking{}, errResultType(method, response.Result)
+	}
+	return tracking, response.Err
+}
+
+// courierListResult returns the courier list of a scripted response
+func courierListResult(method string, response Response) (aftership.CourierList, error) {
+	if response.Result == nil {
+		return aftership.CourierList{}, response.Err
+	}
+	list, ok := response.Result.(aftership.CourierList)
+	if !ok {
+		return aftership.CourierList{}, errResultType(method, response.Result)
+	}
+	return list, response.Err
+}
+
+// notificationResult returns the notification of a scripted response
+func notificationResult(method string, response Response) (aftership.Notification, error) {
+	if response.Result == nil {
+		return aftership.Notification{}, response.Err
+	}
+	notification, ok := response.Result.(aftership.Notification)
+	if !ok {
+		return aftership.Notification{}, errResultType(method, response.Result)
+	}
+	return notification, response.Err
+}
+
+// CreateTracking implements aftership.TrackingsAPI.
+func (m *Mock) CreateTracking(ctx context.Context, params aftership.CreateTrackingParams) (aftership.Tracking, error) {
+	const method = "CreateTracking"
+	if response, ok := m.call(method, params); ok {
+		return trackingResult(method, response)
+	}
+	if m.CreateTrackingFunc != nil {
+		return m.CreateTrackingFunc(ctx, params)
+	}
+	return aftership.Tracking{}, errNotScripted(method)
+}
+
+// DeleteTracking implements aftership.TrackingsAPI.
+func (m *Mock) DeleteTracking(ctx context.Context, identifier aftership.TrackingIdentifier) (aftership.Tracking, error) {
+	const method = "DeleteTracking"
+	if response, ok := m.call(method, identifier); ok {
+		return trackingResult(method, response)
+	}
+	if m.DeleteTrackingFunc != nil {
+		return m.DeleteTrackingFunc(ctx, identifier)
+	}
+	return aftership.Tracking{}, errNotScripted(method)
+}
+
+// GetTrackings implements aftership.TrackingsAPI.
+func (m *Mock) GetTrackings(ctx context.Context, params aftership.GetTrackingsParams) (aftership.PagedTrackings, error) {
+	const method = "GetTrackings"
+	if response, ok := m.call(method, params); ok {
+		if response.Result == nil {
+			return aftership.PagedTrackings{}, response.Err
+		}
+		paged, ok := response.Result.(aftership.PagedTrackings)
+		if !ok {
+			return aftership.PagedTrackings{}, errResultType(method, response.Result)
+		}
+		return paged, response.Err
+	}
+	if m.GetTrackingsFunc != nil {
+		return m.GetTrackingsFunc(ctx, params)
+	}
+	return aftership.PagedTrackings{}, errNotScripted(method)
+}
+
+// GetTracking implements aftership.TrackingsAPI.
+func (m *Mock) GetTracking(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.GetTrackingParams) (aftership.Tracking, error) {
+	const method = "GetTracking"
+	if response, ok := m.call(method, identifier, params); ok {
+		return trackingResult(method, response)
+	}
+	if m.GetTrackingFunc != nil {
+		return m.GetTrackingFunc(ctx, identifier, params)
+	}
+	return aftership.Tracking{}, errNotScripted(method)
+}
+
+// UpdateTracking implements aftership.TrackingsAPI.
+func (m *Mock) UpdateTracking(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.UpdateTrackingParams) (aftership.Tracking, error) {
+	const method = "UpdateTracking"
+	if response, ok := m.call(method, identifier, params); ok {
+		return trackingResult(method, response)
+	}
+	if m.UpdateTrackingFunc != nil {
+		return m.UpdateTrackingFunc(ctx, identifier, params)
+	}
+	return aftership.Tracking{}, errNotScripted(method)
+}
+
+// RetrackTracking implements aftership.TrackingsAPI.
+func (m *Mock) RetrackTracking(ctx context.Context, identifier aftership.TrackingIdentifier) (aftership.Tracking, error) {
+	const method = "RetrackTracking"
+	if response, ok := m.call(method, identifier); ok {
+		return trackingResult(method, response)
+	}
+	if m.RetrackTrackingFunc != nil {
+		return m.RetrackTrackingFunc(ctx, identifier)
+	}
+	return aftership.Tracking{}, errNotScripted(method)
+}
+
+// MarkTrackingAsCompleted implements aftership.TrackingsAPI.
+func (m *Mock) MarkTrackingAsCompleted(ctx context.Context, identifier aftership.TrackingIdentifier, status aftership.TrackingCompletedStatus) (aftership.Tracking, error) {
+	const method = "MarkTrackingAsCompleted"
+	if response, ok := m.call(method, identifier, status); ok {
+		return trackingResult(method, response)
+	}
+	if m.MarkTrackingAsCompletedFunc != nil {
+		return m.MarkTrackingAsCompletedFunc(ctx, identifier, status)
+	}
+	return aftership.Tracking{}, errNotScripted(method)
+}
+
+// GetLastCheckpoint implements aftership.CheckpointsAPI.
+func (m *Mock) GetLastCheckpoint(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.GetCheckpointParams) (aftership.LastCheckpoint, error) {
+	const method = "GetLastCheckpoint"
+	if response, ok := m.call(method, identifier, params); ok {
+		if response.Result == nil {
+			return aftership.LastCheckpoint{}, response.Err
+		}
+		lastCheckpoint, ok := response.Result.(aftership.LastCheckpoint)
+		if !ok {
+			return aftership.LastCheckpoint{}, errResultType(method, response.Result)
+		}
+		return lastCheckpoint, response.Err
+	}
+	if m.GetLastCheckpointFunc != nil {
+		return m.GetLastCheckpointFunc(ctx, identifier, params)
+	}
+	return aftership.LastCheckpoint{}, errNotScripted(method)
+}
+
+// GetCouriers implements aftership.CouriersAPI.
+func (m *Mock) GetCouriers(ctx context.Context) (aftership.CourierList, error) {
+	const method = "GetCouriers"
+	if response, ok := m.call(method); ok {
+		return courierListResult(method, response)
+	}
+	if m.GetCouriersFunc != nil {
+		return m.GetCouriersFunc(ctx)
+	}
+	return aftership.CourierList{}, errNotScripted(method)
+}
+
+// GetAllCouriers implements aftership.CouriersAPI.
+func (m *Mock) GetAllCouriers(ctx context.Context) (aftership.CourierList, error) {
+	const method = "GetAllCouriers"
+	if response, ok := m.call(method); ok {
+		return courierListResult(method, response)
+	}
+	if m.GetAllCouriersFunc != nil {
+		return m.GetAllCouriersFunc(ctx)
+	}
+	return aftership.CourierList{}, errNotScripted(method)
+}
+
+// DetectCouriers implements aftership.CouriersAPI.
+func (m *Mock) DetectCouriers(ctx context.Context, params aftership.CourierDetectionParams) (aftership.CourierList, error) {
+	const method = "DetectCouriers"
+	if response, ok := m.call(method, params); ok {
+		return courierListResult(method, response)
+	}
+	if m.DetectCouriersFunc != nil {
+		return m.DetectCouriersFunc(ctx, params)
+	}
+	return aftership.CourierList{}, errNotScripted(method)
+}
+
+// GetNotification implements aftership.NotificationsAPI.
+func (m *Mock) GetNotification(ctx context.Context, identifier aftership.TrackingIdentifier) (aftership.Notification, error) {
+	const method = "GetNotification"
+	if response, ok := m.call(method, identifier); ok {
+		return notificationResult(method, response)
+	}
+	if m.GetNotificationFunc != nil {
+		return m.GetNotificationFunc(ctx, identifier)
+	}
+	return aftership.Notification{}, errNotScripted(method)
+}
+
+// AddNotification implements aftership.NotificationsAPI.
+func (m *Mock) AddNotification(ctx context.Context, identifier aftership.TrackingIdentifier, notification aftership.Notification) (aftership.Notification, error) {
+	const method = "AddNotification"
+	if response, ok := m.call(method, identifier, notification); ok {
+		return notificationResult(method, response)
+	}
+	if m.AddNotificationFunc != nil {
+		return m.AddNotificationFunc(ctx, identifier, notification)
+	}
+	return aftership.Notification{}, errNotScripted(method)
+}
+
+// RemoveNotification implements aftership.NotificationsAPI.
+func (m *Mock) RemoveNotification(ctx context.Context, identifier aftership.TrackingIdentifier, notification aftership.Notification) (aftership.Notification, error) {
+	const method = "RemoveNotification"
+	if response, ok := m.call(method, identifier, notification); ok {
+		return notificationResult(method, response)
+	}
+	if m.RemoveNotificationFunc != nil {
+		return m.RemoveNotificationFunc(ctx, identifier, notification)
+	}
+	return aftership.Notification{}, errNotScripted(method)
+}
+
+// BatchPredictEstimatedDeliveryDate implements aftership.EstimatedDeliveryDatesAPI.
+func (m *Mock) BatchPredictEstimatedDeliveryDate(ctx context.Context, params []aftership.EstimatedDeliveryDate) (aftership.EstimatedDeliveryDates, error) {
+	const method = "BatchPredictEstimatedDeliveryDate"
+	if response, ok := m.call(method, params); ok {
+		if response.Result == nil {
+			return aftership.EstimatedDeliveryDates{}, response.Err
+		}
+		dates, ok := response.Result.(aftership.EstimatedDeliveryDates)
+		if !ok {
+			return aftership.EstimatedDeliveryDates{}, errResultType(method, response.Result)
+		}
+		return dates, response.Err
+	}
+	if m.BatchPredictEstimatedDeliveryDateFunc != nil {
+		return m.BatchPredictEstimatedDeliveryDateFunc(ctx, params)
+	}
+	return aftership.EstimatedDeliveryDates{}, errNotScripted(method)
+}
@@ -0,0 +1,94 @@
+package aftershiptest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockScriptedResponses(t *testing.T) {
+	ctx := context.Background()
+	id := aftership.TrackingID("5b7658cec7c33c0e007de3c5")
+	notFound := &aftership.APIError{Code: CodeTrackingNotFound, Type: "NotFound"}
+
+	mock := &Mock{}
+	mock.Respond("GetTracking", aftership.Tracking{ID: string(id), Tag: aftership.TagInTransit}, nil).
+		Respond("GetTracking", nil, notFound)
+	mock.GetTrackingFunc = func(ctx context.Context, identifier aftership.TrackingIdentifier, params aftership.GetTrackingParams) (aftership.Tracking, error) {
+		return aftership.Tracking{ID: "fallback"}, nil
+	}
+
+	tracking, err := mock.GetTracking(ctx, id, aftership.GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, aftership.TagInTransit, tracking.Tag)
+
+	_, err = mock.GetTracking(ctx, id, aftership.GetTrackingParams{Fields: "tag"})
+	assert.Equal(t, notFound, err)
+
+	// The function answers once the scripted responses are used up
+	tracking, err = mock.GetTracking(ctx, id, aftership.GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, "fallback", tracking.ID)
+
+	calls := mock.CallsTo("GetTracking")
+	assert.Len(t, calls, 3)
+	assert.Equal(t, []interface{}{id, aftership.GetTrackingParams{Fields: "tag"}}, calls[1].Args)
+}
+
+func TestMockErrors(t *testing.T) {
+	ctx := context.Background()
+	mock := &Mock{}
+
+	_, err := mock.GetCouriers(ctx)
+	assert.EqualError(t, err, "no response scripted for GetCouriers")
+
+	mock.Respond("GetCouriers", aftership.Tracking{}, nil)
+	_, err = mock.GetCouriers(ctx)
+	assert.EqualError(t, err, "invalid result type aftership.Tracking scripted for GetCouriers")
+
+	failure := errors.New("failure")
+	mock.Respond("AddNotification", aftership.Notification{Emails: []string{"a@example.com"}}, failure)
+	notification, err := mock.AddNotification(ctx, aftership.TrackingID("1"), aftership.Notification{})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, []string{"a@example.com"}, notification.Emails)
+
+	assert.Equal(t, []string{"GetCouriers", "GetCouriers", "AddNotification"}, methods(mock.Calls()))
+	mock.Reset()
+	assert.Empty(t, mock.Calls())
+}
+
+func methods(calls []Call) []string {
+	var methods []string
+	for _, call := range calls {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+func TestMockHelpers(t *testing.T) {
+	ctx := context.Background()
+	mock := &Mock{}
+
+	// The helpers of the aftership package take the endpoint interfaces
+	mock.Respond("GetTrackings", aftership.PagedTrackings{Limit: 2, Count: 3, Page: 1,
+		Trackings: []aftership.Tracking{{ID: "1"}, {ID: "2"}}}, nil).
+		Respond("GetTrackings", aftership.PagedTrackings{Limit: 2, Count: 3, Page: 2,
+			Trackings: []aftership.Tracking{{ID: "3"}}}, nil)
+	var ids []string
+	err := aftership.WalkTrackings(ctx, mock, aftership.GetTrackingsParams{}, func(tracking aftership.Tracking) error {
+		ids = append(ids, tracking.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+	assert.Equal(t, []interface{}{aftership.GetTrackingsParams{Page: 2}}, mock.CallsTo("GetTrackings")[1].Args)
+
+	mock.Respond("GetAllCouriers", aftership.CourierList{Total: 1, Couriers: []aftership.Courier{{Slug: "ups"}}}, nil)
+	courier, ok, err := aftership.NewAPICourierRegistry(mock, 0).Courier(ctx, "ups")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "ups", courier.Slug)
+}
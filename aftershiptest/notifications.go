@@ -0,0 +1,96 @@
+package aftershiptest
+
+import (
+	"net/http"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func (s *Server) serveNotifications(w http.ResponseWriter, r *http.Request, segments []string) {
+	action := ""
+	if n := len(segments); n > 1 && (segments[n-1] == "add" || segments[n-1] == "remove") {
+		action, segments = segments[n-1], segments[:n-1]
+	}
+
+	method := http.MethodGet
+	if action != "" {
+		method = http.MethodPost
+	}
+	if !allowMethod(w, r, method) {
+		return
+	}
+
+	_, tracking := s.findTrackingOrWriteError(w, segments)
+	if tracking == nil {
+		return
+	}
+
+	if action != "" {
+		var request struct {
+			Notification aftership.Notification `json:"notification"`
+		}
+		if !decodeBody(w, r, &request) {
+			return
+		}
+
+		update := addReceivers
+		if action == "remove" {
+			update = removeReceivers
+		}
+		notification := request.Notification
+		tracking.Emails = update(tracking.Emails, notification.Emails)
+		tracking.SMSes = update(tracking.SMSes, notification.SMSes)
+		tracking.IOS = update(tracking.IOS, notification.IOS)
+		tracking.Android = update(tracking.Android, notification.Android)
+
+		now := s.now().UTC()
+		tracking.UpdatedAt = &now
+	}
+
+	writeData(w, http.StatusOK, map[string]interface{}{
+		"notification": aftership.Notification{
+			Emails:  nonNil(tracking.Emails),
+			SMSes:   nonNil(tracking.SMSes),
+			IOS:     tracking.IOS,
+			Android: tracking.Android,
+		},
+	})
+}
+
+// addReceivers appends the receivers missing from current
+func addReceivers(current []string, receivers []string) []string {
+	for _, receiver := range receivers {
+		if !contains(current, receiver) {
+			current = append(current, receiver)
+		}
+	}
+	return current
+}
+
+// removeReceivers returns current without receivers
+func removeReceivers(current []string, receivers []string) []string {
+	kept := current[:0]
+	for _, receiver := range current {
+		if !contains(receivers, receiver) {
+			kept = append(kept, receiver)
+		}
+	}
+	return kept
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNil returns an empty slice for nil, which the API encodes as an empty array
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,216 @@
+package aftershiptest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+// APIKey is the API key of the clients returned by Server.Client
+const APIKey = "aftershiptest-api-key"
+
+// DefaultRateLimit is the default number of requests per second of a Server, as in the AfterShip API
+const DefaultRateLimit = 10
+
+// Meta codes of the AfterShip API errors returned by the Server
+const (
+	CodeInvalidJSON         = 4001
+	CodeTrackingExists      = 4003
+	CodeTrackingNotFound    = 4004
+	CodeInvalidValue        = 4005
+	CodeTrackingRequired    = 4007
+	CodeCannotDetectCourier = 4012
+	CodeRetrackNotAllowed   = 4016
+	CodeUnauthorized        = 401
+	CodeNotFound            = 404
+	CodeMethodNotAllowed    = 405
+	CodeTooManyRequests     = 429
+)
+
+// datedAPIVersion matches the versions of the date-versioned APIs, served under /tracking/<version>
+var datedAPIVersion = regexp.MustCompile(`^\d{4}-\d{2}$`)
+
+// Server is a fake AfterShip API server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Predict returns the prediction of an estimated delivery date request of BatchPredictEstimatedDeliveryDate.
+	// Defaults to a delivery 3 days after the pickup, within a range of one day.
+	Predict func(aftership.EstimatedDeliveryDate) aftership.EstimatedDeliveryDate
+
+	mu            sync.Mutex
+	now           func() time.Time
+	trackings     []*aftership.Tracking // In creation order
+	retracks      map[string]int
+	nextID        int
+	couriers      []aftership.Courier
+	activeSlugs   map[string]bool
+	rateLimit     int
+	remaining     int
+	rateLimitNext time.Time
+}
+
+// NewServer starts and returns a new Server with DefaultCouriers, all of them active.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		now:       time.Now,
+		retracks:  make(map[string]int),
+		rateLimit: DefaultRateLimit,
+	}
+	s.SetCouriers(DefaultCouriers)
+	s.Predict = s.predict
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns an aftership.Client calling the server with APIKey.
+func (s *Server) Client() *aftership.Client {
+	client, _ := aftership.NewClient(aftership.Config{
+		APIKey:     APIKey,
+		BaseURL:    s.URL,
+		HTTPClient: s.Server.Client(),
+	})
+	return client
+}
+
+// DatedClient returns an aftership.Client calling the server with APIKey and a date-versioned API such as
+// aftership.APIVersion202407, under the /tracking/<version> path of the server.
+func (s *Server) DatedClient(version aftership.APIVersion) *aftership.Client {
+	client, _ := aftership.NewClient(aftership.Config{
+		APIKey:     APIKey,
+		BaseURL:    s.URL + "/tracking/" + string(version),
+		APIVersion: version,
+		HTTPClient: s.Server.Client(),
+	})
+	return client
+}
+
+// SetRateLimit sets the number of requests allowed per second. Requests above the limit get a 429 response.
+// A limit of 0 or less disables the rate limit.
+func (s *Server) SetRateLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = limit
+	s.remaining = limit
+	s.rateLimitNext = time.Time{}
+}
+
+// ExhaustRateLimit uses up the requests of the current second, so the next requests get a 429 response until the reset.
+func (s *Server) ExhaustRateLimit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refillRateLimit()
+	s.remaining = 0
+}
+
+// refillRateLimit starts a new rate limit window when the current one is over. s.mu must be held.
+func (s *Server) refillRateLimit() {
+	now := s.now()
+	if !now.Before(s.rateLimitNext) {
+		s.remaining = s.rateLimit
+		s.rateLimitNext = now.Truncate(time.Second).Add(time.Second)
+	}
+}
+
+// takeRateLimit counts a request against the rate limit and sets its headers. s.mu must be held.
+func (s *Server) takeRateLimit(w http.ResponseWriter) bool {
+	if s.rateLimit <= 0 {
+		return true
+	}
+
+	s.refillRateLimit()
+	allowed := s.remaining > 0
+	if allowed {
+		s.remaining--
+	}
+
+	w.Header().Set("x-ratelimit-limit", strconv.Itoa(s.rateLimit))
+	w.Header().Set("x-ratelimit-remaining", strconv.Itoa(s.remaining))
+	w.Header().Set("x-ratelimit-reset", strconv.FormatInt(s.rateLimitNext.Unix(), 10))
+	return allowed
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("aftership-api-key") == "" && r.Header.Get("as-api-key") == "" {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized", "Invalid API key.")
+		return
+	}
+
+	if !s.takeRateLimit(w) {
+		writeError(w, http.StatusTooManyRequests, CodeTooManyRequests, "TooManyRequests",
+			"You have exceeded the API call rate limit. Default limit is 10 requests per second.")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	dated := len(segments) > 2 && segments[0] == "tracking" && datedAPIVersion.MatchString(segments[1])
+	if dated {
+		segments = segments[2:]
+	}
+
+	switch segments[0] {
+	case "trackings":
+		s.serveTrackings(w, r, segments[1:], dated)
+	case "last_checkpoint":
+		s.serveLastCheckpoint(w, r, segments[1:])
+	case "notifications":
+		s.serveNotifications(w, r, segments[1:])
+	case "couriers":
+		s.serveCouriers(w, r, segments[1:])
+	case "estimated-delivery-date":
+		if len(segments) == 2 && segments[1] == "predict-batch" && allowMethod(w, r, http.MethodPost) {
+			s.servePredictBatch(w, r)
+			return
+		}
+		writeNotFound(w)
+	default:
+		writeNotFound(w)
+	}
+}
+
+// allowMethod writes a 405 response and returns false when r does not use method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "MethodNotAllowed", "The method is not allowed.")
+	return false
+}
+
+// decodeBody decodes the JSON body of r into v, and writes a 4001 response when it is invalid
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidJSON, "BadRequest", "Invalid JSON data.")
+		return false
+	}
+	return true
+}
+
+func writeData(w http.ResponseWriter, status int, data interface{}) {
+	writeResponse(w, status, aftership.Meta{Code: status}, data)
+}
+
+func writeError(w http.ResponseWriter, status int, code int, errorType string, message string) {
+	writeResponse(w, status, aftership.Meta{Code: code, Type: errorType, Message: message}, struct{}{})
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, CodeNotFound, "NotFound", "The URI requested is invalid or the resource requested does not exist.")
+}
+
+func writeResponse(w http.ResponseWriter, status int, meta aftership.Meta, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(aftership.Response{Meta: meta, Data: data})
+}
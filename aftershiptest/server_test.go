@@ -0,0 +1,329 @@
+package aftershiptest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackingLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetRateLimit(0)
+	client := server.Client()
+	ctx := context.Background()
+
+	created, err := client.CreateTracking(ctx, aftership.CreateTrackingParams{
+		TrackingNumber: "1Z999AA10123456784",
+		Title:          "Order #1001",
+		Emails:         []string{"customer@example.com"},
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "ups", created.Slug) // Detected
+	assert.Equal(t, aftership.TagPending, created.Tag)
+	assert.True(t, created.Active)
+
+	id := aftership.TrackingID(created.ID)
+	byNumber := aftership.SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+
+	tracking, err := client.GetTracking(ctx, byNumber, aftership.GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, created.ID, tracking.ID)
+	assert.Equal(t, "Order #1001", tracking.Title)
+
+	updated, err := client.UpdateTracking(ctx, id, aftership.UpdateTrackingParams{Title: "Order #1002"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Order #1002", updated.Title)
+	assert.Equal(t, []string{"customer@example.com"}, updated.Emails)
+
+	// Retracking an active tracking is not allowed
+	_, err = client.RetrackTracking(ctx, id)
+	assertAPIError(t, err, CodeRetrackNotAllowed)
+
+	assert.True(t, server.AddCheckpoint(created.ID, aftership.Checkpoint{
+		Tag:            aftership.TagDelivered,
+		Subtag:         "Delivered_001",
+		Message:        "Delivered",
+		CheckpointTime: "2022-11-05T10:00:00Z",
+	}))
+	last, err := client.GetLastCheckpoint(ctx, id, aftership.GetCheckpointParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, aftership.TagDelivered, last.Tag)
+	assert.Equal(t, "Delivered", last.Checkpoint.Message)
+
+	for i := 0; i < aftership.MaxRetracks; i++ {
+		retracked, err := client.RetrackTracking(ctx, id)
+		assert.Nil(t, err)
+		assert.True(t, retracked.Active)
+		server.UpdateTracking(created.ID, func(tracking *aftership.Tracking) { tracking.Active = false })
+	}
+	_, err = client.RetrackTracking(ctx, id)
+	assertAPIError(t, err, CodeRetrackNotAllowed)
+
+	completed, err := client.MarkTrackingAsCompleted(ctx, id, aftership.TrackingCompletedStatusLost)
+	assert.Nil(t, err)
+	assert.Equal(t, aftership.TagException, completed.Tag)
+	assert.False(t, completed.Active)
+
+	deleted, err := client.DeleteTracking(ctx, byNumber)
+	assert.Nil(t, err)
+	assert.Equal(t, created.ID, deleted.ID)
+
+	_, err = client.GetTracking(ctx, id, aftership.GetTrackingParams{})
+	assertAPIError(t, err, CodeTrackingNotFound)
+	assert.Empty(t, server.Trackings())
+}
+
+func TestCreateTrackingErrors(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	params := aftership.CreateTrackingParams{Slug: "dhl", TrackingNumber: "1234567890"}
+	existing, err := client.CreateTracking(ctx, params)
+	assert.Nil(t, err)
+
+	_, err = client.CreateTracking(ctx, params)
+	assertAPIError(t, err, CodeTrackingExists)
+
+	// Detection is limited to the active couriers
+	server.SetCouriers(DefaultCouriers, "ups")
+	_, err = client.CreateTracking(ctx, aftership.CreateTrackingParams{TrackingNumber: "RR123456785GB"})
+	assertAPIError(t, err, CodeCannotDetectCourier)
+
+	tracking, ok := server.Tracking(existing.ID)
+	assert.True(t, ok)
+	assert.Equal(t, "dhl", tracking.Slug)
+	assert.Len(t, server.Trackings(), 1)
+}
+
+func TestGetTrackingsPagination(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	start := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 250; i++ {
+		createdAt := start.Add(time.Duration(i) * time.Hour)
+		slug := "ups"
+		if i%2 == 1 {
+			slug = "fedex"
+		}
+		server.AddTracking(aftership.Tracking{
+			Slug:           slug,
+			TrackingNumber: fmt.Sprintf("TN%04d", i),
+			CreatedAt:      &createdAt,
+		})
+	}
+
+	page, err := client.GetTrackings(ctx, aftership.GetTrackingsParams{Limit: 10, Page: 2, Slug: "ups"})
+	assert.Nil(t, err)
+	assert.Equal(t, 125, page.Count)
+	assert.Equal(t, 10, page.Limit)
+	assert.Len(t, page.Trackings, 10)
+	assert.Equal(t, "TN0228", page.Trackings[0].TrackingNumber) // Most recent first
+
+	var count int
+	err = aftership.WalkTrackings(ctx, client, aftership.GetTrackingsParams{}, func(aftership.Tracking) error {
+		count++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 250, count)
+
+	page, err = client.GetTrackings(ctx, aftership.GetTrackingsParams{
+		CreatedAtMin: start.Add(240 * time.Hour).Format(time.RFC3339),
+		Keyword:      "tn024",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, page.Count)
+}
+
+func TestDatedAPI(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetRateLimit(0)
+	client := server.DatedClient(aftership.APIVersion202407)
+	ctx := context.Background()
+
+	created, err := client.CreateTracking(ctx, aftership.CreateTrackingParams{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+		Emails:         []string{"customer@example.com"},
+		CustomerName:   "Jane",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"customer@example.com"}, created.Emails)
+	assert.Equal(t, "Jane", created.CustomerName)
+
+	// Trackings are served unwrapped, with customers
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/tracking/2024-07/trackings/"+created.ID, nil)
+	req.Header.Set("as-api-key", APIKey)
+	resp, err := server.Server.Client().Do(req)
+	if assert.Nil(t, err) {
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+		assert.Equal(t, created.ID, body.Data["id"])
+		assert.NotNil(t, body.Data["customers"])
+		assert.Nil(t, body.Data["emails"])
+		assert.Nil(t, body.Data["custom_name"])
+	}
+
+	// Trackings given by slug and tracking number are looked up by ID
+	byNumber := aftership.SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+	updated, err := client.UpdateTracking(ctx, byNumber, aftership.UpdateTrackingParams{Title: "Order #1001"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Order #1001", updated.Title)
+	assert.Equal(t, []string{"customer@example.com"}, updated.Emails)
+
+	for i := 0; i < 4; i++ {
+		server.AddTracking(aftership.Tracking{Slug: "fedex", TrackingNumber: fmt.Sprintf("TN%04d", i)})
+	}
+	page, err := client.GetTrackings(ctx, aftership.GetTrackingsParams{Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, page.Count)
+	assert.True(t, page.HasNextPage)
+	assert.Equal(t, "2", page.NextCursor)
+
+	var count int
+	err = aftership.WalkTrackings(ctx, client, aftership.GetTrackingsParams{Limit: 2}, func(aftership.Tracking) error {
+		count++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, count)
+
+	deleted, err := client.DeleteTracking(ctx, byNumber)
+	assert.Nil(t, err)
+	assert.Equal(t, created.ID, deleted.ID)
+	assert.Len(t, server.Trackings(), 4)
+}
+
+func TestRateLimit(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	now := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	server.now = func() time.Time { return now }
+	client := server.Client()
+	ctx := context.Background()
+
+	_, err := client.GetCouriers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultRateLimit, client.GetRateLimit().Limit)
+	assert.Equal(t, DefaultRateLimit-1, client.GetRateLimit().Remaining)
+	assert.Equal(t, now.Unix()+1, client.GetRateLimit().Reset)
+
+	server.ExhaustRateLimit()
+	_, err = client.GetCouriers(ctx)
+	tooManyRequests, ok := err.(*aftership.TooManyRequestsError)
+	assert.True(t, ok)
+	if ok {
+		assert.Equal(t, CodeTooManyRequests, tooManyRequests.Code)
+		assert.Equal(t, 0, tooManyRequests.RateLimit.Remaining)
+	}
+
+	// A new window refills the limit
+	now = now.Add(time.Second)
+	_, err = client.GetCouriers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultRateLimit-1, client.GetRateLimit().Remaining)
+
+	server.SetRateLimit(0)
+	for i := 0; i < 2*DefaultRateLimit; i++ {
+		_, err = client.GetCouriers(ctx)
+		assert.Nil(t, err)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/couriers")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNotifications(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	tracking := server.AddTracking(aftership.Tracking{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+	id := aftership.TrackingID(tracking.ID)
+
+	notification, err := client.AddNotification(ctx, id, aftership.Notification{
+		Emails:  []string{"a@example.com", "b@example.com"},
+		SMSes:   []string{"+85291234567"},
+		Android: []string{"device-1"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, notification.Emails)
+
+	notification, err = client.RemoveNotification(ctx, id, aftership.Notification{Emails: []string{"a@example.com"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b@example.com"}, notification.Emails)
+
+	notification, err = client.GetNotification(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, aftership.Notification{
+		Emails:  []string{"b@example.com"},
+		SMSes:   []string{"+85291234567"},
+		Android: []string{"device-1"},
+	}, notification)
+}
+
+func TestCouriersAndPredictions(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetCouriers(DefaultCouriers, "ups", "royal-mail")
+	client := server.Client()
+	ctx := context.Background()
+
+	active, err := client.GetCouriers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, active.Total)
+
+	all, err := client.GetAllCouriers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, len(DefaultCouriers), all.Total)
+
+	detected, err := client.DetectCouriers(ctx, aftership.CourierDetectionParams{TrackingNumber: "RR123456785GB"})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, detected.Total) {
+		assert.Equal(t, "royal-mail", detected.Couriers[0].Slug)
+		assert.Equal(t, "https://www.royalmail.com", detected.Couriers[0].WebURL)
+	}
+
+	dates, err := client.BatchPredictEstimatedDeliveryDate(ctx, []aftership.EstimatedDeliveryDate{
+		{Slug: "ups", PickupTime: "2022-11-01 10:00:00"},
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, dates.Dates, 1) {
+		assert.Equal(t, "2022-11-04", dates.Dates[0].EstimatedDeliveryDate)
+		assert.Equal(t, "2022-11-03", dates.Dates[0].EstimatedDeliveryDateMin)
+		assert.Equal(t, "2022-11-05", dates.Dates[0].EstimatedDeliveryDateMax)
+	}
+}
+
+func assertAPIError(t *testing.T, err error, code int) {
+	t.Helper()
+	apiError, ok := err.(*aftership.APIError)
+	if assert.True(t, ok, "expected an APIError, got %v", err) {
+		assert.Equal(t, code, apiError.Code)
+	}
+}
@@ -0,0 +1,682 @@
+package aftershiptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+// Default and maximum page sizes of GetTrackings
+const (
+	defaultLimit = 100
+	maxLimit     = 200
+)
+
+// AddTracking adds tracking to the server as is, and returns it. The ID, creation and update times are set
+// when missing, and the tag defaults to Pending. It does not check for duplicates.
+func (s *Server) AddTracking(tracking aftership.Tracking) aftership.Tracking {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.addTracking(tracking)
+	return copyTracking(stored)
+}
+
+func (s *Server) addTracking(tracking aftership.Tracking) *aftership.Tracking {
+	now := s.now().UTC()
+	if tracking.ID == "" {
+		s.nextID++
+		tracking.ID = fmt.Sprintf("%024x", s.nextID)
+	}
+	if tracking.CreatedAt == nil {
+		tracking.CreatedAt = &now
+	}
+	if tracking.UpdatedAt == nil {
+		tracking.UpdatedAt = &now
+	}
+	if tracking.Tag == "" {
+		tracking.Tag = aftership.TagPending
+		tracking.Active = true
+	}
+	if tracking.UniqueToken == "" {
+		tracking.UniqueToken = "fake-" + tracking.ID
+	}
+
+	stored := copyTracking(&tracking)
+	s.trackings = append(s.trackings, &stored)
+	return &stored
+}
+
+// Tracking returns the tracking with id.
+func (s *Server) Tracking(id string) (aftership.Tracking, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tracking := range s.trackings {
+		if tracking.ID == id {
+			return copyTracking(tracking), true
+		}
+	}
+	return aftership.Tracking{}, false
+}
+
+// Trackings returns all the trackings of the server, in creation order.
+func (s *Server) Trackings() []aftership.Tracking {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trackings := make([]aftership.Tracking, len(s.trackings))
+	for i, tracking := range s.trackings {
+		trackings[i] = copyTracking(tracking)
+	}
+	return trackings
+}
+
+// UpdateTracking calls update with the tracking with id, to change it in place. It returns false when there is no such tracking.
+func (s *Server) UpdateTracking(id string, update func(*aftership.Tracking)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tracking := range s.trackings {
+		if tracking.ID == id {
+			update(tracking)
+			now := s.now().UTC()
+			tracking.UpdatedAt = &now
+			return true
+		}
+	}
+	return false
+}
+
+// AddCheckpoint appends checkpoint to the tracking with id, as the carrier would.
+// The tag and subtag of the tracking follow the checkpoint, and a Delivered checkpoint makes the tracking inactive.
+func (s *Server) AddCheckpoint(id string, checkpoint aftership.Checkpoint) bool {
+	now := s.now().UTC()
+	return s.UpdateTracking(id, func(tracking *aftership.Tracking) {
+		if checkpoint.CreatedAt == nil {
+			checkpoint.CreatedAt = &now
+		}
+		if checkpoint.CheckpointTime == "" {
+			checkpoint.CheckpointTime = now.Format(time.RFC3339)
+		}
+		if checkpoint.Slug == "" {
+			checkpoint.Slug = tracking.Slug
+		}
+		tracking.Checkpoints = append(tracking.Checkpoints, checkpoint)
+		tracking.TrackedCount++
+		if checkpoint.Tag != "" {
+			tracking.Tag = checkpoint.Tag
+			tracking.Subtag = checkpoint.Subtag
+			tracking.SubtagMessage = checkpoint.SubtagMessage
+		}
+		if checkpoint.Tag == aftership.TagDelivered {
+			tracking.Active = false
+			tracking.ShipmentDeliveryDate = checkpoint.CheckpointTime
+		}
+	})
+}
+
+// findTracking returns the tracking identified by the path segments, an ID or a slug and a tracking number
+func (s *Server) findTracking(segments []string) (int, *aftership.Tracking) {
+	for i, tracking := range s.trackings {
+		switch len(segments) {
+		case 1:
+			if tracking.ID == segments[0] {
+				return i, tracking
+			}
+		case 2:
+			if tracking.Slug == segments[0] && tracking.TrackingNumber == segments[1] {
+				return i, tracking
+			}
+		}
+	}
+	return -1, nil
+}
+
+// findTrackingOrWriteError returns the tracking identified by the path segments, or writes a 4004 response
+func (s *Server) findTrackingOrWriteError(w http.ResponseWriter, segments []string) (int, *aftership.Tracking) {
+	if len(segments) < 1 || len(segments) > 2 {
+		writeNotFound(w)
+		return -1, nil
+	}
+
+	i, tracking := s.findTracking(segments)
+	if tracking == nil {
+		writeError(w, http.StatusNotFound, CodeTrackingNotFound, "NotFound", "Tracking does not exist.")
+	}
+	return i, tracking
+}
+
+// serveTrackings serves the trackings endpoints, of the date-versioned APIs when dated is set:
+// trackings identified by ID only, not wrapped in {"tracking": ...}, with customers and cursor pagination.
+func (s *Server) serveTrackings(w http.ResponseWriter, r *http.Request, segments []string, dated bool) {
+	if len(segments) == 0 || segments[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.getTrackings(w, r, dated)
+		case http.MethodPost:
+			s.createTracking(w, r, dated)
+		default:
+			allowMethod(w, r, http.MethodGet)
+		}
+		return
+	}
+
+	switch action := segments[len(segments)-1]; {
+	case (action == "retrack" || action == "mark-as-completed") && len(segments) > 1:
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		segments = segments[:len(segments)-1]
+		if dated && len(segments) != 1 {
+			writeNotFound(w)
+			return
+		}
+		_, tracking := s.findTrackingOrWriteError(w, segments)
+		if tracking == nil {
+			return
+		}
+		if action == "retrack" {
+			s.retrackTracking(w, tracking, dated)
+		} else {
+			s.markTrackingAsCompleted(w, r, tracking, dated)
+		}
+		return
+	}
+
+	if dated && len(segments) != 1 {
+		writeNotFound(w)
+		return
+	}
+	i, tracking := s.findTrackingOrWriteError(w, segments)
+	if tracking == nil {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeTracking(w, http.StatusOK, tracking, dated)
+	case http.MethodPut:
+		s.updateTracking(w, r, tracking, dated)
+	case http.MethodDelete:
+		s.trackings = append(s.trackings[:i], s.trackings[i+1:]...)
+		writeTracking(w, http.StatusOK, tracking, dated)
+	default:
+		allowMethod(w, r, http.MethodGet)
+	}
+}
+
+func (s *Server) getTrackings(w http.ResponseWriter, r *http.Request, dated bool) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 || dated {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	filter, ok := newTrackingFilter(w, query)
+	if !ok {
+		return
+	}
+
+	// Most recent trackings first
+	matched := make([]*aftership.Tracking, 0, len(s.trackings))
+	for i := len(s.trackings) - 1; i >= 0; i-- {
+		if filter.match(s.trackings[i]) {
+			matched = append(matched, s.trackings[i])
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(*matched[j].CreatedAt)
+	})
+
+	// The cursors of the date-versioned APIs are the offsets of their pages
+	offset := (page - 1) * limit
+	if dated && query.Get("cursor") != "" {
+		offset, err = strconv.Atoi(query.Get("cursor"))
+		if err != nil || offset < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidValue, "BadRequest", "The value of `cursor` is invalid.")
+			return
+		}
+	}
+
+	trackings := []aftership.Tracking{}
+	for i := offset; i < len(matched) && len(trackings) < limit; i++ {
+		trackings = append(trackings, copyTracking(matched[i]))
+	}
+
+	if dated {
+		pagination := datedPagination{Total: len(matched)}
+		if next := offset + len(trackings); next < len(matched) {
+			pagination.NextCursor = strconv.Itoa(next)
+			pagination.HasNextPage = true
+		}
+		datedTrackings := make([]map[string]interface{}, len(trackings))
+		for i := range trackings {
+			datedTrackings[i] = datedTracking(&trackings[i])
+		}
+		writeData(w, http.StatusOK, map[string]interface{}{"pagination": pagination, "trackings": datedTrackings})
+		return
+	}
+
+	writeData(w, http.StatusOK, aftership.PagedTrackings{
+		Limit:     limit,
+		Count:     len(matched),
+		Page:      page,
+		Trackings: trackings,
+	})
+}
+
+// trackingFilter matches the trackings of a GetTrackings query
+type trackingFilter struct {
+	slugs           map[string]bool
+	tags            map[string]bool
+	origins         map[string]bool
+	destinations    map[string]bool
+	trackingNumbers map[string]bool
+	keyword         string
+	createdAtMin    time.Time
+	createdAtMax    time.Time
+}
+
+// newTrackingFilter returns the filter of query, or writes a 4005 response when a date is invalid
+func newTrackingFilter(w http.ResponseWriter, query url.Values) (trackingFilter, bool) {
+	filter := trackingFilter{
+		slugs:           splitSet(query.Get("slug")),
+		tags:            splitSet(query.Get("tag")),
+		origins:         splitSet(query.Get("origin")),
+		destinations:    splitSet(query.Get("destination")),
+		trackingNumbers: splitSet(query.Get("tracking_numbers")),
+		keyword:         strings.ToLower(query.Get("keyword")),
+	}
+
+	for name, t := range map[string]*time.Time{
+		"created_at_min": &filter.createdAtMin,
+		"created_at_max": &filter.createdAtMax,
+	} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidValue, "BadRequest",
+				fmt.Sprintf("The value of `%s` is invalid.", name))
+			return trackingFilter{}, false
+		}
+		*t = parsed
+	}
+	return filter, true
+}
+
+func (f trackingFilter) match(tracking *aftership.Tracking) bool {
+	switch {
+	case !matchSet(f.slugs, tracking.Slug),
+		!matchSet(f.tags, tracking.Tag),
+		!matchSet(f.origins, tracking.OriginCountryISO3),
+		!matchSet(f.destinations, tracking.DestinationCountryISO3),
+		!matchSet(f.trackingNumbers, tracking.TrackingNumber),
+		!f.createdAtMin.IsZero() && tracking.CreatedAt.Before(f.createdAtMin),
+		!f.createdAtMax.IsZero() && tracking.CreatedAt.After(f.createdAtMax):
+		return false
+	}
+	if f.keyword == "" {
+		return true
+	}
+
+	fields := []string{tracking.TrackingNumber, tracking.Title, tracking.OrderID, tracking.CustomerName}
+	fields = append(fields, tracking.Emails...)
+	fields = append(fields, tracking.SMSes...)
+	for _, value := range tracking.CustomFields {
+		fields = append(fields, value)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), f.keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSet returns the set of the comma separated values, nil when there is none
+func splitSet(values string) map[string]bool {
+	if values == "" {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, value := range strings.Split(values, ",") {
+		set[strings.TrimSpace(value)] = true
+	}
+	return set
+}
+
+func matchSet(set map[string]bool, value string) bool {
+	return set == nil || set[value]
+}
+
+func (s *Server) createTracking(w http.ResponseWriter, r *http.Request, dated bool) {
+	params := &aftership.CreateTrackingParams{}
+	customers, ok := decodeTrackingBody(w, r, params, dated)
+	if !ok {
+		return
+	}
+	if customers != nil {
+		params.Emails, params.SMSes, params.CustomerName = customers.contacts()
+	}
+	if strings.TrimSpace(params.TrackingNumber) == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidValue, "BadRequest", "The value of `tracking_number` is invalid.")
+		return
+	}
+
+	if params.Slug == "" {
+		couriers := s.detect(aftership.CourierDetectionParams{TrackingNumber: params.TrackingNumber})
+		if len(couriers) == 0 {
+			writeError(w, http.StatusBadRequest, CodeCannotDetectCourier, "BadRequest",
+				"Cannot detect courier. Activate courier at https://admin.aftership.com/settings/couriers.")
+			return
+		}
+		params.Slug = couriers[0].Slug
+	}
+
+	if _, existing := s.findTracking([]string{params.Slug, params.TrackingNumber}); existing != nil {
+		writeResponse(w, http.StatusBadRequest,
+			aftership.Meta{Code: CodeTrackingExists, Type: "BadRequest", Message: "Tracking already exists."},
+			map[string]interface{}{"tracking": map[string]string{
+				"id":              existing.ID,
+				"slug":            existing.Slug,
+				"tracking_number": existing.TrackingNumber,
+			}})
+		return
+	}
+
+	tracking := s.addTracking(aftership.Tracking{
+		TrackingNumber:            params.TrackingNumber,
+		Slug:                      params.Slug,
+		Title:                     params.Title,
+		OrderID:                   params.OrderID,
+		OrderIDPath:               params.OrderIDPath,
+		CustomFields:              params.CustomFields,
+		Language:                  params.Language,
+		OrderPromisedDeliveryDate: params.OrderPromisedDeliveryDate,
+		DeliveryType:              params.DeliveryType,
+		PickupLocation:            params.PickupLocation,
+		PickupNote:                params.PickupNote,
+		AdditionalField:           params.AdditionalField,
+		IOS:                       params.IOS,
+		Android:                   params.Android,
+		Emails:                    params.Emails,
+		SMSes:                     params.SMSes,
+		CustomerName:              params.CustomerName,
+		OriginCountryISO3:         params.OriginCountryISO3,
+		DestinationCountryISO3:    params.DestinationCountryISO3,
+		Note:                      params.Note,
+		OrderDate:                 params.OrderDate,
+		OrderNumber:               params.OrderNumber,
+		ShipmentType:              params.ShipmentType,
+		ShipmentTags:              params.ShipmentTags,
+	})
+	if tracking.Title == "" {
+		tracking.Title = tracking.TrackingNumber
+	}
+	writeTracking(w, http.StatusCreated, tracking, dated)
+}
+
+func (s *Server) updateTracking(w http.ResponseWriter, r *http.Request, tracking *aftership.Tracking, dated bool) {
+	params := &aftership.UpdateTrackingParams{}
+	customers, ok := decodeTrackingBody(w, r, params, dated)
+	if !ok {
+		return
+	}
+	if customers != nil {
+		params.Emails, params.SMSes, params.CustomerName = customers.contacts()
+	}
+	updateStrings := map[*string]string{
+		&tracking.Title:                     params.Title,
+		&tracking.CustomerName:              params.CustomerName,
+		&tracking.OrderID:                   params.OrderID,
+		&tracking.OrderIDPath:               params.OrderIDPath,
+		&tracking.Note:                      params.Note,
+		&tracking.Language:                  params.Language,
+		&tracking.OrderPromisedDeliveryDate: params.OrderPromisedDeliveryDate,
+		&tracking.DeliveryType:              params.DeliveryType,
+		&tracking.PickupLocation:            params.PickupLocation,
+		&tracking.PickupNote:                params.PickupNote,
+		&tracking.Slug:                      params.Slug,
+		&tracking.OrderNumber:               params.OrderNumber,
+		&tracking.OrderDate:                 params.OrderDate,
+		&tracking.ShipmentType:              params.ShipmentType,
+	}
+	for field, value := range updateStrings {
+		if value != "" {
+			*field = value
+		}
+	}
+	if params.Emails != nil {
+		tracking.Emails = params.Emails
+	}
+	if params.SMSes != nil {
+		tracking.SMSes = params.SMSes
+	}
+	if params.CustomFields != nil {
+		tracking.CustomFields = params.CustomFields
+	}
+
+	now := s.now().UTC()
+	tracking.UpdatedAt = &now
+	writeTracking(w, http.StatusOK, tracking, dated)
+}
+
+func (s *Server) retrackTracking(w http.ResponseWriter, tracking *aftership.Tracking, dated bool) {
+	if tracking.Active {
+		writeError(w, http.StatusBadRequest, CodeRetrackNotAllowed, "BadRequest",
+			"Retrack is not allowed. You can only retrack an inactive tracking.")
+		return
+	}
+	if s.retracks[tracking.ID] >= aftership.MaxRetracks {
+		writeError(w, http.StatusBadRequest, CodeRetrackNotAllowed, "BadRequest",
+			fmt.Sprintf("Retrack is not allowed. You can only retrack a tracking %d times.", aftership.MaxRetracks))
+		return
+	}
+
+	s.retracks[tracking.ID]++
+	now := s.now().UTC()
+	tracking.Active = true
+	tracking.UpdatedAt = &now
+	writeTracking(w, http.StatusOK, tracking, dated)
+}
+
+// completedTags are the tag and subtag of the trackings marked as completed, by reason
+var completedTags = map[aftership.TrackingCompletedStatus][2]string{
+	aftership.TrackingCompletedStatusDelivered:        {aftership.TagDelivered, "Delivered_001"},
+	aftership.TrackingCompletedStatusLost:             {aftership.TagException, "Exception_013"},
+	aftership.TrackingCompletedStatusReturnedToSender: {aftership.TagException, "Exception_011"},
+}
+
+func (s *Server) markTrackingAsCompleted(w http.ResponseWriter, r *http.Request, tracking *aftership.Tracking, dated bool) {
+	var request struct {
+		Reason aftership.TrackingCompletedStatus `json:"reason"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	tags, ok := completedTags[request.Reason]
+	if !ok {
+		writeError(w, http.StatusBadRequest, CodeInvalidValue, "BadRequest", "The value of `reason` is invalid.")
+		return
+	}
+
+	now := s.now().UTC()
+	tracking.Active = false
+	tracking.Tag, tracking.Subtag = tags[0], tags[1]
+	tracking.UpdatedAt = &now
+	writeTracking(w, http.StatusOK, tracking, dated)
+}
+
+func (s *Server) serveLastCheckpoint(w http.ResponseWriter, r *http.Request, segments []string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	_, tracking := s.findTrackingOrWriteError(w, segments)
+	if tracking == nil {
+		return
+	}
+
+	lastCheckpoint := aftership.LastCheckpoint{
+		ID:             tracking.ID,
+		Slug:           tracking.Slug,
+		TrackingNumber: tracking.TrackingNumber,
+		Tag:            tracking.Tag,
+		Subtag:         tracking.Subtag,
+		SubtagMessage:  tracking.SubtagMessage,
+	}
+	if n := len(tracking.Checkpoints); n > 0 {
+		lastCheckpoint.Checkpoint = tracking.Checkpoints[n-1]
+	}
+	writeData(w, http.StatusOK, lastCheckpoint)
+}
+
+// copyTracking returns a copy of tracking that shares no slice or map with it
+func copyTracking(tracking *aftership.Tracking) aftership.Tracking {
+	c := *tracking
+	c.Checkpoints = append([]aftership.Checkpoint(nil), tracking.Checkpoints...)
+	c.Emails = append([]string(nil), tracking.Emails...)
+	c.SMSes = append([]string(nil), tracking.SMSes...)
+	c.IOS = append([]string(nil), tracking.IOS...)
+	c.Android = append([]string(nil), tracking.Android...)
+	c.ShipmentTags = append([]string(nil), tracking.ShipmentTags...)
+	if tracking.CustomFields != nil {
+		c.CustomFields = make(map[string]string, len(tracking.CustomFields))
+		for key, value := range tracking.CustomFields {
+			c.CustomFields[key] = value
+		}
+	}
+	return c
+}
+
+// datedPagination is the pagination of GetTrackings with the date-versioned APIs
+type datedPagination struct {
+	Total       int    `json:"total"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	HasNextPage bool   `json:"has_next_page"`
+}
+
+// datedCustomer is a customer of a tracking with the date-versioned APIs
+type datedCustomer struct {
+	Role        string `json:"role,omitempty"`
+	Name        string `json:"name,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+type datedCustomers []datedCustomer
+
+// newDatedCustomers returns the customers of the emails, smses and customer name of a tracking
+func newDatedCustomers(emails []string, smses []string, name string) datedCustomers {
+	var customers datedCustomers
+	for i := 0; i < len(emails) || i < len(smses); i++ {
+		customer := datedCustomer{Role: "buyer"}
+		if i < len(emails) {
+			customer.Email = emails[i]
+		}
+		if i < len(smses) {
+			customer.PhoneNumber = smses[i]
+		}
+		customers = append(customers, customer)
+	}
+	if name != "" {
+		if len(customers) == 0 {
+			customers = append(customers, datedCustomer{Role: "buyer"})
+		}
+		customers[0].Name = name
+	}
+	return customers
+}
+
+// contacts returns the emails, smses and first customer name of the customers
+func (customers datedCustomers) contacts() (emails []string, smses []string, name string) {
+	for _, customer := range customers {
+		if customer.Email != "" {
+			emails = append(emails, customer.Email)
+		}
+		if customer.PhoneNumber != "" {
+			smses = append(smses, customer.PhoneNumber)
+		}
+		if name == "" {
+			name = customer.Name
+		}
+	}
+	return emails, smses, name
+}
+
+// datedTracking returns tracking as served by the date-versioned APIs, with customers instead of
+// its emails, smses and customer name
+func datedTracking(tracking *aftership.Tracking) map[string]interface{} {
+	data, _ := json.Marshal(tracking)
+	var fields map[string]interface{}
+	_ = json.Unmarshal(data, &fields)
+
+	delete(fields, "emails")
+	delete(fields, "smses")
+	delete(fields, "custom_name")
+	if customers := newDatedCustomers(tracking.Emails, tracking.SMSes, tracking.CustomerName); customers != nil {
+		fields["customers"] = customers
+	}
+	return fields
+}
+
+// writeTracking writes the response of a single tracking, wrapped in {"tracking": ...} unless dated
+func writeTracking(w http.ResponseWriter, status int, tracking *aftership.Tracking, dated bool) {
+	if dated {
+		writeData(w, status, datedTracking(tracking))
+		return
+	}
+	writeData(w, status, map[string]interface{}{"tracking": tracking})
+}
+
+// decodeTrackingBody decodes the tracking of a create or update request into params: the "tracking" object,
+// or the body itself and its customers when dated. It writes the error response and returns false when the body is invalid.
+func decodeTrackingBody(w http.ResponseWriter, r *http.Request, params interface{}, dated bool) (datedCustomers, bool) {
+	var body json.RawMessage
+	if !decodeBody(w, r, &body) {
+		return nil, false
+	}
+
+	if !dated {
+		var request struct {
+			Tracking json.RawMessage `json:"tracking"`
+		}
+		if err := json.Unmarshal(body, &request); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidJSON, "BadRequest", "Invalid JSON data.")
+			return nil, false
+		}
+		if len(request.Tracking) == 0 || string(request.Tracking) == "null" {
+			writeError(w, http.StatusBadRequest, CodeTrackingRequired, "BadRequest", "`tracking` is required.")
+			return nil, false
+		}
+		body = request.Tracking
+	}
+
+	var customers struct {
+		Customers datedCustomers `json:"customers"`
+	}
+	if err := json.Unmarshal(body, params); err != nil || (dated && json.Unmarshal(body, &customers) != nil) {
+		writeError(w, http.StatusBadRequest, CodeInvalidJSON, "BadRequest", "Invalid JSON data.")
+		return nil, false
+	}
+	return customers.Customers, true
+}
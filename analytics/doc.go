@@ -0,0 +1,7 @@
+/*
+Package analytics computes carrier performance metrics from AfterShip trackings.
+
+Trackings are read from JSON files with ReadTrackings, or fetched from the API
+with aftership.WalkTrackings, and added one at a time to the aggregators.
+*/
+package analytics
@@ -0,0 +1,308 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+// Sources of EDDPrediction
+const (
+	EDDSourceAfterShip = "aftership_estimated_delivery_date" // Tracking.EstimatedDeliveryDate or BatchPredictEstimatedDeliveryDate
+	EDDSourceLatest    = "latest_estimated_delivery"         // Tracking.LatestEstimatedDelivery
+)
+
+// confidenceBuckets is the number of buckets of width 0.1 between confidence scores 0.0 and 1.0
+const confidenceBuckets = 10
+
+// EDDPrediction is an estimated delivery date to evaluate against the actual delivery date.
+// Dates are in YYYY-MM-DD format, optionally followed by a time which is ignored.
+type EDDPrediction struct {
+	Slug            string  `json:"slug"`
+	ServiceTypeName string  `json:"service_type_name"`
+	Source          string  `json:"source"`
+	Date            string  `json:"date"`             // The estimated delivery date
+	DateMin         string  `json:"date_min"`         // The lower end of a date range estimate
+	DateMax         string  `json:"date_max"`         // The upper end of a date range estimate
+	ConfidenceScore float64 `json:"confidence_score"` // From 0.0 to 1.0, 0 when unknown
+}
+
+// PredictionFromEstimatedDeliveryDate returns the prediction of an estimated delivery date,
+// as returned by BatchPredictEstimatedDeliveryDate.
+func PredictionFromEstimatedDeliveryDate(edd aftership.EstimatedDeliveryDate) EDDPrediction {
+	return EDDPrediction{
+		Slug:            edd.Slug,
+		ServiceTypeName: edd.ServiceTypeName,
+		Source:          EDDSourceAfterShip,
+		Date:            edd.EstimatedDeliveryDate,
+		DateMin:         edd.EstimatedDeliveryDateMin,
+		DateMax:         edd.EstimatedDeliveryDateMax,
+		ConfidenceScore: edd.ConfidenceScore,
+	}
+}
+
+// PredictionsFromTracking returns the predictions of a tracking snapshot: the AfterShip estimated delivery date
+// and the latest estimated delivery, when present.
+func PredictionsFromTracking(tracking aftership.Tracking) []EDDPrediction {
+	var predictions []EDDPrediction
+
+	edd := tracking.EstimatedDeliveryDate
+	if edd.EstimatedDeliveryDate != "" || edd.EstimatedDeliveryDateMin != "" || edd.EstimatedDeliveryDateMax != "" {
+		prediction := PredictionFromEstimatedDeliveryDate(edd)
+		if prediction.Slug == "" {
+			prediction.Slug = tracking.Slug
+		}
+		predictions = append(predictions, prediction)
+	}
+
+	latest := tracking.LatestEstimatedDelivery
+	if latest.Datetime != "" || latest.DatetimeMin != "" || latest.DatetimeMax != "" {
+		predictions = append(predictions, EDDPrediction{
+			Slug:            tracking.Slug,
+			ServiceTypeName: edd.ServiceTypeName,
+			Source:          EDDSourceLatest,
+			Date:            latest.Datetime,
+			DateMin:         latest.DatetimeMin,
+			DateMax:         latest.DatetimeMax,
+		})
+	}
+
+	return predictions
+}
+
+// EDDGroup is a group of predictions evaluated together
+type EDDGroup struct {
+	Slug            string `json:"slug"`
+	ServiceTypeName string `json:"service_type_name"`
+	Source          string `json:"source"`
+}
+
+// ConfidenceBucket is the accuracy of the predictions with a confidence score in [Min, Max)
+type ConfidenceBucket struct {
+	Min            float64 `json:"min"`
+	Max            float64 `json:"max"`
+	Count          int     `json:"count"`
+	Hits           int     `json:"hits"`
+	HitRate        float64 `json:"hit_rate"`
+	MeanConfidence float64 `json:"mean_confidence"` // Well calibrated predictions have a HitRate close to MeanConfidence
+}
+
+// EDDAccuracyStats is the accuracy of a group of predictions
+type EDDAccuracyStats struct {
+	EDDGroup
+	Count   int     `json:"count"`
+	Hits    int     `json:"hits"`     // Deliveries within the predicted range, or on the predicted date
+	HitRate float64 `json:"hit_rate"` // Hits / Count
+
+	// MeanAbsoluteError is the mean number of days between the delivery and the prediction.
+	// The error of a range is the distance to its nearest end, 0 within the range.
+	MeanAbsoluteError float64 `json:"mean_absolute_error"`
+
+	// MeanError is the mean signed error in days, positive when deliveries arrive after the predictions
+	MeanError float64 `json:"mean_error"`
+
+	// Calibration is the accuracy by confidence score, in buckets of 0.1. Empty buckets and predictions
+	// without confidence score are left out.
+	Calibration []ConfidenceBucket `json:"calibration"`
+}
+
+// eddAccumulator sums the errors of a group of predictions
+type eddAccumulator struct {
+	count         int
+	hits          int
+	sumAbsError   int
+	sumError      int
+	buckets       [confidenceBuckets]int
+	bucketHits    [confidenceBuckets]int
+	bucketSumConf [confidenceBuckets]float64
+}
+
+func (a *eddAccumulator) add(errorDays int, score float64) {
+	a.count++
+	hit := errorDays == 0
+	if hit {
+		a.hits++
+	}
+	a.sumError += errorDays
+	if errorDays < 0 {
+		errorDays = -errorDays
+	}
+	a.sumAbsError += errorDays
+
+	if score <= 0 {
+		return
+	}
+	bucket := int(score * confidenceBuckets)
+	if bucket >= confidenceBuckets {
+		bucket = confidenceBuckets - 1
+	}
+	a.buckets[bucket]++
+	a.bucketSumConf[bucket] += score
+	if hit {
+		a.bucketHits[bucket]++
+	}
+}
+
+func (a *eddAccumulator) stats(group EDDGroup) EDDAccuracyStats {
+	stats := EDDAccuracyStats{
+		EDDGroup:          group,
+		Count:             a.count,
+		Hits:              a.hits,
+		HitRate:           ratio(float64(a.hits), a.count),
+		MeanAbsoluteError: ratio(float64(a.sumAbsError), a.count),
+		MeanError:         ratio(float64(a.sumError), a.count),
+	}
+
+	for i, count := range a.buckets {
+		if count == 0 {
+			continue
+		}
+		stats.Calibration = append(stats.Calibration, ConfidenceBucket{
+			Min:            float64(i) / confidenceBuckets,
+			Max:            float64(i+1) / confidenceBuckets,
+			Count:          count,
+			Hits:           a.bucketHits[i],
+			HitRate:        ratio(float64(a.bucketHits[i]), count),
+			MeanConfidence: ratio(a.bucketSumConf[i], count),
+		})
+	}
+	return stats
+}
+
+// EDDAccuracy evaluates estimated delivery date predictions against the actual delivery dates,
+// grouped by slug, service type and source. Dates are compared as calendar days.
+type EDDAccuracy struct {
+	groups   map[EDDGroup]*eddAccumulator
+	overall  eddAccumulator
+	excluded int
+}
+
+// NewEDDAccuracy returns an empty EDDAccuracy.
+func NewEDDAccuracy() *EDDAccuracy {
+	return &EDDAccuracy{groups: make(map[EDDGroup]*eddAccumulator)}
+}
+
+// Add evaluates prediction against the actual delivery date, e.g. the ShipmentDeliveryDate of the delivered tracking.
+// It returns false when the prediction has no date or the delivery date is invalid.
+func (a *EDDAccuracy) Add(prediction EDDPrediction, delivered string) bool {
+	actual, ok := parseDate(delivered)
+	if !ok {
+		a.excluded++
+		return false
+	}
+
+	errorDays, ok := predictionError(prediction, actual)
+	if !ok {
+		a.excluded++
+		return false
+	}
+
+	group := EDDGroup{Slug: prediction.Slug, ServiceTypeName: prediction.ServiceTypeName, Source: prediction.Source}
+	accumulator, ok := a.groups[group]
+	if !ok {
+		accumulator = &eddAccumulator{}
+		a.groups[group] = accumulator
+	}
+	accumulator.add(errorDays, prediction.ConfidenceScore)
+	a.overall.add(errorDays, prediction.ConfidenceScore)
+	return true
+}
+
+// AddTracking evaluates the predictions of snapshot, a tracking captured before the delivery,
+// against the ShipmentDeliveryDate of delivered. It returns the number of predictions added.
+func (a *EDDAccuracy) AddTracking(snapshot aftership.Tracking, delivered aftership.Tracking) int {
+	if delivered.Tag != aftership.TagDelivered {
+		a.excluded++
+		return 0
+	}
+
+	added := 0
+	for _, prediction := range PredictionsFromTracking(snapshot) {
+		if a.Add(prediction, delivered.ShipmentDeliveryDate) {
+			added++
+		}
+	}
+	return added
+}
+
+// Excluded returns the number of predictions and trackings that could not be evaluated
+func (a *EDDAccuracy) Excluded() int {
+	return a.excluded
+}
+
+// Stats returns the accuracy of every group, sorted by group.
+func (a *EDDAccuracy) Stats() []EDDAccuracyStats {
+	stats := make([]EDDAccuracyStats, 0, len(a.groups))
+	for group, accumulator := range a.groups {
+		stats = append(stats, accumulator.stats(group))
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i].EDDGroup, stats[j].EDDGroup
+		switch {
+		case a.Slug != b.Slug:
+			return a.Slug < b.Slug
+		case a.ServiceTypeName != b.ServiceTypeName:
+			return a.ServiceTypeName < b.ServiceTypeName
+		}
+		return a.Source < b.Source
+	})
+	return stats
+}
+
+// Overall returns the accuracy of all the predictions together.
+func (a *EDDAccuracy) Overall() EDDAccuracyStats {
+	return a.overall.stats(EDDGroup{})
+}
+
+// predictionError returns the signed number of days from prediction to actual, 0 when actual is within the range
+func predictionError(prediction EDDPrediction, actual time.Time) (int, bool) {
+	earliest, okEarliest := parseDate(prediction.DateMin)
+	latest, okLatest := parseDate(prediction.DateMax)
+	switch {
+	case okEarliest && !okLatest:
+		latest = earliest
+	case okLatest && !okEarliest:
+		earliest = latest
+	case !okEarliest && !okLatest:
+		date, ok := parseDate(prediction.Date)
+		if !ok {
+			return 0, false
+		}
+		earliest, latest = date, date
+	}
+	if latest.Before(earliest) {
+		earliest, latest = latest, earliest
+	}
+
+	switch {
+	case actual.Before(earliest):
+		return -days(earliest.Sub(actual)), true
+	case actual.After(latest):
+		return days(actual.Sub(latest)), true
+	}
+	return 0, true
+}
+
+// parseDate parses the calendar date of a YYYY-MM-DD value, ignoring any time that follows
+func parseDate(value string) (time.Time, bool) {
+	const layout = "2006-01-02"
+	if len(value) < len(layout) {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(layout, value[:len(layout)])
+	return date, err == nil
+}
+
+func days(d time.Duration) int {
+	return int(math.Round(d.Hours() / 24))
+}
+
+func ratio(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
@@ -0,0 +1,106 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredictionsFromTracking(t *testing.T) {
+	predictions := PredictionsFromTracking(aftership.Tracking{
+		Slug: "fedex",
+		EstimatedDeliveryDate: aftership.EstimatedDeliveryDate{
+			ServiceTypeName:       "FEDEX HOME DELIVERY",
+			EstimatedDeliveryDate: "2022-11-05",
+			ConfidenceScore:       0.8,
+		},
+		LatestEstimatedDelivery: aftership.LatestEstimatedDelivery{
+			Type:        "range",
+			DatetimeMin: "2022-11-04",
+			DatetimeMax: "2022-11-06",
+		},
+	})
+	assert.Equal(t, []EDDPrediction{
+		{Slug: "fedex", ServiceTypeName: "FEDEX HOME DELIVERY", Source: EDDSourceAfterShip, Date: "2022-11-05", ConfidenceScore: 0.8},
+		{Slug: "fedex", ServiceTypeName: "FEDEX HOME DELIVERY", Source: EDDSourceLatest, DateMin: "2022-11-04", DateMax: "2022-11-06"},
+	}, predictions)
+
+	assert.Empty(t, PredictionsFromTracking(aftership.Tracking{Slug: "fedex"}))
+}
+
+func TestPredictionError(t *testing.T) {
+	cases := []struct {
+		prediction EDDPrediction
+		delivered  string
+		exp        int
+	}{
+		{EDDPrediction{Date: "2022-11-05"}, "2022-11-05T18:00:00+08:00", 0},
+		{EDDPrediction{Date: "2022-11-05"}, "2022-11-07T09:00:00", 2},
+		{EDDPrediction{Date: "2022-11-05"}, "2022-11-04", -1},
+		{EDDPrediction{DateMin: "2022-11-04", DateMax: "2022-11-06"}, "2022-11-06", 0},
+		{EDDPrediction{DateMin: "2022-11-04", DateMax: "2022-11-06"}, "2022-11-09", 3},
+		{EDDPrediction{DateMin: "2022-11-04", DateMax: "2022-11-06"}, "2022-11-01", -3},
+		{EDDPrediction{Date: "2022-11-01", DateMax: "2022-11-06"}, "2022-11-05", -1},
+	}
+	for _, c := range cases {
+		actual, _ := parseDate(c.delivered)
+		errorDays, ok := predictionError(c.prediction, actual)
+		assert.True(t, ok)
+		assert.Equal(t, c.exp, errorDays, c.prediction)
+	}
+
+	actual, _ := parseDate("2022-11-01")
+	_, ok := predictionError(EDDPrediction{}, actual)
+	assert.False(t, ok)
+}
+
+func TestEDDAccuracy(t *testing.T) {
+	accuracy := NewEDDAccuracy()
+	ups := func(date string, score float64) EDDPrediction {
+		return EDDPrediction{Slug: "ups", ServiceTypeName: "UPS Ground", Source: EDDSourceAfterShip, Date: date, ConfidenceScore: score}
+	}
+
+	assert.True(t, accuracy.Add(ups("2022-11-05", 0.95), "2022-11-05"))
+	assert.True(t, accuracy.Add(ups("2022-11-05", 0.92), "2022-11-07"))
+	assert.True(t, accuracy.Add(ups("2022-11-05", 0.55), "2022-11-05"))
+	assert.True(t, accuracy.Add(ups("2022-11-05", 0), "2022-11-04"))
+	assert.False(t, accuracy.Add(ups("", 0.5), "2022-11-04"))
+	assert.False(t, accuracy.Add(ups("2022-11-05", 0.5), ""))
+
+	added := accuracy.AddTracking(aftership.Tracking{
+		Slug:                    "dhl",
+		LatestEstimatedDelivery: aftership.LatestEstimatedDelivery{DatetimeMin: "2022-11-04", DatetimeMax: "2022-11-06"},
+	}, aftership.Tracking{Tag: aftership.TagDelivered, ShipmentDeliveryDate: "2022-11-06T10:00:00"})
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 0, accuracy.AddTracking(aftership.Tracking{}, aftership.Tracking{Tag: aftership.TagInTransit}))
+	assert.Equal(t, 3, accuracy.Excluded())
+
+	stats := accuracy.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, EDDAccuracyStats{
+		EDDGroup: EDDGroup{Slug: "dhl", Source: EDDSourceLatest},
+		Count:    1,
+		Hits:     1,
+		HitRate:  1,
+	}, stats[0])
+
+	assert.Equal(t, EDDGroup{Slug: "ups", ServiceTypeName: "UPS Ground", Source: EDDSourceAfterShip}, stats[1].EDDGroup)
+	assert.Equal(t, 4, stats[1].Count)
+	assert.Equal(t, 2, stats[1].Hits)
+	assert.Equal(t, 0.5, stats[1].HitRate)
+	assert.Equal(t, 0.75, stats[1].MeanAbsoluteError)
+	assert.Equal(t, 0.25, stats[1].MeanError)
+	assert.Len(t, stats[1].Calibration, 2)
+	assert.Equal(t, 0.5, stats[1].Calibration[0].Min)
+	assert.Equal(t, 1, stats[1].Calibration[0].Count)
+	assert.Equal(t, 1.0, stats[1].Calibration[0].HitRate)
+	assert.Equal(t, 0.9, stats[1].Calibration[1].Min)
+	assert.Equal(t, 2, stats[1].Calibration[1].Count)
+	assert.Equal(t, 0.5, stats[1].Calibration[1].HitRate)
+	assert.InDelta(t, 0.935, stats[1].Calibration[1].MeanConfidence, 1e-9)
+
+	overall := accuracy.Overall()
+	assert.Equal(t, 5, overall.Count)
+	assert.Equal(t, 3, overall.Hits)
+}
@@ -0,0 +1,103 @@
+package analytics_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/aftership/aftership-sdk-go/v2/analytics"
+)
+
+func ExampleTransitTimes() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	transitTimes := analytics.NewTransitTimes()
+	err = aftership.WalkTrackings(context.Background(), cli, aftership.GetTrackingsParams{
+		Tag: aftership.TagDelivered,
+	}, func(tracking aftership.Tracking) error {
+		transitTimes.Add(tracking)
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if err := analytics.WriteTransitTimeCSV(os.Stdout, transitTimes.Stats()); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func ExampleReadTrackings() {
+	file, err := os.Open("trackings.json")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+
+	trackings, err := analytics.ReadTrackings(file)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	transitTimes := analytics.NewTransitTimes(analytics.LaneSlug, analytics.LaneDestinationCountry)
+	for _, tracking := range trackings {
+		transitTimes.Add(tracking)
+	}
+
+	for _, stats := range transitTimes.Stats() {
+		fmt.Println(stats.Slug, stats.DestinationCountryISO3, stats.Count, stats.P50, stats.P90, stats.P99)
+	}
+}
+
+func ExampleEDDAccuracy() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Snapshots of the trackings saved when the predictions were shown to customers
+	file, err := os.Open("snapshots.jsonl")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+
+	snapshots, err := analytics.ReadTrackings(file)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	accuracy := analytics.NewEDDAccuracy()
+	for _, snapshot := range snapshots {
+		delivered, err := cli.GetTracking(context.Background(), aftership.TrackingID(snapshot.ID), aftership.GetTrackingParams{})
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		accuracy.AddTracking(snapshot, delivered)
+	}
+
+	for _, stats := range accuracy.Stats() {
+		fmt.Println(stats.Slug, stats.ServiceTypeName, stats.Source, stats.HitRate, stats.MeanAbsoluteError)
+		for _, bucket := range stats.Calibration {
+			fmt.Println(bucket.Min, bucket.Max, bucket.MeanConfidence, bucket.HitRate)
+		}
+	}
+}
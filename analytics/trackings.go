@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+// ReadTrackings reads trackings from r. It accepts a JSON array of trackings, JSON lines of trackings,
+// and the data of GetTrackings responses with or without the API envelope, as saved from the API.
+func ReadTrackings(r io.Reader) ([]aftership.Tracking, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read trackings")
+	}
+
+	contents = bytes.TrimSpace(contents)
+	if len(contents) > 0 && contents[0] == '[' {
+		var trackings []aftership.Tracking
+		if err := json.Unmarshal(contents, &trackings); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling the trackings")
+		}
+		return trackings, nil
+	}
+
+	var trackings []aftership.Tracking
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	for {
+		var object map[string]json.RawMessage
+		if err := decoder.Decode(&object); err == io.EOF {
+			return trackings, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling the trackings")
+		}
+
+		page, err := readTrackingsObject(object)
+		if err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling the trackings")
+		}
+		trackings = append(trackings, page...)
+	}
+}
+
+// readTrackingsObject unwraps the trackings of a single JSON object: a response envelope, a page of trackings,
+// a single tracking response or a tracking
+func readTrackingsObject(object map[string]json.RawMessage) ([]aftership.Tracking, error) {
+	if data, ok := object["data"]; ok {
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, err
+		}
+	}
+
+	if raw, ok := object["trackings"]; ok {
+		var trackings []aftership.Tracking
+		err := json.Unmarshal(raw, &trackings)
+		return trackings, err
+	}
+
+	raw, ok := object["tracking"]
+	if !ok {
+		var err error
+		raw, err = json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tracking aftership.Tracking
+	err := json.Unmarshal(raw, &tracking)
+	return []aftership.Tracking{tracking}, err
+}
+
+// parseTime parses the checkpoint and shipment times of AfterShip. Times without a time zone,
+// which are local to the checkpoint, are read as UTC.
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTrackings(t *testing.T) {
+	inputs := []string{
+		`[{"tracking_number": "1"}, {"tracking_number": "2"}]`,
+		`{"tracking_number": "1"}
+		{"tracking_number": "2"}`,
+		`{"meta": {"code": 200}, "data": {"page": 1, "limit": 100, "count": 2, "trackings": [{"tracking_number": "1"}, {"tracking_number": "2"}]}}`,
+		`{"page": 1, "trackings": [{"tracking_number": "1"}]}
+		{"meta": {"code": 200}, "data": {"tracking": {"tracking_number": "2"}}}`,
+	}
+	for _, input := range inputs {
+		trackings, err := ReadTrackings(strings.NewReader(input))
+		assert.Nil(t, err, input)
+		assert.Len(t, trackings, 2, input)
+		assert.Equal(t, "1", trackings[0].TrackingNumber, input)
+		assert.Equal(t, "2", trackings[1].TrackingNumber, input)
+	}
+
+	trackings, err := ReadTrackings(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Empty(t, trackings)
+
+	_, err = ReadTrackings(strings.NewReader(`{"tracking_number": 1}`))
+	assert.NotNil(t, err)
+
+	_, err = ReadTrackings(strings.NewReader(`[{"tracking_number": "1"}`))
+	assert.NotNil(t, err)
+}
+
+func TestParseTime(t *testing.T) {
+	parsed, ok := parseTime("2022-11-02T08:00:00+08:00")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC), parsed.UTC())
+
+	parsed, ok = parseTime("2022-11-02T08:00:00")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 11, 2, 8, 0, 0, 0, time.UTC), parsed)
+
+	_, ok = parseTime("")
+	assert.False(t, ok)
+}
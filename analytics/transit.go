@@ -0,0 +1,222 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+// LaneField is a field of Lane that transit times can be grouped by
+type LaneField string
+
+// Lane fields
+const (
+	LaneSlug               LaneField = "slug"
+	LaneOriginCountry      LaneField = "origin_country_iso3"
+	LaneDestinationCountry LaneField = "destination_country_iso3"
+	LaneShipmentType       LaneField = "shipment_type"
+)
+
+// AllLaneFields groups by every field of Lane
+var AllLaneFields = []LaneField{LaneSlug, LaneOriginCountry, LaneDestinationCountry, LaneShipmentType}
+
+// Lane is a group of trackings with the same courier, origin, destination and shipment type.
+// Fields that are not grouped by are empty.
+type Lane struct {
+	Slug                   string `json:"slug"`
+	OriginCountryISO3      string `json:"origin_country_iso3"`
+	DestinationCountryISO3 string `json:"destination_country_iso3"`
+	ShipmentType           string `json:"shipment_type"`
+}
+
+// TransitTimeStats is the transit time distribution of a lane
+type TransitTimeStats struct {
+	Lane
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// TransitTimes aggregates the transit times of trackings by lane.
+// The transit time runs from the first carrier checkpoint to the ShipmentDeliveryDate.
+type TransitTimes struct {
+	groupBy  map[LaneField]bool
+	lanes    map[Lane][]time.Duration
+	excluded int
+}
+
+// NewTransitTimes returns an empty TransitTimes grouping by the given lane fields, or by AllLaneFields when none is given.
+func NewTransitTimes(groupBy ...LaneField) *TransitTimes {
+	if len(groupBy) == 0 {
+		groupBy = AllLaneFields
+	}
+
+	t := &TransitTimes{
+		groupBy: make(map[LaneField]bool, len(groupBy)),
+		lanes:   make(map[Lane][]time.Duration),
+	}
+	for _, field := range groupBy {
+		t.groupBy[field] = true
+	}
+	return t
+}
+
+// Add adds the transit time of tracking. It returns false when the tracking is excluded
+// because TransitTime cannot measure it.
+func (t *TransitTimes) Add(tracking aftership.Tracking) bool {
+	transitTime, ok := TransitTime(tracking)
+	if !ok {
+		t.excluded++
+		return false
+	}
+
+	lane := t.lane(tracking)
+	t.lanes[lane] = append(t.lanes[lane], transitTime)
+	return true
+}
+
+// Excluded returns the number of trackings excluded by Add
+func (t *TransitTimes) Excluded() int {
+	return t.excluded
+}
+
+// Stats returns the transit time distribution of every lane, sorted by lane.
+func (t *TransitTimes) Stats() []TransitTimeStats {
+	stats := make([]TransitTimeStats, 0, len(t.lanes))
+	for lane, durations := range t.lanes {
+		sorted := make([]time.Duration, len(durations))
+		copy(sorted, durations)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		var sum time.Duration
+		for _, d := range sorted {
+			sum += d
+		}
+
+		stats = append(stats, TransitTimeStats{
+			Lane:  lane,
+			Count: len(sorted),
+			Min:   sorted[0],
+			Mean:  sum / time.Duration(len(sorted)),
+			P50:   percentile(sorted, 50),
+			P90:   percentile(sorted, 90),
+			P99:   percentile(sorted, 99),
+			Max:   sorted[len(sorted)-1],
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return lessLane(stats[i].Lane, stats[j].Lane)
+	})
+	return stats
+}
+
+func (t *TransitTimes) lane(tracking aftership.Tracking) Lane {
+	var lane Lane
+	if t.groupBy[LaneSlug] {
+		lane.Slug = tracking.Slug
+	}
+	if t.groupBy[LaneOriginCountry] {
+		lane.OriginCountryISO3 = tracking.OriginCountryISO3
+	}
+	if t.groupBy[LaneDestinationCountry] {
+		lane.DestinationCountryISO3 = tracking.DestinationCountryISO3
+	}
+	if t.groupBy[LaneShipmentType] {
+		lane.ShipmentType = tracking.ShipmentType
+	}
+	return lane
+}
+
+// TransitTime returns the time from the first carrier checkpoint of tracking to its ShipmentDeliveryDate.
+// InfoReceived checkpoints are not carrier checkpoints, as the carrier does not have the parcel yet.
+// It returns false for Pending and Expired trackings, trackings without a delivery date or carrier checkpoint,
+// and negative transit times.
+func TransitTime(tracking aftership.Tracking) (time.Duration, bool) {
+	if tracking.Tag == aftership.TagPending || tracking.Tag == aftership.TagExpired {
+		return 0, false
+	}
+
+	delivered, ok := parseTime(tracking.ShipmentDeliveryDate)
+	if !ok {
+		return 0, false
+	}
+
+	var first time.Time
+	for _, checkpoint := range tracking.Checkpoints {
+		if checkpoint.Tag == aftership.TagInfoReceived || checkpoint.Tag == aftership.TagPending {
+			continue
+		}
+		if t, ok := parseTime(checkpoint.CheckpointTime); ok && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	if first.IsZero() || delivered.Before(first) {
+		return 0, false
+	}
+
+	return delivered.Sub(first), true
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func lessLane(a, b Lane) bool {
+	switch {
+	case a.Slug != b.Slug:
+		return a.Slug < b.Slug
+	case a.OriginCountryISO3 != b.OriginCountryISO3:
+		return a.OriginCountryISO3 < b.OriginCountryISO3
+	case a.DestinationCountryISO3 != b.DestinationCountryISO3:
+		return a.DestinationCountryISO3 < b.DestinationCountryISO3
+	}
+	return a.ShipmentType < b.ShipmentType
+}
+
+// transitTimeCSVHeader is the header of WriteTransitTimeCSV. Durations are in days.
+var transitTimeCSVHeader = []string{
+	"slug", "origin_country_iso3", "destination_country_iso3", "shipment_type",
+	"count", "min_days", "mean_days", "p50_days", "p90_days", "p99_days", "max_days",
+}
+
+// WriteTransitTimeCSV writes stats to w as CSV with a header row. Durations are written in days with two decimals.
+func WriteTransitTimeCSV(w io.Writer, stats []TransitTimeStats) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(transitTimeCSVHeader); err != nil {
+		return errors.Wrap(err, "error writing CSV header")
+	}
+
+	for _, s := range stats {
+		record := []string{
+			s.Slug, s.OriginCountryISO3, s.DestinationCountryISO3, s.ShipmentType,
+			strconv.Itoa(s.Count), formatDays(s.Min), formatDays(s.Mean),
+			formatDays(s.P50), formatDays(s.P90), formatDays(s.P99), formatDays(s.Max),
+		}
+		if err := writer.Write(record); err != nil {
+			return errors.Wrap(err, "error writing CSV record")
+		}
+	}
+
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "error writing CSV record")
+}
+
+func formatDays(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours()/24, 'f', 2, 64)
+}
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func transitTestTracking(slug string, origin string, pickedUp string, delivered string) aftership.Tracking {
+	return aftership.Tracking{
+		Slug:                   slug,
+		OriginCountryISO3:      origin,
+		DestinationCountryISO3: "USA",
+		ShipmentType:           "Ground",
+		Tag:                    aftership.TagDelivered,
+		ShipmentDeliveryDate:   delivered,
+		Checkpoints: []aftership.Checkpoint{
+			{CheckpointTime: "2022-10-30T10:00:00", Tag: aftership.TagInfoReceived},
+			{CheckpointTime: pickedUp, Tag: aftership.TagInTransit},
+			{CheckpointTime: delivered, Tag: aftership.TagDelivered},
+		},
+	}
+}
+
+func TestTransitTime(t *testing.T) {
+	tracking := transitTestTracking("ups", "CHN", "2022-11-01T10:00:00+08:00", "2022-11-03T22:00:00+08:00")
+	transitTime, ok := TransitTime(tracking)
+	assert.True(t, ok)
+	assert.Equal(t, 60*time.Hour, transitTime)
+
+	excluded := []aftership.Tracking{
+		{Tag: aftership.TagPending, ShipmentDeliveryDate: "2022-11-03"},
+		{Tag: aftership.TagInTransit, Checkpoints: tracking.Checkpoints},
+		{Tag: aftership.TagDelivered, ShipmentDeliveryDate: "2022-11-03", Checkpoints: tracking.Checkpoints[:1]},
+		{Tag: aftership.TagDelivered, ShipmentDeliveryDate: "2022-11-03", Checkpoints: []aftership.Checkpoint{
+			{CheckpointTime: "2022-11-05T10:00:00", Tag: aftership.TagInTransit},
+		}},
+	}
+	expired := tracking
+	expired.Tag = aftership.TagExpired
+	excluded = append(excluded, expired)
+
+	for i, tracking := range excluded {
+		_, ok := TransitTime(tracking)
+		assert.False(t, ok, i)
+	}
+}
+
+func TestTransitTimesStats(t *testing.T) {
+	transitTimes := NewTransitTimes()
+	for day := 1; day <= 10; day++ {
+		delivered := fmt.Sprintf("2022-11-%02dT10:00:00", day+1)
+		assert.True(t, transitTimes.Add(transitTestTracking("ups", "CHN", "2022-11-01T10:00:00", delivered)))
+	}
+	assert.True(t, transitTimes.Add(transitTestTracking("fedex", "CHN", "2022-11-01T10:00:00", "2022-11-04T10:00:00")))
+	assert.False(t, transitTimes.Add(aftership.Tracking{Tag: aftership.TagExpired}))
+	assert.Equal(t, 1, transitTimes.Excluded())
+
+	day := 24 * time.Hour
+	stats := transitTimes.Stats()
+	assert.Equal(t, []TransitTimeStats{
+		{
+			Lane:  Lane{Slug: "fedex", OriginCountryISO3: "CHN", DestinationCountryISO3: "USA", ShipmentType: "Ground"},
+			Count: 1, Min: 3 * day, Mean: 3 * day, P50: 3 * day, P90: 3 * day, P99: 3 * day, Max: 3 * day,
+		},
+		{
+			Lane:  Lane{Slug: "ups", OriginCountryISO3: "CHN", DestinationCountryISO3: "USA", ShipmentType: "Ground"},
+			Count: 10, Min: day, Mean: 5*day + 12*time.Hour, P50: 5 * day, P90: 9 * day, P99: 10 * day, Max: 10 * day,
+		},
+	}, stats)
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteTransitTimeCSV(&buf, stats))
+	assert.Equal(t, "slug,origin_country_iso3,destination_country_iso3,shipment_type,count,min_days,mean_days,p50_days,p90_days,p99_days,max_days\n"+
+		"fedex,CHN,USA,Ground,1,3.00,3.00,3.00,3.00,3.00,3.00\n"+
+		"ups,CHN,USA,Ground,10,1.00,5.50,5.00,9.00,10.00,10.00\n", buf.String())
+}
+
+func TestTransitTimesGroupBy(t *testing.T) {
+	transitTimes := NewTransitTimes(LaneDestinationCountry)
+	transitTimes.Add(transitTestTracking("ups", "CHN", "2022-11-01T10:00:00", "2022-11-02T10:00:00"))
+	transitTimes.Add(transitTestTracking("fedex", "DEU", "2022-11-01T10:00:00", "2022-11-04T10:00:00"))
+
+	stats := transitTimes.Stats()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, Lane{DestinationCountryISO3: "USA"}, stats[0].Lane)
+	assert.Equal(t, 2, stats[0].Count)
+	assert.Equal(t, 24*time.Hour, stats[0].P50)
+	assert.Equal(t, 72*time.Hour, stats[0].P90)
+}
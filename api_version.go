@@ -0,0 +1,279 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// APIVersion is the version of the AfterShip tracking API called by a Client
+type APIVersion string
+
+// API versions
+const (
+	// APIVersionV4 is the legacy API at https://api.aftership.com/v4, the default
+	APIVersionV4 APIVersion = "v4"
+
+	// APIVersion202407 is the date-versioned API at https://api.aftership.com/tracking/2024-07
+	APIVersion202407 APIVersion = "2024-07"
+)
+
+// datedAPIVersion matches the date-versioned APIs
+var datedAPIVersion = regexp.MustCompile(`^\d{4}-\d{2}$`)
+
+// dated returns true for the date-versioned APIs, which differ from v4 by:
+//   - the base URL, https://api.aftership.com/tracking/<version>
+//   - the as-api-key header of the API key
+//   - the tracking IDs in the paths, trackings given by slug and tracking number being looked up first
+//   - the tracking bodies and data, not wrapped in {"tracking": ...}
+//   - the customers of the trackings, replacing their emails, smses and customer name
+//   - the cursor pagination of GetTrackings
+func (version APIVersion) dated() bool {
+	return datedAPIVersion.MatchString(string(version))
+}
+
+// versioned is implemented by the APIs knowing their version: Client, and CachingClient through the API it wraps
+type versioned interface {
+	apiVersion() APIVersion
+}
+
+// apiVersion returns the configured API version of the client
+func (client *Client) apiVersion() APIVersion {
+	return client.Config.APIVersion
+}
+
+// apiVersionOf returns the version of api, or APIVersionV4 when it is unknown, such as for a mock
+func apiVersionOf(api interface{}) APIVersion {
+	if v, ok := api.(versioned); ok {
+		return v.apiVersion()
+	}
+	return APIVersionV4
+}
+
+// baseURL returns the default base URL of version
+func (version APIVersion) baseURL() string {
+	if version.dated() {
+		return "https://api.aftership.com/tracking/" + string(version)
+	}
+	return "https://api.aftership.com/v4"
+}
+
+// validate returns an error when version is neither v4 nor a date-versioned API
+func (version APIVersion) validate() error {
+	if version != APIVersionV4 && !version.dated() {
+		return errors.Errorf(errInvalidAPIVersion, version)
+	}
+	return nil
+}
+
+// identifierPath returns the URI path of identifier. With the date-versioned APIs, trackings identified by
+// slug and tracking number are looked up to use their ID, kept by the client for the next calls.
+// A stale lookup is used as is, and the other errors are wrapped with message.
+func (client *Client) identifierPath(ctx context.Context, identifier TrackingIdentifier, message string) (string, error) {
+	uriPath, err := identifier.URIPath()
+	if err != nil {
+		return "", errors.Wrap(err, message)
+	}
+
+	stn, ok := identifier.(SlugTrackingNumber)
+	if !ok || !client.Config.APIVersion.dated() {
+		return uriPath, nil
+	}
+
+	client.trackingIDsMu.Lock()
+	id, ok := client.trackingIDs[stn]
+	client.trackingIDsMu.Unlock()
+	if ok {
+		return TrackingID(id).URIPath()
+	}
+
+	paged, err := client.GetTrackings(ctx, GetTrackingsParams{Slug: stn.Slug, TrackingNumbers: stn.TrackingNumber})
+	var stale *StaleResponseError
+	if err != nil && !errors.As(err, &stale) {
+		return "", errors.Wrap(err, message)
+	}
+	for _, tracking := range paged.Trackings {
+		if tracking.Slug == stn.Slug && tracking.TrackingNumber == stn.TrackingNumber && tracking.ID != "" {
+			client.trackingIDsMu.Lock()
+			if client.trackingIDs == nil {
+				client.trackingIDs = make(map[SlugTrackingNumber]string)
+			}
+			client.trackingIDs[stn] = tracking.ID
+			client.trackingIDsMu.Unlock()
+			return TrackingID(tracking.ID).URIPath()
+		}
+	}
+	if stale != nil {
+		// Not the stale response of the call itself
+		return "", errors.Wrap(stale.Err, message)
+	}
+	return "", &APIError{
+		Code:    4004,
+		Type:    "NotFound",
+		Message: "Tracking does not exist.",
+		Path:    "/trackings" + uriPath,
+	}
+}
+
+// forgetTrackingID removes the looked up ID of identifier, once its tracking is deleted or moved to another slug
+func (client *Client) forgetTrackingID(identifier TrackingIdentifier) {
+	if stn, ok := identifier.(SlugTrackingNumber); ok {
+		client.trackingIDsMu.Lock()
+		delete(client.trackingIDs, stn)
+		client.trackingIDsMu.Unlock()
+	}
+}
+
+// datedCustomer is a customer of a tracking in the date-versioned APIs
+type datedCustomer struct {
+	Role        string `json:"role,omitempty"`
+	Name        string `json:"name,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+// datedCustomers returns the customers of the emails, smses and customer name of a v4 tracking
+func datedCustomers(emails []string, smses []string, name string) []datedCustomer {
+	var customers []datedCustomer
+	for i := 0; i < len(emails) || i < len(smses); i++ {
+		customer := datedCustomer{Role: "buyer"}
+		if i < len(emails) {
+			customer.Email = emails[i]
+		}
+		if i < len(smses) {
+			customer.PhoneNumber = smses[i]
+		}
+		customers = append(customers, customer)
+	}
+	if name != "" {
+		if len(customers) == 0 {
+			customers = append(customers, datedCustomer{Role: "buyer"})
+		}
+		customers[0].Name = name
+	}
+	return customers
+}
+
+// datedTrackingBody returns the body creating or updating a tracking with the date-versioned APIs:
+// params, with their emails, smses and customer name moved to customers
+func datedTrackingBody(params interface{}, emails []string, smses []string, name string) (map[string]interface{}, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling params to JSON")
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, errors.Wrap(err, "error marshalling params to JSON")
+	}
+	delete(body, "emails")
+	delete(body, "smses")
+	delete(body, "customer_name")
+	if customers := datedCustomers(emails, smses, name); customers != nil {
+		body["customers"] = customers
+	}
+	return body, nil
+}
+
+// datedTracking holds the fields of a date-versioned tracking mapped to other Tracking fields
+type datedTracking struct {
+	Customers                    []datedCustomer `json:"customers"`
+	CourierEstimatedDeliveryDate *struct {
+		EstimatedDeliveryDate string `json:"estimated_delivery_date"`
+	} `json:"courier_estimated_delivery_date"`
+}
+
+// unmarshalDatedTracking unmarshals a tracking of the date-versioned APIs
+func unmarshalDatedTracking(data []byte, tracking *Tracking) error {
+	if err := json.Unmarshal(data, tracking); err != nil {
+		return err
+	}
+
+	var dated datedTracking
+	if err := json.Unmarshal(data, &dated); err != nil {
+		return err
+	}
+	for _, customer := range dated.Customers {
+		if customer.Email != "" {
+			tracking.Emails = append(tracking.Emails, customer.Email)
+		}
+		if customer.PhoneNumber != "" {
+			tracking.SMSes = append(tracking.SMSes, customer.PhoneNumber)
+		}
+		if tracking.CustomerName == "" {
+			tracking.CustomerName = customer.Name
+		}
+	}
+	if dated.CourierEstimatedDeliveryDate != nil && tracking.ExpectedDelivery == "" {
+		tracking.ExpectedDelivery = dated.CourierEstimatedDeliveryDate.EstimatedDeliveryDate
+	}
+	return nil
+}
+
+// UnmarshalJSON unmarshals the data of the single tracking responses: {"tracking": ...} with v4,
+// and the tracking itself with the date-versioned APIs.
+func (w *trackingWrapper) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if tracking, ok := fields["tracking"]; ok {
+		return json.Unmarshal(tracking, &w.Tracking)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return unmarshalDatedTracking(data, &w.Tracking)
+}
+
+// UnmarshalJSON unmarshals the data of GetTrackings, mapping the cursor pagination of the date-versioned APIs,
+// or the PagedTrackings marshalled by the SDK.
+func (p *PagedTrackings) UnmarshalJSON(data []byte) error {
+	var paged struct {
+		Limit       int               `json:"limit"`
+		Count       int               `json:"count"`
+		Page        int               `json:"page"`
+		Trackings   []json.RawMessage `json:"trackings"`
+		NextCursor  string            `json:"next_cursor"`
+		HasNextPage bool              `json:"has_next_page"`
+		Pagination  *struct {
+			Total       int    `json:"total"`
+			NextCursor  string `json:"next_cursor"`
+			HasNextPage bool   `json:"has_next_page"`
+		} `json:"pagination"`
+	}
+	if err := json.Unmarshal(data, &paged); err != nil {
+		return err
+	}
+
+	*p = PagedTrackings{
+		Limit:       paged.Limit,
+		Count:       paged.Count,
+		Page:        paged.Page,
+		NextCursor:  paged.NextCursor,
+		HasNextPage: paged.HasNextPage,
+	}
+	if paged.Trackings != nil {
+		p.Trackings = make([]Tracking, len(paged.Trackings))
+	}
+	for i, raw := range paged.Trackings {
+		var err error
+		if paged.Pagination != nil {
+			err = unmarshalDatedTracking(raw, &p.Trackings[i])
+		} else {
+			err = json.Unmarshal(raw, &p.Trackings[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if paged.Pagination != nil {
+		p.Count = paged.Pagination.Total
+		p.NextCursor = paged.Pagination.NextCursor
+		p.HasNextPage = paged.Pagination.HasNextPage
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const datedTestTracking = `{"id": "5b74f4958776db0e00b6f5ed", "slug": "ups", "tracking_number": "1Z999AA10123456784",
+	"tag": "InTransit", "customers": [{"role": "buyer", "name": "Jane", "email": "jane@example.com", "phone_number": "+85291234567"}],
+	"courier_estimated_delivery_date": {"estimated_delivery_date": "2024-07-10"}}`
+
+func newDatedTestClient(t *testing.T) *Client {
+	client, err := NewClient(Config{APIKey: "YOUR_API_KEY", BaseURL: server.URL, APIVersion: APIVersion202407})
+	assert.Nil(t, err)
+	return client
+}
+
+func TestAPIVersionConfig(t *testing.T) {
+	client, err := NewClient(Config{APIKey: "YOUR_API_KEY", APIVersion: APIVersion202407})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://api.aftership.com/tracking/2024-07", client.Config.BaseURL)
+
+	client, err = NewClient(Config{APIKey: "YOUR_API_KEY"})
+	assert.Nil(t, err)
+	assert.Equal(t, APIVersionV4, client.Config.APIVersion)
+	assert.Equal(t, "https://api.aftership.com/v4", client.Config.BaseURL)
+
+	_, err = NewClient(Config{APIKey: "YOUR_API_KEY", APIVersion: "v5"})
+	assert.EqualError(t, err, `invalid API version "v5", use v4 or a date version such as 2024-07`)
+}
+
+func TestDatedAPITrackings(t *testing.T) {
+	setup()
+	defer teardown()
+	client := newDatedTestClient(t)
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "YOUR_API_KEY", r.Header.Get("as-api-key"))
+		assert.Empty(t, r.Header.Get("aftership-api-key"))
+
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "ups", r.URL.Query().Get("slug"))
+			if r.URL.Query().Get("tracking_numbers") != "1Z999AA10123456784" {
+				w.Write([]byte(`{"meta": {"code": 200}, "data": {"pagination": {"total": 0}, "trackings": []}}`))
+				return
+			}
+			w.Write([]byte(`{"meta": {"code": 200}, "data": {"pagination": {"total": 1, "has_next_page": false},
+				"trackings": [` + datedTestTracking + `]}}`))
+		case http.MethodPost:
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{"slug": "ups", "tracking_number": "1Z999AA10123456784",
+				"customers": [{"role": "buyer", "name": "Jane", "email": "jane@example.com", "phone_number": "+85291234567"}]}`, string(body))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"meta": {"code": 201}, "data": ` + datedTestTracking + `}`))
+		}
+	})
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var body map[string]interface{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]interface{}{"title": "Order #1001"}, body)
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": ` + datedTestTracking + `}`))
+	})
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed/retrack", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"id": "5b74f4958776db0e00b6f5ed", "active": true}}`))
+	})
+
+	ctx := context.Background()
+	tracking, err := client.CreateTracking(ctx, CreateTrackingParams{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+		Emails:         []string{"jane@example.com"},
+		SMSes:          []string{"+85291234567"},
+		CustomerName:   "Jane",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "5b74f4958776db0e00b6f5ed", tracking.ID)
+	assert.Equal(t, []string{"jane@example.com"}, tracking.Emails)
+	assert.Equal(t, []string{"+85291234567"}, tracking.SMSes)
+	assert.Equal(t, "Jane", tracking.CustomerName)
+	assert.Equal(t, "2024-07-10", tracking.ExpectedDelivery)
+
+	// Trackings given by slug and tracking number are looked up by ID
+	ups := SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+	tracking, err = client.GetTracking(ctx, ups, GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, TagInTransit, tracking.Tag)
+
+	tracking, err = client.UpdateTracking(ctx, ups, UpdateTrackingParams{Title: "Order #1001"})
+	assert.Nil(t, err)
+	assert.Equal(t, "5b74f4958776db0e00b6f5ed", tracking.ID)
+
+	tracking, err = client.RetrackTracking(ctx, TrackingID("5b74f4958776db0e00b6f5ed"))
+	assert.Nil(t, err)
+	assert.True(t, tracking.Active)
+
+	_, err = client.GetTracking(ctx, SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456785"}, GetTrackingParams{})
+	if assert.IsType(t, &APIError{}, err) {
+		assert.Equal(t, 4004, err.(*APIError).Code)
+	}
+}
+
+func TestDatedAPIPagination(t *testing.T) {
+	setup()
+	defer teardown()
+	client := newDatedTestClient(t)
+
+	pages := map[string]string{
+		"": `{"meta": {"code": 200}, "data": {"pagination": {"total": 4, "next_cursor": "c2", "has_next_page": true},
+			"trackings": [{"id": "1"}, {"id": "2"}]}}`,
+		"c2": `{"meta": {"code": 200}, "data": {"pagination": {"total": 4, "next_cursor": "c3", "has_next_page": true},
+			"trackings": [{"id": "3"}]}}`,
+		"c3": `{"meta": {"code": 200}, "data": {"pagination": {"total": 4, "has_next_page": false},
+			"trackings": [{"id": "4"}]}}`,
+	}
+	var cursors []string
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.Query().Get("page"))
+		cursors = append(cursors, r.URL.Query().Get("cursor"))
+		w.Write([]byte(pages[r.URL.Query().Get("cursor")]))
+	})
+
+	paged, err := client.GetTrackings(context.Background(), GetTrackingsParams{Page: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, paged.Count)
+	assert.Equal(t, "c2", paged.NextCursor)
+	assert.True(t, paged.HasNextPage)
+
+	// Walking follows the cursors, and stops on the last page
+	cursors = nil
+	var ids []string
+	err = WalkTrackings(context.Background(), client, GetTrackingsParams{}, func(tracking Tracking) error {
+		ids = append(ids, tracking.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+	assert.Equal(t, []string{"", "c2", "c3"}, cursors)
+}
+
+func TestDatedAPIStaleIfError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	down := false
+	lookups := 0
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"meta": {"code": 503, "type": "ServiceUnavailable"}, "data": {}}`))
+			return
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"pagination": {"total": 1}, "trackings": [` + datedTestTracking + `]}}`))
+	})
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"meta": {"code": 503, "type": "ServiceUnavailable"}, "data": {}}`))
+			return
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": ` + datedTestTracking + `}`))
+	})
+
+	store := NewMemoryResponseStore(0)
+	client := newDatedTestClient(t)
+	client.Config.StaleIfError = store
+	ctx := context.Background()
+	ups := SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+	_, err := client.GetTracking(ctx, ups, GetTrackingParams{})
+	assert.Nil(t, err)
+
+	// The looked up ID is kept, and the stale tracking returned
+	down = true
+	tracking, err := client.GetTracking(ctx, ups, GetTrackingParams{})
+	var staleErr *StaleResponseError
+	if assert.True(t, errors.As(err, &staleErr)) {
+		assert.Equal(t, "/trackings/5b74f4958776db0e00b6f5ed", staleErr.Path)
+	}
+	assert.Equal(t, "5b74f4958776db0e00b6f5ed", tracking.ID)
+	assert.Equal(t, 1, lookups)
+
+	// A stale lookup gives the ID of the stale tracking
+	client = newDatedTestClient(t)
+	client.Config.StaleIfError = store
+	tracking, err = client.GetTracking(ctx, ups, GetTrackingParams{})
+	if assert.True(t, errors.As(err, &staleErr)) {
+		assert.Equal(t, "/trackings/5b74f4958776db0e00b6f5ed", staleErr.Path)
+	}
+	assert.Equal(t, TagInTransit, tracking.Tag)
+
+	// Other lookup errors are wrapped
+	client = newDatedTestClient(t)
+	client.Config.StaleIfError = NewMemoryResponseStore(0)
+	_, err = client.GetTracking(ctx, ups, GetTrackingParams{})
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.False(t, errors.As(err, &staleErr))
+	assert.Contains(t, err.Error(), "error getting tracking: ")
+}
+
+func TestPagedTrackingsJSON(t *testing.T) {
+	paged := PagedTrackings{
+		Limit:       2,
+		Count:       4,
+		Trackings:   []Tracking{{ID: "1", Emails: []string{"jane@example.com"}}},
+		NextCursor:  "c2",
+		HasNextPage: true,
+	}
+	data, err := json.Marshal(paged)
+	assert.Nil(t, err)
+
+	var unmarshalled PagedTrackings
+	assert.Nil(t, json.Unmarshal(data, &unmarshalled))
+	assert.Equal(t, paged, unmarshalled)
+}
@@ -0,0 +1,151 @@
+package aftership
+
+import (
+	"context"
+	"time"
+)
+
+// CacheOptions configures a CachingClient. The zero value uses the defaults.
+type CacheOptions struct {
+	// Store keeps the fetched trackings. Defaults to a MemoryStore of DefaultMemoryStoreSize trackings.
+	Store Store
+
+	// MaxAge is how long a stored tracking is served without calling the API. Defaults to 1 minute.
+	MaxAge time.Duration
+}
+
+// CachingClient is an API serving GetTracking from a Store while the stored tracking is fresher than MaxAge,
+// and fetching it from the wrapped API otherwise. GetTracking calls with Fields or Lang are not cached.
+//
+// The trackings returned by CreateTracking, UpdateTracking and MarkTrackingAsCompleted refresh the store,
+// and DeleteTracking, RetrackTracking and the notification changes remove the tracking from it.
+// The other calls go to the wrapped API. The store is best effort: its read and write errors do not fail the API calls.
+type CachingClient struct {
+	API
+	store  Store
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+var _ API = (*CachingClient)(nil)
+
+// NewCachingClient returns a CachingClient wrapping api, usually a Client, with options.
+func NewCachingClient(api API, options CacheOptions) *CachingClient {
+	if options.Store == nil {
+		options.Store = NewMemoryStore(0)
+	}
+	if options.MaxAge <= 0 {
+		options.MaxAge = time.Minute
+	}
+	return &CachingClient{
+		API:    api,
+		store:  options.Store,
+		maxAge: options.MaxAge,
+		now:    time.Now,
+	}
+}
+
+// apiVersion returns the version of the wrapped API
+func (c *CachingClient) apiVersion() APIVersion {
+	return apiVersionOf(c.API)
+}
+
+// Store returns the store of the cached trackings
+func (c *CachingClient) Store() Store {
+	return c.store
+}
+
+// GetTracking returns the stored tracking of identifier when it is fresh, and fetches and stores it otherwise.
+func (c *CachingClient) GetTracking(ctx context.Context, identifier TrackingIdentifier, params GetTrackingParams) (Tracking, error) {
+	if params.Fields != "" || params.Lang != "" {
+		return c.API.GetTracking(ctx, identifier, params)
+	}
+
+	// Read errors fall through to the API, like a missing tracking
+	stored, ok, err := c.store.Get(ctx, identifier)
+	if err == nil && ok && c.now().Sub(stored.StoredAt) < c.maxAge {
+		return stored.Tracking, nil
+	}
+
+	tracking, err := c.API.GetTracking(ctx, identifier, params)
+	if err == nil {
+		c.put(ctx, tracking)
+	}
+	return tracking, err
+}
+
+// CreateTracking creates a tracking and stores it
+func (c *CachingClient) CreateTracking(ctx context.Context, params CreateTrackingParams) (Tracking, error) {
+	tracking, err := c.API.CreateTracking(ctx, params)
+	if err == nil {
+		c.put(ctx, tracking)
+	}
+	return tracking, err
+}
+
+// UpdateTracking updates a tracking and stores the updated tracking
+func (c *CachingClient) UpdateTracking(ctx context.Context, identifier TrackingIdentifier, params UpdateTrackingParams) (Tracking, error) {
+	tracking, err := c.API.UpdateTracking(ctx, identifier, params)
+	c.refresh(ctx, identifier, tracking, err)
+	return tracking, err
+}
+
+// MarkTrackingAsCompleted marks a tracking as completed and stores the completed tracking
+func (c *CachingClient) MarkTrackingAsCompleted(ctx context.Context, identifier TrackingIdentifier, status TrackingCompletedStatus) (Tracking, error) {
+	tracking, err := c.API.MarkTrackingAsCompleted(ctx, identifier, status)
+	c.refresh(ctx, identifier, tracking, err)
+	return tracking, err
+}
+
+// RetrackTracking retracks a tracking and removes it from the store, its checkpoints being about to change
+func (c *CachingClient) RetrackTracking(ctx context.Context, identifier TrackingIdentifier) (Tracking, error) {
+	tracking, err := c.API.RetrackTracking(ctx, identifier)
+	c.invalidate(ctx, identifier)
+	return tracking, err
+}
+
+// DeleteTracking deletes a tracking and removes it from the store
+func (c *CachingClient) DeleteTracking(ctx context.Context, identifier TrackingIdentifier) (Tracking, error) {
+	tracking, err := c.API.DeleteTracking(ctx, identifier)
+	c.invalidate(ctx, identifier)
+	return tracking, err
+}
+
+// AddNotification adds notification receivers to a tracking and removes it from the store
+func (c *CachingClient) AddNotification(ctx context.Context, identifier TrackingIdentifier, notification Notification) (Notification, error) {
+	notification, err := c.API.AddNotification(ctx, identifier, notification)
+	c.invalidate(ctx, identifier)
+	return notification, err
+}
+
+// RemoveNotification removes notification receivers from a tracking and removes it from the store
+func (c *CachingClient) RemoveNotification(ctx context.Context, identifier TrackingIdentifier, notification Notification) (Notification, error) {
+	notification, err := c.API.RemoveNotification(ctx, identifier, notification)
+	c.invalidate(ctx, identifier)
+	return notification, err
+}
+
+// put stores a tracking fetched now. Trackings without ID, nor slug and tracking number, cannot be stored.
+// The cache is best effort: a failed store write must not fail the API call, so its error is ignored and
+// the tracking is fetched again next time.
+func (c *CachingClient) put(ctx context.Context, tracking Tracking) {
+	if _, err := trackingKeys(tracking); err != nil {
+		return
+	}
+	_ = c.store.Put(ctx, StoredTracking{Tracking: tracking, StoredAt: c.now()})
+}
+
+// refresh replaces the stored tracking of identifier by the tracking returned by a successful write
+func (c *CachingClient) refresh(ctx context.Context, identifier TrackingIdentifier, tracking Tracking, err error) {
+	// The slug may have changed, leaving the tracking stored under the old one
+	c.invalidate(ctx, identifier)
+	if err == nil {
+		c.put(ctx, tracking)
+	}
+}
+
+// invalidate removes the stored tracking of identifier after a write, failed writes included as they may
+// have been applied. Like put, it is best effort.
+func (c *CachingClient) invalidate(ctx context.Context, identifier TrackingIdentifier) {
+	_ = c.store.Delete(ctx, identifier)
+}
@@ -0,0 +1,49 @@
+package aftership_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func ExampleCachingClient() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Keep the trackings on disk, and serve them for 5 minutes without calling the API.
+	store, err := aftership.NewFileStore("/var/lib/aftership/trackings")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cached := aftership.NewCachingClient(cli, aftership.CacheOptions{
+		Store:  store,
+		MaxAge: 5 * time.Minute,
+	})
+
+	tracking, err := cached.GetTracking(context.Background(), aftership.SlugTrackingNumber{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+	}, aftership.GetTrackingParams{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(tracking.Tag)
+
+	// The trackings of an order, as last fetched
+	order, err := store.ListByOrderID(context.Background(), tracking.OrderID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(len(order))
+}
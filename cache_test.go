@@ -0,0 +1,167 @@
+package aftership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCachingClient(maxAge time.Duration) (*CachingClient, *time.Time) {
+	now := storeTestTime
+	c := NewCachingClient(client, CacheOptions{MaxAge: maxAge})
+	c.now = func() time.Time {
+		return now
+	}
+	return c, &now
+}
+
+func TestCachingClientGetTracking(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/trackings/ups/1Z999AA10123456784", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed",
+			"slug": "ups", "tracking_number": "1Z999AA10123456784", "title": "call %d"}}}`, calls)
+	})
+
+	c, now := newTestCachingClient(time.Minute)
+	ctx := context.Background()
+	identifier := SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+
+	tracking, err := c.GetTracking(ctx, identifier, GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, "call 1", tracking.Title)
+
+	// Served from the store, by slug and tracking number or by ID
+	tracking, err = c.GetTracking(ctx, identifier, GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, "call 1", tracking.Title)
+	_, ok, _ := c.Store().Get(ctx, TrackingID("5b74f4958776db0e00b6f5ed"))
+	assert.True(t, ok)
+	assert.Equal(t, 1, calls)
+
+	// Partial trackings are not cached
+	tracking, err = c.GetTracking(ctx, identifier, GetTrackingParams{Fields: "title"})
+	assert.Nil(t, err)
+	assert.Equal(t, "call 2", tracking.Title)
+
+	*now = now.Add(time.Minute)
+	tracking, err = c.GetTracking(ctx, identifier, GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, "call 3", tracking.Title)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCachingClientWrites(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tracking := `{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed",
+		"slug": "%s", "tracking_number": "1Z999AA10123456784", "title": "%s"}}}`
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprintf(w, tracking, "ups", "fetched")
+		case http.MethodPut:
+			fmt.Fprintf(w, tracking, "fedex", "updated")
+		case http.MethodDelete:
+			fmt.Fprintf(w, tracking, "fedex", "deleted")
+		}
+	})
+	mux.HandleFunc("/trackings/fedex/1Z999AA10123456784/retrack", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed", "active": true}}}`))
+	})
+
+	c, _ := newTestCachingClient(time.Minute)
+	ctx := context.Background()
+	id := TrackingID("5b74f4958776db0e00b6f5ed")
+
+	_, err := c.GetTracking(ctx, id, GetTrackingParams{})
+	assert.Nil(t, err)
+
+	// Updates refresh the store, under the new slug
+	_, err = c.UpdateTracking(ctx, id, UpdateTrackingParams{Slug: "fedex"})
+	assert.Nil(t, err)
+	fedex := SlugTrackingNumber{Slug: "fedex", TrackingNumber: "1Z999AA10123456784"}
+	stored, ok, _ := c.Store().Get(ctx, fedex)
+	assert.True(t, ok)
+	assert.Equal(t, "updated", stored.Tracking.Title)
+	_, ok, _ = c.Store().Get(ctx, SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+	assert.False(t, ok)
+
+	// Retracks invalidate the store
+	_, err = c.RetrackTracking(ctx, fedex)
+	assert.Nil(t, err)
+	_, ok, _ = c.Store().Get(ctx, id)
+	assert.False(t, ok)
+
+	_, err = c.GetTracking(ctx, id, GetTrackingParams{})
+	assert.Nil(t, err)
+	_, err = c.DeleteTracking(ctx, id)
+	assert.Nil(t, err)
+	_, ok, _ = c.Store().Get(ctx, id)
+	assert.False(t, ok)
+}
+
+// failingStore is a Store whose reads and writes fail
+type failingStore struct {
+	*MemoryStore
+}
+
+func (s failingStore) Get(ctx context.Context, identifier TrackingIdentifier) (StoredTracking, bool, error) {
+	return StoredTracking{}, false, errors.New("disk unreadable")
+}
+
+func (s failingStore) Put(ctx context.Context, stored StoredTracking) error {
+	return errors.New("disk full")
+}
+
+func (s failingStore) Delete(ctx context.Context, identifier TrackingIdentifier) error {
+	return errors.New("disk full")
+}
+
+func TestCachingClientStoreErrors(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"meta": {"code": 201}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed",
+			"slug": "ups", "tracking_number": "1Z999AA10123456784"}}}`))
+	})
+	gets := 0
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed",
+			"slug": "ups", "tracking_number": "1Z999AA10123456784", "title": "Order #1001"}}}`))
+	})
+
+	// The API calls succeed when the store cannot be read nor written
+	c := NewCachingClient(client, CacheOptions{Store: failingStore{NewMemoryStore(0)}})
+	ctx := context.Background()
+	tracking, err := c.CreateTracking(ctx, CreateTrackingParams{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+	assert.Nil(t, err)
+	assert.Equal(t, "5b74f4958776db0e00b6f5ed", tracking.ID)
+
+	id := TrackingID("5b74f4958776db0e00b6f5ed")
+	for i := 0; i < 2; i++ {
+		tracking, err = c.GetTracking(ctx, id, GetTrackingParams{})
+		assert.Nil(t, err)
+		assert.Equal(t, "Order #1001", tracking.Title)
+	}
+	assert.Equal(t, 2, gets)
+	tracking, err = c.UpdateTracking(ctx, id, UpdateTrackingParams{Title: "Order #1001"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Order #1001", tracking.Title)
+	_, err = c.DeleteTracking(ctx, id)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,238 @@
+package checkdigit
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Validation errors
+var (
+	// ErrInvalidFormat is returned when a tracking number does not have the format of the algorithm
+	ErrInvalidFormat = errors.New("tracking number has an invalid format")
+
+	// ErrInvalidCheckDigit is returned when the check digit of a tracking number does not match
+	ErrInvalidCheckDigit = errors.New("tracking number has an invalid check digit")
+)
+
+// Func validates the check digit of a normalized tracking number
+type Func func(trackingNumber string) error
+
+var (
+	s10Format    = regexp.MustCompile(`^[A-Z]{2}\d{9}[A-Z]{2}$`)
+	upsFormat    = regexp.MustCompile(`^1Z[0-9A-Z]{16}$`)
+	impbFormat   = regexp.MustCompile(`^(420\d{5}(\d{4})?)?(9[1-5](\d{20}|\d{24}))$`)
+	fedExFormat  = regexp.MustCompile(`^(\d{12}|\d{15}|\d{22}|\d{34})$`)
+	dhlFormat    = regexp.MustCompile(`^\d{10}$`)
+	digitsFormat = regexp.MustCompile(`^\d+$`)
+)
+
+// s10Weights are the UPU S10 weights of the 8 serial number digits
+var s10Weights = []int{8, 6, 4, 2, 3, 5, 9, 7}
+
+// S10 validates a UPU S10 item identifier, e.g. RA123456785GB, with the mod 11 check digit.
+func S10(trackingNumber string) error {
+	if !s10Format.MatchString(trackingNumber) {
+		return ErrInvalidFormat
+	}
+
+	sum := 0
+	for i, weight := range s10Weights {
+		sum += digit(trackingNumber[2+i]) * weight
+	}
+
+	check := 11 - sum%11
+	switch check {
+	case 10:
+		check = 0
+	case 11:
+		check = 5
+	}
+
+	if check != digit(trackingNumber[10]) {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// UPS validates a UPS 1Z tracking number. Letters count as (position in the alphabet + 1) mod 10.
+func UPS(trackingNumber string) error {
+	if !upsFormat.MatchString(trackingNumber) {
+		return ErrInvalidFormat
+	}
+
+	body := trackingNumber[2:17]
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		value := digit(body[i])
+		if body[i] >= 'A' && body[i] <= 'Z' {
+			value = int(body[i]-'A'+2) % 10
+		}
+
+		if i%2 == 1 {
+			value *= 2
+		}
+		sum += value
+	}
+
+	if (10-sum%10)%10 != digit(trackingNumber[17]) {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// IMpb validates a USPS Intelligent Mail package barcode with the mod 10 check digit.
+// A leading 420 routing code with a 5 or 9 digit ZIP code is ignored.
+func IMpb(trackingNumber string) error {
+	matches := impbFormat.FindStringSubmatch(trackingNumber)
+	if matches == nil {
+		return ErrInvalidFormat
+	}
+
+	return Mod10(matches[3])
+}
+
+// Mod10 validates the last digit of a numeric tracking number with weights 3 and 1, alternating from the right.
+// It is used by USPS IMpb, GS1 SSCC and FedEx Ground.
+func Mod10(trackingNumber string) error {
+	if len(trackingNumber) < 2 || !digitsFormat.MatchString(trackingNumber) {
+		return ErrInvalidFormat
+	}
+
+	last := len(trackingNumber) - 1
+	sum := 0
+	for i := last - 1; i >= 0; i-- {
+		value := digit(trackingNumber[i])
+		if (last-i)%2 == 1 {
+			value *= 3
+		}
+		sum += value
+	}
+
+	if (10-sum%10)%10 != digit(trackingNumber[last]) {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// fedExExpressWeights are the FedEx Express weights, applied from the right of the 11 digit serial number
+var fedExExpressWeights = []int{1, 3, 7}
+
+// FedEx validates FedEx Express (12 digits), FedEx Ground (15 digits), FedEx Ground 96 (22 digits)
+// and FedEx SmartPost (34 digits) tracking numbers. The 22 and 34 digit numbers are checked on their
+// trailing 15 digit serial number.
+func FedEx(trackingNumber string) error {
+	if !digitsFormat.MatchString(trackingNumber) {
+		return ErrInvalidFormat
+	}
+
+	switch len(trackingNumber) {
+	case 12:
+		sum := 0
+		for i := 0; i < 11; i++ {
+			sum += digit(trackingNumber[10-i]) * fedExExpressWeights[i%3]
+		}
+		if sum%11%10 != digit(trackingNumber[11]) {
+			return ErrInvalidCheckDigit
+		}
+		return nil
+	case 15:
+		return Mod10(trackingNumber)
+	case 22:
+		return Mod10(trackingNumber[7:])
+	case 34:
+		return Mod10(trackingNumber[19:])
+	}
+
+	return ErrInvalidFormat
+}
+
+// DHLExpress validates a 10 digit DHL Express waybill number with the mod 7 check digit.
+func DHLExpress(trackingNumber string) error {
+	if len(trackingNumber) != 10 || !digitsFormat.MatchString(trackingNumber) {
+		return ErrInvalidFormat
+	}
+
+	serial := 0
+	for i := 0; i < 9; i++ {
+		serial = (serial*10 + digit(trackingNumber[i])) % 7
+	}
+
+	if serial != digit(trackingNumber[9]) {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// postal validates the S10 and IMpb formats used by postal services
+func postal(trackingNumber string) error {
+	if s10Format.MatchString(trackingNumber) {
+		return S10(trackingNumber)
+	}
+	if impbFormat.MatchString(trackingNumber) {
+		return IMpb(trackingNumber)
+	}
+	return nil
+}
+
+// checked validates the tracking numbers matching format with validate, and accepts the other formats,
+// whose check digit algorithm is unknown
+func checked(format *regexp.Regexp, validate Func) Func {
+	return func(trackingNumber string) error {
+		if !format.MatchString(trackingNumber) {
+			return nil
+		}
+		return validate(trackingNumber)
+	}
+}
+
+// bySlug maps courier slugs to their check digit algorithms
+var bySlug = map[string]Func{
+	"ups":                  checked(upsFormat, UPS),
+	"usps":                 postal,
+	"fedex":                checked(fedExFormat, FedEx),
+	"dhl":                  checked(dhlFormat, DHLExpress),
+	"royal-mail":           postal,
+	"deutsch-post":         postal,
+	"china-post":           postal,
+	"china-ems":            postal,
+	"hong-kong-post":       postal,
+	"canada-post":          postal,
+	"australia-post":       postal,
+	"la-poste-colissimo":   postal,
+	"postnl-international": postal,
+	"japan-post":           postal,
+	"singapore-post":       postal,
+}
+
+// Supported reports whether there is a check digit algorithm for the courier slug.
+func Supported(slug string) bool {
+	_, ok := bySlug[slug]
+	return ok
+}
+
+// Normalize upper cases trackingNumber and strips spaces and dashes.
+func Normalize(trackingNumber string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(trackingNumber))
+}
+
+// Validate checks the tracking number against the check digit algorithm of the courier slug.
+// Tracking numbers of unsupported couriers, and tracking numbers in formats without a known
+// check digit, e.g. UPS T1234567890, are accepted.
+func Validate(slug, trackingNumber string) error {
+	validate, ok := bySlug[slug]
+	if !ok {
+		return nil
+	}
+	return validate(Normalize(trackingNumber))
+}
+
+func digit(c byte) int {
+	return int(c - '0')
+}
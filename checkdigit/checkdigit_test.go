@@ -0,0 +1,79 @@
+package checkdigit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS10(t *testing.T) {
+	assert.Nil(t, S10("RA123456785GB"))
+	assert.Nil(t, S10("EE000000005US"))
+	assert.Equal(t, ErrInvalidCheckDigit, S10("RA123456784GB"))
+	assert.Equal(t, ErrInvalidFormat, S10("RA12345678GB"))
+	assert.Equal(t, ErrInvalidFormat, S10("ra123456785gb"))
+}
+
+func TestUPS(t *testing.T) {
+	assert.Nil(t, UPS("1Z999AA10123456784"))
+	assert.Equal(t, ErrInvalidCheckDigit, UPS("1Z999AA10123456785"))
+	assert.Equal(t, ErrInvalidFormat, UPS("1Z999AA1012345678"))
+}
+
+func TestIMpb(t *testing.T) {
+	assert.Nil(t, IMpb("9205590164917312751089"))
+	assert.Nil(t, IMpb("9400111899223197428497"))
+	assert.Nil(t, IMpb("420921559205590164917312751089"))
+	assert.Equal(t, ErrInvalidCheckDigit, IMpb("9400111899223197428490"))
+	assert.Equal(t, ErrInvalidFormat, IMpb("8400111899223197428497"))
+}
+
+func TestMod10(t *testing.T) {
+	assert.Nil(t, Mod10("00"))
+	assert.Nil(t, Mod10("449044304137821"))
+	assert.Equal(t, ErrInvalidCheckDigit, Mod10("449044304137822"))
+	assert.Equal(t, ErrInvalidFormat, Mod10("1"))
+	assert.Equal(t, ErrInvalidFormat, Mod10("44904430413782A"))
+}
+
+func TestFedEx(t *testing.T) {
+	assert.Nil(t, FedEx("797806677146"))
+	assert.Nil(t, FedEx("449044304137821"))
+	assert.Nil(t, FedEx("9612019059803563050071"))
+	assert.Nil(t, FedEx("9261292700000000000"+"449044304137821"))
+	assert.Equal(t, ErrInvalidCheckDigit, FedEx("797806677145"))
+	assert.Equal(t, ErrInvalidCheckDigit, FedEx("9612019059803563050072"))
+	assert.Equal(t, ErrInvalidFormat, FedEx("12345"))
+	assert.Equal(t, ErrInvalidFormat, FedEx("79780667714A"))
+}
+
+func TestDHLExpress(t *testing.T) {
+	assert.Nil(t, DHLExpress("3318810025"))
+	assert.Equal(t, ErrInvalidCheckDigit, DHLExpress("3318810026"))
+	assert.Equal(t, ErrInvalidFormat, DHLExpress("331881002"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.True(t, Supported("ups"))
+	assert.False(t, Supported("unknown"))
+
+	assert.Nil(t, Validate("ups", "1z 999 aa1 0123 4567 84"))
+	assert.Equal(t, ErrInvalidCheckDigit, Validate("ups", "1Z999AA10123456785"))
+
+	// formats without a known check digit are accepted
+	assert.Nil(t, Validate("ups", "T1234567890"))
+	assert.Nil(t, Validate("fedex", "12345678901234567890"))
+	assert.Nil(t, Validate("dhl", "JJD000390007827123456"))
+	assert.Nil(t, Validate("dhl", "JD014600006281230704"))
+	assert.Equal(t, ErrInvalidCheckDigit, Validate("fedex", "797806677145"))
+	assert.Equal(t, ErrInvalidCheckDigit, Validate("dhl", "3318810026"))
+
+	// postal services check S10 and IMpb, and accept other formats
+	assert.Nil(t, Validate("usps", "9205-5901-6491-7312-7510-89"))
+	assert.Equal(t, ErrInvalidCheckDigit, Validate("usps", "EC123456784US"))
+	assert.Nil(t, Validate("usps", "8212345678"))
+	assert.Equal(t, ErrInvalidCheckDigit, Validate("royal-mail", "RA123456784GB"))
+
+	// unsupported couriers are accepted
+	assert.Nil(t, Validate("unknown", "anything"))
+}
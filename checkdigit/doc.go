@@ -0,0 +1,5 @@
+/*
+Package checkdigit validates the check digits of tracking numbers
+using the public algorithms of the couriers.
+*/
+package checkdigit
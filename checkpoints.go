@@ -3,9 +3,6 @@ package aftership
 import (
 	"context"
 	"fmt"
-	"net/http"
-
-	"github.com/pkg/errors"
 )
 
 // GetCheckpointParams is the additional parameters in checkpoint query
@@ -35,13 +32,13 @@ type LastCheckpoint struct {
 
 // GetLastCheckpoint returns the tracking information of the last checkpoint of a single tracking.
 func (client *Client) GetLastCheckpoint(ctx context.Context, identifier TrackingIdentifier, params GetCheckpointParams) (LastCheckpoint, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error getting last checkpoint")
 	if err != nil {
-		return LastCheckpoint{}, errors.Wrap(err, "error getting last checkpoint")
+		return LastCheckpoint{}, err
 	}
 
 	uriPath = fmt.Sprintf("/last_checkpoint%s", uriPath)
 	var lastCheckpoint LastCheckpoint
-	err = client.makeRequest(ctx, http.MethodGet, uriPath, params, nil, &lastCheckpoint)
+	err = client.makeReadRequest(ctx, uriPath, params, &lastCheckpoint)
 	return lastCheckpoint, err
 }
@@ -32,3 +32,28 @@ func ExampleClient_GetLastCheckpoint() {
 
 	fmt.Println(result)
 }
+
+func ExampleNewTimeline() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	tracking, err := cli.GetTracking(context.Background(), aftership.TrackingID("5b74f4958776db0e00b6f5ed"), aftership.GetTrackingParams{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	timeline := aftership.NewTimeline(tracking.Checkpoints, aftership.TimelineOptions{})
+	for _, leg := range timeline.Legs {
+		fmt.Println(leg.Location, leg.Arrived, leg.Dwell)
+	}
+	for _, anomaly := range timeline.Anomalies {
+		fmt.Println(anomaly.Type, anomaly.Message)
+	}
+}
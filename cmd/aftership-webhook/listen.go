@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+// maxBodySize is the maximum size of the webhook bodies accepted by listen
+const maxBodySize = 10 << 20
+
+func listen(ctx context.Context, e *env, args []string) error {
+	flags, secret := e.flagSet("listen", "")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	forward := flags.String("forward", "", "URL to forward the verified webhooks to")
+	save := flags.String("save", "", "directory to save the webhook payloads to")
+	raw := flags.Bool("json", false, "print the webhook payloads instead of the tracking changes")
+	if err := e.parse(flags, args, secret); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return &usageError{message: fmt.Sprintf("unexpected argument %q", flags.Arg(0))}
+	}
+	if *secret == "" {
+		fmt.Fprintf(e.stderr, "warning: no webhook secret, signatures are not verified\n")
+	}
+	if *save != "" {
+		if err := os.MkdirAll(*save, 0o755); err != nil {
+			return errors.Wrap(err, "error creating the save directory")
+		}
+	}
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: newListener(e, *secret, *forward, *save, *raw),
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+		case <-ctx.Done():
+		}
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(e.stderr, "listening on %s\n", *addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return errors.Wrap(err, "error listening")
+	}
+	return nil
+}
+
+// listener is the HTTP handler receiving the webhooks
+type listener struct {
+	env     *env
+	secret  string
+	forward string
+	save    string
+	raw     bool
+
+	mu        sync.Mutex
+	trackings map[string]aftership.Tracking // The last received trackings by ID, to print their changes
+}
+
+func newListener(e *env, secret string, forward string, save string, raw bool) *listener {
+	return &listener{
+		env:       e,
+		secret:    secret,
+		forward:   forward,
+		save:      save,
+		raw:       raw,
+		trackings: make(map[string]aftership.Tracking),
+	}
+}
+
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+
+	if l.secret != "" && !aftership.VerifyWebhookSignature(body, r.Header.Get(aftership.HeaderWebhookSignature), l.secret) {
+		fmt.Fprintf(l.env.stderr, "rejected webhook from %s: invalid signature\n", r.RemoteAddr)
+		http.Error(w, "invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	var webhook aftership.Webhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		fmt.Fprintf(l.env.stderr, "rejected webhook from %s: %v\n", r.RemoteAddr, err)
+		http.Error(w, "invalid webhook body", http.StatusBadRequest)
+		return
+	}
+
+	l.print(webhook, body)
+
+	if l.save != "" {
+		if err := l.saveBody(webhook, body); err != nil {
+			fmt.Fprintf(l.env.stderr, "error saving webhook %s: %v\n", webhook.EventID, err)
+		}
+	}
+
+	status := http.StatusOK
+	if l.forward != "" {
+		status, err = l.env.deliver(r.Context(), l.forward, body, l.secret)
+		if err != nil {
+			fmt.Fprintf(l.env.stderr, "error forwarding webhook %s: %v\n", webhook.EventID, err)
+			status = http.StatusBadGateway
+		} else {
+			fmt.Fprintf(l.env.stdout, "  forwarded to %s: %d\n", l.forward, status)
+		}
+	}
+	w.WriteHeader(status)
+}
+
+// print prints the webhook, or the changes of its tracking since the previous webhook
+func (l *listener) print(webhook aftership.Webhook, body []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := l.env.stdout
+	if l.raw {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			indented.Reset()
+			indented.Write(body)
+		}
+		indented.WriteByte('\n')
+		_, _ = indented.WriteTo(out)
+		return
+	}
+
+	tracking := webhook.Msg
+	previous, seen := l.trackings[tracking.ID]
+	l.trackings[tracking.ID] = tracking
+
+	fmt.Fprintf(out, "%s %s %s %s/%s\n", time.Unix(webhook.TS, 0).UTC().Format(time.RFC3339), webhook.Event,
+		webhook.EventID, tracking.Slug, tracking.TrackingNumber)
+
+	switch {
+	case !seen:
+		fmt.Fprintf(out, "  tag: %s\n", tagDescription(tracking))
+	case previous.Tag != tracking.Tag || previous.Subtag != tracking.Subtag:
+		fmt.Fprintf(out, "  tag: %s -> %s\n", tagDescription(previous), tagDescription(tracking))
+	}
+
+	// The checkpoints received since the previous webhook, or the last one
+	checkpoints := tracking.Checkpoints
+	if seen && len(previous.Checkpoints) <= len(checkpoints) {
+		checkpoints = checkpoints[len(previous.Checkpoints):]
+	} else if len(checkpoints) > 0 {
+		checkpoints = checkpoints[len(checkpoints)-1:]
+	}
+	for _, checkpoint := range checkpoints {
+		fmt.Fprintf(out, "  checkpoint: %s %s %s: %s\n", checkpoint.CheckpointTime, checkpoint.Tag,
+			checkpoint.DisplayLocation(), checkpoint.Message)
+	}
+
+	if tracking.ExpectedDelivery != "" && (!seen || previous.ExpectedDelivery != tracking.ExpectedDelivery) {
+		fmt.Fprintf(out, "  expected delivery: %s\n", tracking.ExpectedDelivery)
+	}
+}
+
+// unsafeFileChars are the characters replaced in the names of the saved payloads
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// saveBody saves the body of a webhook in the save directory
+func (l *listener) saveBody(webhook aftership.Webhook, body []byte) error {
+	name := fmt.Sprintf("%d-%s.json", webhook.TS, unsafeFileChars.ReplaceAllString(webhook.EventID, "_"))
+	return ioutil.WriteFile(filepath.Join(l.save, name), body, 0o644)
+}
+
+func tagDescription(tracking aftership.Tracking) string {
+	if tracking.Subtag == "" {
+		return tracking.Tag
+	}
+	return fmt.Sprintf("%s (%s %s)", tracking.Tag, tracking.Subtag, tracking.SubtagMessage)
+}
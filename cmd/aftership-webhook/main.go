@@ -0,0 +1,173 @@
+/*
+Command aftership-webhook helps developing AfterShip webhook consumers locally.
+
+Usage:
+
+	aftership-webhook listen [-addr :8080] [-forward URL] [-save DIR] [-json]
+	aftership-webhook replay -url URL FILE...
+	aftership-webhook send -url URL [-event tracking_update] [-print] TRACKING_FILE
+
+listen runs an HTTP server accepting the tracking webhooks, verifying their signature and printing the changes
+of the trackings. The webhooks can be forwarded to a local URL, and saved to replay them later.
+
+replay sends saved webhook payloads to a URL, signed with the webhook secret.
+
+send sends a synthetic webhook of a tracking, read from a JSON file holding a Tracking or a GetTracking response,
+signed with the webhook secret.
+
+The webhook secret is read from the -secret flag or the AFTERSHIP_WEBHOOK_SECRET environment variable.
+*/
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+// envSecret is the environment variable of the webhook secret
+const envSecret = "AFTERSHIP_WEBHOOK_SECRET"
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a command of the tool
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = []command{
+	{"listen", "", "Receive, verify and print webhooks", listen},
+	{"replay", "FILE...", "Send saved webhook payloads", replay},
+	{"send", "TRACKING_FILE", "Send a synthetic webhook of a tracking", send},
+}
+
+// env is the environment of the commands
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	client *http.Client
+}
+
+// usageError is an error in the command line
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run runs the command line args and returns the exit code
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, getenv func(string) string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		e := &env{stdin: stdin, stdout: stdout, stderr: stderr, getenv: getenv, client: &http.Client{Timeout: 30 * time.Second}}
+		err := cmd.run(context.Background(), e, args[1:])
+		switch err.(type) {
+		case nil:
+			return exitOK
+		case *usageError:
+			fmt.Fprintf(stderr, "%s\nusage: aftership-webhook %s [flags] %s\n", err, cmd.name, cmd.args)
+			return exitUsage
+		}
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "aftership-webhook %s: %v\n", cmd.name, err)
+		return exitError
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	printUsage(stderr)
+	return exitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: aftership-webhook <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-22s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintf(w, "\nThe webhook secret defaults to $%s.\n", envSecret)
+}
+
+// flagSet returns the flag set of the command name, with the -secret flag
+func (e *env) flagSet(name string, args string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: aftership-webhook %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	secret := flags.String("secret", "", "webhook secret, defaults to $"+envSecret)
+	return flags, secret
+}
+
+// parse parses the flags of args, the secret defaulting to its environment variable. The environment is read
+// after parsing, for the usage not to print the secret as default.
+func (e *env) parse(flags *flag.FlagSet, args []string, secret *string) error {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return &usageError{message: err.Error()}
+	}
+	if *secret == "" {
+		*secret = e.getenv(envSecret)
+	}
+	return nil
+}
+
+// deliver posts a webhook body to url, signed with secret when not empty, and returns the response status
+func (e *env) deliver(ctx context.Context, url string, body []byte, secret string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "HTTP request creation failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(aftership.HeaderWebhookSignature, aftership.WebhookSignature(body, secret))
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "HTTP request failed")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
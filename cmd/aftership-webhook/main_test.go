@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "webhook-secret"
+
+// runTool runs the command line args and returns the exit code, the output and the errors
+func runTool(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr, func(key string) string {
+		if key == envSecret {
+			return testSecret
+		}
+		return ""
+	})
+	return code, stdout.String(), stderr.String()
+}
+
+func newTestEnv() (*env, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &env{stdout: &stdout, stderr: &stderr, client: http.DefaultClient}, &stdout, &stderr
+}
+
+func webhookBody(t *testing.T, tracking aftership.Tracking) []byte {
+	body, err := json.Marshal(aftership.Webhook{
+		Event:   aftership.WebhookEventTrackingUpdate,
+		EventID: "event-" + tracking.Tag,
+		Msg:     tracking,
+		TS:      1667390400,
+	})
+	assert.Nil(t, err)
+	return body
+}
+
+func post(handler http.Handler, body []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(aftership.HeaderWebhookSignature, signature)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestListener(t *testing.T) {
+	var forwarded [][]byte
+	forward := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !aftership.VerifyWebhookSignature(body, r.Header.Get(aftership.HeaderWebhookSignature), testSecret) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		forwarded = append(forwarded, body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer forward.Close()
+
+	dir, err := ioutil.TempDir("", "webhooks")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	e, stdout, stderr := newTestEnv()
+	l := newListener(e, testSecret, forward.URL, dir, false)
+
+	tracking := aftership.Tracking{
+		ID:             "5b7658cec7c33c0e007de3c5",
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+		Tag:            aftership.TagInTransit,
+		Checkpoints: []aftership.Checkpoint{
+			{Tag: aftership.TagInTransit, City: "Louisville", CountryName: "USA", Message: "Departed", CheckpointTime: "2022-11-01T08:00:00-04:00"},
+		},
+	}
+	body := webhookBody(t, tracking)
+	w := post(l, body, aftership.WebhookSignature(body, testSecret))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, stdout.String(), "tracking_update event-InTransit ups/1Z999AA10123456784")
+	assert.Contains(t, stdout.String(), "tag: InTransit\n")
+	assert.Contains(t, stdout.String(), "checkpoint: 2022-11-01T08:00:00-04:00 InTransit Louisville, USA: Departed")
+
+	stdout.Reset()
+	tracking.Tag = aftership.TagDelivered
+	tracking.Subtag = "Delivered_001"
+	tracking.SubtagMessage = "Delivered"
+	tracking.Checkpoints = append(tracking.Checkpoints, aftership.Checkpoint{
+		Tag: aftership.TagDelivered, Location: "Brooklyn, NY", Message: "Left at front door", CheckpointTime: "2022-11-02T11:58:00-04:00",
+	})
+	body = webhookBody(t, tracking)
+	w = post(l, body, aftership.WebhookSignature(body, testSecret))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, stdout.String(), "tag: InTransit -> Delivered (Delivered_001 Delivered)")
+	assert.Contains(t, stdout.String(), "Brooklyn, NY: Left at front door")
+	assert.NotContains(t, stdout.String(), "Departed")
+	assert.Len(t, forwarded, 2)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	// Invalid signatures are rejected, and not forwarded
+	w = post(l, body, aftership.WebhookSignature(body, "other-secret"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, stderr.String(), "invalid signature")
+	assert.Len(t, forwarded, 2)
+
+	w = post(l, []byte("{"), aftership.WebhookSignature([]byte("{"), testSecret))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestReplayAndSend(t *testing.T) {
+	var received []aftership.Webhook
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		webhook, err := aftership.ParseWebhook(body, r.Header.Get(aftership.HeaderWebhookSignature), testSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = append(received, webhook)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "webhooks")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	trackingFile := filepath.Join(dir, "tracking.json")
+	assert.Nil(t, ioutil.WriteFile(trackingFile,
+		[]byte(`{"meta":{"code":200},"data":{"tracking":{"id":"1","slug":"dhl","tracking_number":"1234567890","tag":"OutForDelivery"}}}`), 0o644))
+
+	code, stdout, stderr := runTool("send", "-url", server.URL, "-first", trackingFile)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "200 OK\n", stdout)
+	if assert.Len(t, received, 1) {
+		assert.True(t, received[0].IsTrackingFirstTracking)
+		assert.NotEmpty(t, received[0].EventID)
+		assert.Equal(t, "1234567890", received[0].Msg.TrackingNumber)
+		assert.Equal(t, aftership.TagOutForDelivery, received[0].Msg.Tag)
+	}
+
+	savedFile := filepath.Join(dir, "saved.json")
+	assert.Nil(t, ioutil.WriteFile(savedFile, webhookBody(t, aftership.Tracking{Slug: "ups", Tag: aftership.TagPending}), 0o644))
+
+	code, stdout, stderr = runTool("replay", "-url", server.URL, savedFile)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, savedFile+": 200 OK\n", stdout)
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "event-Pending", received[1].EventID)
+	}
+
+	code, stdout, _ = runTool("replay", "-url", server.URL, "-secret", "other-secret", savedFile)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, savedFile+": 401 Unauthorized\n", stdout)
+
+	code, stdout, stderr = runTool("send", "-print", trackingFile)
+	assert.Equal(t, exitOK, code, stderr)
+	lines := strings.SplitN(stdout, "\n", 2)
+	signature := strings.TrimPrefix(lines[0], aftership.HeaderWebhookSignature+": ")
+	assert.True(t, aftership.VerifyWebhookSignature([]byte(strings.TrimSpace(lines[1])), signature, testSecret))
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stderr string
+	}{
+		{[]string{"serve"}, `unknown command "serve"`},
+		{[]string{"send", "tracking.json"}, "missing -url"},
+		{[]string{"send", "-url", "http://localhost"}, "expected 1 argument(s), got 0"},
+		{[]string{"replay", "-url", "http://localhost"}, "no webhook file to replay"},
+		{[]string{"listen", "extra"}, `unexpected argument "extra"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, _, stderr := runTool(tt.args...)
+			assert.Equal(t, exitUsage, code)
+			assert.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
+
+func TestUsageHidesSecret(t *testing.T) {
+	for _, args := range [][]string{{"listen", "-h"}, {"replay", "-h"}, {"send", "-bogus"}} {
+		code, _, stderr := runTool(args...)
+		assert.NotEqual(t, exitError, code)
+		assert.Contains(t, stderr, "defaults to $"+envSecret)
+		assert.NotContains(t, stderr, testSecret)
+	}
+}
+
+func TestListenerRaw(t *testing.T) {
+	e, stdout, _ := newTestEnv()
+	l := newListener(e, testSecret, "", "", true)
+
+	body := webhookBody(t, aftership.Tracking{Slug: "ups", Tag: aftership.TagPending})
+	w := post(l, body, aftership.WebhookSignature(body, testSecret))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, stdout.String(), "\n  \"event_id\": \"event-Pending\",\n")
+	assert.True(t, strings.HasSuffix(stdout.String(), "}\n"))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+func replay(ctx context.Context, e *env, args []string) error {
+	flags, secret := e.flagSet("replay", "FILE...")
+	url := flags.String("url", "", "URL to send the webhooks to")
+	if err := e.parse(flags, args, secret); err != nil {
+		return err
+	}
+	if *url == "" {
+		return &usageError{message: "missing -url"}
+	}
+	if flags.NArg() == 0 {
+		return &usageError{message: "no webhook file to replay"}
+	}
+
+	for _, name := range flags.Args() {
+		body, err := ioutil.ReadFile(name)
+		if err != nil {
+			return errors.Wrap(err, "error reading webhook")
+		}
+		if !json.Valid(body) {
+			return errors.Errorf("invalid webhook JSON in %s", name)
+		}
+
+		status, err := e.deliver(ctx, *url, body, *secret)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "%s: %d %s\n", name, status, http.StatusText(status))
+	}
+	return nil
+}
+
+func send(ctx context.Context, e *env, args []string) error {
+	flags, secret := e.flagSet("send", "TRACKING_FILE")
+	url := flags.String("url", "", "URL to send the webhook to")
+	event := flags.String("event", aftership.WebhookEventTrackingUpdate, "event of the webhook")
+	first := flags.Bool("first", false, "mark the webhook as the first one of the tracking")
+	printOnly := flags.Bool("print", false, "print the webhook and its signature instead of sending it")
+	if err := e.parse(flags, args, secret); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return &usageError{message: fmt.Sprintf("expected 1 argument(s), got %d", flags.NArg())}
+	}
+	if *url == "" && !*printOnly {
+		return &usageError{message: "missing -url"}
+	}
+
+	tracking, err := readTracking(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(aftership.Webhook{
+		Event:                   *event,
+		EventID:                 uuid.New().String(),
+		IsTrackingFirstTracking: *first,
+		Msg:                     tracking,
+		TS:                      time.Now().Unix(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "error marshalling webhook")
+	}
+
+	if *printOnly {
+		if *secret != "" {
+			fmt.Fprintf(e.stdout, "%s: %s\n", aftership.HeaderWebhookSignature, aftership.WebhookSignature(body, *secret))
+		}
+		fmt.Fprintf(e.stdout, "%s\n", body)
+		return nil
+	}
+
+	status, err := e.deliver(ctx, *url, body, *secret)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "%d %s\n", status, http.StatusText(status))
+	return nil
+}
+
+// readTracking reads a tracking from a JSON file holding a Tracking, or a GetTracking response
+func readTracking(name string) (aftership.Tracking, error) {
+	body, err := ioutil.ReadFile(name)
+	if err != nil {
+		return aftership.Tracking{}, errors.Wrap(err, "error reading tracking")
+	}
+
+	var file struct {
+		aftership.Tracking
+		Data *struct {
+			Tracking aftership.Tracking `json:"tracking"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &file); err != nil {
+		return aftership.Tracking{}, errors.Wrap(err, "error unmarshalling tracking")
+	}
+	if file.Data != nil {
+		return file.Data.Tracking, nil
+	}
+	return file.Tracking, nil
+}
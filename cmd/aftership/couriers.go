@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+func courierList(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("courier list", "")
+	all := flags.Bool("all", false, "list all the couriers supported by AfterShip")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	var list aftership.CourierList
+	var err error
+	if *all {
+		list, err = c.client.GetAllCouriers(ctx)
+	} else {
+		list, err = c.client.GetCouriers(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	return c.printCouriers(list)
+}
+
+func courierDetect(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("courier detect", "<tracking-number>")
+	slugs := flags.String("slug", "", "comma separated slugs to detect among, the active couriers when empty")
+	var params aftership.CourierDetectionParams
+	flags.StringVar(&params.TrackingPostalCode, "postal-code", "", "tracking postal code")
+	flags.StringVar(&params.TrackingShipDate, "ship-date", "", "tracking ship date in YYYYMMDD format")
+	flags.StringVar(&params.TrackingAccountNumber, "account-number", "", "tracking account number")
+	flags.StringVar(&params.TrackingKey, "key", "", "tracking key")
+	flags.StringVar(&params.TrackingDestinationCountry, "tracking-destination-country", "", "tracking destination country")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	params.TrackingNumber = rest[0]
+	params.Slug = splitList(*slugs)
+	list, err := c.client.DetectCouriers(ctx, params)
+	if err != nil {
+		return err
+	}
+	return c.printCouriers(list)
+}
+
+func eddPredict(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("edd predict", "")
+	file := flags.String("file", "", "JSON array of estimated delivery date requests, - for the standard input")
+	var edd aftership.EstimatedDeliveryDate
+	flags.StringVar(&edd.Slug, "slug", "", "courier slug")
+	flags.StringVar(&edd.ServiceTypeName, "service-type", "", "service type name")
+	flags.StringVar(&edd.PickupTime, "pickup-time", "", "local pickup time, e.g. 2022-11-01 10:00:00")
+	origin := flags.String("origin", "", "origin country (ISO Alpha-3)")
+	originPostalCode := flags.String("origin-postal-code", "", "origin postal code")
+	destination := flags.String("destination", "", "destination country (ISO Alpha-3)")
+	destinationPostalCode := flags.String("destination-postal-code", "", "destination postal code")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	var requests []aftership.EstimatedDeliveryDate
+	if *file != "" {
+		var err error
+		if requests, err = c.readEstimatedDeliveryDates(*file); err != nil {
+			return err
+		}
+	} else {
+		if edd.Slug == "" {
+			return usageErrorf("missing -slug or -file")
+		}
+		if *origin != "" || *originPostalCode != "" {
+			edd.OriginAddress = &aftership.Address{Country: *origin, PostalCode: *originPostalCode}
+		}
+		if *destination != "" || *destinationPostalCode != "" {
+			edd.DestinationAddress = &aftership.Address{Country: *destination, PostalCode: *destinationPostalCode}
+		}
+		requests = []aftership.EstimatedDeliveryDate{edd}
+	}
+
+	dates, err := c.client.BatchPredictEstimatedDeliveryDate(ctx, requests)
+	if err != nil {
+		return err
+	}
+	return c.printEstimatedDeliveryDates(dates)
+}
+
+// readEstimatedDeliveryDates reads the JSON array of estimated delivery date requests of file
+func (c *cli) readEstimatedDeliveryDates(file string) ([]aftership.EstimatedDeliveryDate, error) {
+	var r io.Reader = c.stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "error opening estimated delivery date requests")
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var requests []aftership.EstimatedDeliveryDate
+	if err := json.NewDecoder(r).Decode(&requests); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling estimated delivery date requests")
+	}
+	return requests, nil
+}
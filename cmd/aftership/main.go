@@ -0,0 +1,300 @@
+/*
+Command aftership calls the AfterShip API from the command line.
+
+Usage:
+
+	aftership [flags] <command> [command flags] [arguments]
+
+The API key is read from the -api-key flag or the AFTERSHIP_API_KEY environment variable.
+When an AES secret is given with -api-secret or AFTERSHIP_API_SECRET, the requests are signed.
+Trackings are identified by their ID, or by slug/tracking-number.
+
+Examples:
+
+	aftership tracking list -tag InTransit -slug ups
+	aftership -output json tracking get ups/1Z999AA10123456784
+	aftership -dry-run tracking create -slug dhl -title "Order #1001" 1234567890
+	aftership notification add -emails a@example.com 5b7658cec7c33c0e007de3c5
+	aftership courier detect RR123456785GB
+	aftership edd predict -file dates.json
+
+Run aftership -h for the list of commands, and aftership <command> -h for their flags.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+// Environment variables of the global flags
+const (
+	envAPIKey    = "AFTERSHIP_API_KEY"
+	envAPISecret = "AFTERSHIP_API_SECRET"
+	envBaseURL   = "AFTERSHIP_BASE_URL"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a command of the CLI
+type command struct {
+	name     string
+	args     string
+	summary  string
+	mutation bool // Mutations print their request instead of sending it with -dry-run
+	run      func(ctx context.Context, c *cli, args []string) error
+}
+
+// commands are the commands of the CLI, in usage order
+var commands = []command{
+	{"tracking list", "", "List trackings matching the filters", false, trackingList},
+	{"tracking get", "<tracking>", "Get a tracking", false, trackingGet},
+	{"tracking create", "<tracking-number>", "Create a tracking", true, trackingCreate},
+	{"tracking update", "<tracking>", "Update a tracking", true, trackingUpdate},
+	{"tracking delete", "<tracking>", "Delete a tracking", true, trackingDelete},
+	{"tracking retrack", "<tracking>", "Retrack an expired tracking", true, trackingRetrack},
+	{"tracking complete", "<tracking>", "Mark a tracking as completed", true, trackingComplete},
+	{"last-checkpoint", "<tracking>", "Get the last checkpoint of a tracking", false, lastCheckpoint},
+	{"notification get", "<tracking>", "Get the notification receivers of a tracking", false, notificationGet},
+	{"notification add", "<tracking>", "Add notification receivers to a tracking", true, notificationAdd},
+	{"notification remove", "<tracking>", "Remove notification receivers from a tracking", true, notificationRemove},
+	{"courier list", "", "List the active couriers, or all couriers with -all", false, courierList},
+	{"courier detect", "<tracking-number>", "Detect the couriers of a tracking number", false, courierDetect},
+	{"edd predict", "", "Predict estimated delivery dates", false, eddPredict},
+}
+
+// cli is the state shared by the commands
+type cli struct {
+	client *aftership.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	format string
+	dryRun bool
+}
+
+// usageError is an error in the command line
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run runs the command line args and returns the exit code
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, getenv func(string) string) int {
+	flags := flag.NewFlagSet("aftership", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	apiKey := flags.String("api-key", "", "AfterShip API key, defaults to $"+envAPIKey)
+	apiSecret := flags.String("api-secret", "", "AES API secret signing the requests, defaults to $"+envAPISecret)
+	baseURL := flags.String("base-url", "", "base URL of the AfterShip API, defaults to $"+envBaseURL+" or the production API")
+	format := flags.String("output", formatTable, "output format, table or json")
+	dryRun := flags.Bool("dry-run", false, "print the requests of the mutations instead of sending them")
+	timeout := flags.Duration("timeout", time.Minute, "timeout of the command")
+	flags.Usage = func() { printUsage(flags) }
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	// The environment is read after parsing, for the usage not to print the API key and secret as defaults
+	envDefault(apiKey, getenv(envAPIKey))
+	envDefault(apiSecret, getenv(envAPISecret))
+	envDefault(baseURL, getenv(envBaseURL))
+
+	cmd, cmdArgs, ok := findCommand(flags.Args())
+	if !ok {
+		if flags.NArg() > 0 {
+			fmt.Fprintf(stderr, "unknown command %q\n", strings.Join(flags.Args(), " "))
+		}
+		printUsage(flags)
+		return exitUsage
+	}
+	if *format != formatTable && *format != formatJSON {
+		fmt.Fprintf(stderr, "invalid output format %q, use table or json\n", *format)
+		return exitUsage
+	}
+
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr, format: *format, dryRun: *dryRun && cmd.mutation}
+	if !c.dryRun && !helpRequested(cmdArgs) {
+		if *apiKey == "" {
+			fmt.Fprintf(stderr, "missing API key, use -api-key or $%s\n", envAPIKey)
+			return exitUsage
+		}
+
+		config := aftership.Config{APIKey: *apiKey, BaseURL: *baseURL, UserAgentPrefix: "aftership-cli"}
+		if *apiSecret != "" {
+			config.AuthenticationType = aftership.AES
+			config.APISecret = *apiSecret
+		}
+		client, err := aftership.NewClient(config)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		c.client = client
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	err := cmd.run(ctx, c, cmdArgs)
+	switch err.(type) {
+	case nil:
+		return exitOK
+	case *usageError:
+		fmt.Fprintf(stderr, "%s\nusage: aftership %s [flags] %s\n", err, cmd.name, cmd.args)
+		return exitUsage
+	}
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "aftership %s: %v\n", cmd.name, err)
+	return exitError
+}
+
+// envDefault sets the flag value to the value of its environment variable when empty
+func envDefault(value *string, env string) {
+	if *value == "" {
+		*value = env
+	}
+}
+
+// helpRequested returns true when the command flags of args ask for the usage of the command
+func helpRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "-h", "-help", "--h", "--help":
+			return true
+		case "--":
+			return false
+		}
+	}
+	return false
+}
+
+// findCommand returns the command of args, and its arguments
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func printUsage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintf(w, "usage: aftership [flags] <command> [command flags] [arguments]\n\nflags:\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(w, "\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-42s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintf(w, "\n<tracking> is a tracking ID or slug/tracking-number.\n")
+}
+
+// flagSet returns the flag set of the command name
+func (c *cli) flagSet(name string, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: aftership %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses the flags of args, and checks the number of remaining arguments
+func parse(flags *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, &usageError{message: err.Error()}
+	}
+	if flags.NArg() != nargs {
+		return nil, usageErrorf("expected %d argument(s), got %d", nargs, flags.NArg())
+	}
+	return flags.Args(), nil
+}
+
+// parseIdentifier parses a tracking ID, or a slug/tracking-number
+func parseIdentifier(arg string) (aftership.TrackingIdentifier, error) {
+	if i := strings.Index(arg, "/"); i >= 0 {
+		slug, trackingNumber := arg[:i], arg[i+1:]
+		if slug == "" || trackingNumber == "" {
+			return nil, usageErrorf("invalid tracking %q, use an ID or slug/tracking-number", arg)
+		}
+		return aftership.SlugTrackingNumber{Slug: slug, TrackingNumber: trackingNumber}, nil
+	}
+	if arg == "" {
+		return nil, usageErrorf("empty tracking ID")
+	}
+	return aftership.TrackingID(arg), nil
+}
+
+// splitList splits a comma separated flag value, nil when empty
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// dryRunRequest is the output of the mutations with -dry-run
+type dryRunRequest struct {
+	DryRun bool        `json:"dry_run"`
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// printDryRun prints the request of a mutation with -dry-run, the path being relative to the base URL
+func (c *cli) printDryRun(method string, path string, body interface{}) error {
+	return errors.Wrap(writeJSON(c.stdout, dryRunRequest{DryRun: true, Method: method, Path: path, Body: body}),
+		"error writing dry run")
+}
+
+// identifierPath returns the path of a tracking identifier under prefix, followed by suffix
+func identifierPath(prefix string, identifier aftership.TrackingIdentifier, suffix string) (string, error) {
+	uriPath, err := identifier.URIPath()
+	if err != nil {
+		return "", &usageError{message: err.Error()}
+	}
+	return prefix + uriPath + suffix, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/aftership/aftership-sdk-go/v2/aftershiptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// runCLI runs the command line args against server and returns the exit code, the output and the errors
+func runCLI(server *aftershiptest.Server, stdin string, args ...string) (int, string, string) {
+	return runCLIEnv(map[string]string{envAPIKey: aftershiptest.APIKey, envBaseURL: server.URL}, stdin, args...)
+}
+
+// runCLIEnv runs the command line args with the environment env
+func runCLIEnv(env map[string]string, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr, func(key string) string { return env[key] })
+	return code, stdout.String(), stderr.String()
+}
+
+func newTestServer() *aftershiptest.Server {
+	server := aftershiptest.NewServer()
+	server.SetRateLimit(0)
+	return server
+}
+
+func TestTrackingCommands(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(server, "", "-output", "json", "tracking", "create",
+		"-title", "Order #1001", "-emails", "a@example.com,b@example.com", "1Z999AA10123456784")
+	assert.Equal(t, exitOK, code, stderr)
+	var created aftership.Tracking
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &created))
+	assert.Equal(t, "ups", created.Slug)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, created.Emails)
+
+	code, stdout, stderr = runCLI(server, "", "tracking", "update", "-title", "Order #1002", "ups/1Z999AA10123456784")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Order #1002")
+
+	server.AddCheckpoint(created.ID, aftership.Checkpoint{
+		Tag:            aftership.TagInTransit,
+		Message:        "Departed from facility",
+		City:           "Louisville",
+		CountryName:    "USA",
+		CheckpointTime: "2022-11-02T08:00:00-04:00",
+	})
+
+	code, stdout, stderr = runCLI(server, "", "tracking", "get", created.ID)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Tracking number:  1Z999AA10123456784")
+	assert.Contains(t, stdout, "2022-11-02T08:00:00-04:00  InTransit  Louisville, USA  Departed from facility")
+
+	code, stdout, stderr = runCLI(server, "", "last-checkpoint", created.ID)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Departed from facility")
+
+	code, stdout, stderr = runCLI(server, "", "tracking", "list", "-tag", aftership.TagInTransit)
+	assert.Equal(t, exitOK, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasPrefix(lines[0], "ID "))
+		assert.True(t, strings.HasPrefix(lines[1], created.ID))
+	}
+
+	code, _, stderr = runCLI(server, "", "tracking", "complete", "-reason", "lost", created.ID)
+	assert.Equal(t, exitOK, code, stderr)
+	tracking, _ := server.Tracking(created.ID)
+	assert.Equal(t, aftership.TagException, tracking.Tag)
+
+	code, _, stderr = runCLI(server, "", "tracking", "delete", created.ID)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Empty(t, server.Trackings())
+
+	code, _, stderr = runCLI(server, "", "tracking", "get", created.ID)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "4004")
+}
+
+func TestDryRun(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	tracking := server.AddTracking(aftership.Tracking{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+
+	tests := []struct {
+		args     []string
+		expected dryRunRequest
+	}{
+		{
+			[]string{"-dry-run", "tracking", "create", "-slug", "dhl", "1234567890"},
+			dryRunRequest{DryRun: true, Method: "POST", Path: "/trackings", Body: map[string]interface{}{
+				"tracking": map[string]interface{}{"slug": "dhl", "tracking_number": "1234567890"},
+			}},
+		},
+		{
+			[]string{"-dry-run", "tracking", "retrack", "ups/1Z999AA10123456784"},
+			dryRunRequest{DryRun: true, Method: "POST", Path: "/trackings/ups/1Z999AA10123456784/retrack"},
+		},
+		{
+			[]string{"-dry-run", "tracking", "delete", tracking.ID},
+			dryRunRequest{DryRun: true, Method: "DELETE", Path: "/trackings/" + tracking.ID},
+		},
+		{
+			[]string{"-dry-run", "notification", "remove", "-smses", "+85291234567", tracking.ID},
+			dryRunRequest{DryRun: true, Method: "POST", Path: "/notifications/" + tracking.ID + "/remove", Body: map[string]interface{}{
+				"notification": map[string]interface{}{"emails": nil, "smses": []interface{}{"+85291234567"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, stdout, stderr := runCLI(server, "", tt.args...)
+			assert.Equal(t, exitOK, code, stderr)
+
+			var request dryRunRequest
+			assert.Nil(t, json.Unmarshal([]byte(stdout), &request))
+			assert.Equal(t, tt.expected, request)
+		})
+	}
+
+	// Nothing was sent
+	trackings := server.Trackings()
+	assert.Len(t, trackings, 1)
+	assert.Equal(t, tracking.ID, trackings[0].ID)
+}
+
+func TestNotificationAndCourierCommands(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	tracking := server.AddTracking(aftership.Tracking{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+
+	code, stdout, stderr := runCLI(server, "", "notification", "add", "-emails", "a@example.com", tracking.ID)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Emails:  a@example.com")
+
+	code, stdout, stderr = runCLI(server, "", "courier", "detect", "RR123456785GB")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "royal-mail")
+
+	code, stdout, stderr = runCLI(server, "", "-output", "json", "courier", "list", "-all")
+	assert.Equal(t, exitOK, code, stderr)
+	var list aftership.CourierList
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &list))
+	assert.Equal(t, len(aftershiptest.DefaultCouriers), list.Total)
+
+	code, stdout, stderr = runCLI(server, `[{"slug":"ups","pickup_time":"2022-11-01 10:00:00"}]`, "edd", "predict", "-file", "-")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "2022-11-04")
+}
+
+func TestUsageErrors(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		args   []string
+		stderr string
+	}{
+		{[]string{"tracking", "fly"}, `unknown command "tracking fly"`},
+		{[]string{"-output", "xml", "courier", "list"}, `invalid output format "xml"`},
+		{[]string{"tracking", "get"}, "expected 1 argument(s), got 0"},
+		{[]string{"tracking", "get", "ups/"}, `invalid tracking "ups/"`},
+		{[]string{"tracking", "complete", "-reason", "stolen", "1"}, `invalid reason "stolen"`},
+		{[]string{"notification", "add", "1"}, "no receiver to add"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, _, stderr := runCLI(server, "", tt.args...)
+			assert.Equal(t, exitUsage, code)
+			assert.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
+
+func TestAPIKeyEnvironment(t *testing.T) {
+	code, _, stderr := runCLIEnv(map[string]string{}, "", "courier", "list")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "missing API key")
+
+	// The usage does not print the secrets of the environment
+	env := map[string]string{envAPIKey: "sk_secret_123", envAPISecret: "aes_secret_456"}
+	code, _, stderr = runCLIEnv(env, "", "bogus")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "defaults to $"+envAPIKey)
+	assert.NotContains(t, stderr, "sk_secret_123")
+	assert.NotContains(t, stderr, "aes_secret_456")
+}
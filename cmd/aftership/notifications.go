@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func notificationGet(ctx context.Context, c *cli, args []string) error {
+	identifier, err := parseTrackingArgs(c.flagSet("notification get", "<tracking>"), args)
+	if err != nil {
+		return err
+	}
+
+	notification, err := c.client.GetNotification(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	return c.printNotification(notification)
+}
+
+func notificationAdd(ctx context.Context, c *cli, args []string) error {
+	return changeNotification(ctx, c, "add", args)
+}
+
+func notificationRemove(ctx context.Context, c *cli, args []string) error {
+	return changeNotification(ctx, c, "remove", args)
+}
+
+// changeNotification adds or removes the receivers of the flags
+func changeNotification(ctx context.Context, c *cli, action string, args []string) error {
+	flags := c.flagSet("notification "+action, "<tracking>")
+	emails := flags.String("emails", "", "comma separated emails")
+	smses := flags.String("smses", "", "comma separated phone numbers")
+	ios := flags.String("ios", "", "comma separated iOS device IDs")
+	android := flags.String("android", "", "comma separated Android registration IDs")
+	identifier, err := parseTrackingArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	notification := aftership.Notification{
+		Emails:  splitList(*emails),
+		SMSes:   splitList(*smses),
+		IOS:     splitList(*ios),
+		Android: splitList(*android),
+	}
+	if len(notification.Emails)+len(notification.SMSes)+len(notification.IOS)+len(notification.Android) == 0 {
+		return usageErrorf("no receiver to %s, use -emails, -smses, -ios or -android", action)
+	}
+
+	if c.dryRun {
+		path, err := identifierPath("/notifications", identifier, "/"+action)
+		if err != nil {
+			return err
+		}
+		return c.printDryRun(http.MethodPost, path, map[string]interface{}{"notification": notification})
+	}
+
+	if action == "add" {
+		notification, err = c.client.AddNotification(ctx, identifier, notification)
+	} else {
+		notification, err = c.client.RemoveNotification(ctx, identifier, notification)
+	}
+	if err != nil {
+		return err
+	}
+	return c.printNotification(notification)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+	"github.com/pkg/errors"
+)
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	return errors.Wrap(writeJSON(c.stdout, v), "error writing JSON")
+}
+
+// printTable prints v as JSON with -output json, or the rows written by table otherwise
+func (c *cli) printTable(v interface{}, table func(w io.Writer)) error {
+	if c.format == formatJSON {
+		return c.printJSON(v)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	table(w)
+	return errors.Wrap(w.Flush(), "error writing table")
+}
+
+func row(w io.Writer, columns ...string) {
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (c *cli) printTrackings(trackings []aftership.Tracking) error {
+	if trackings == nil {
+		trackings = []aftership.Tracking{}
+	}
+	return c.printTable(trackings, func(w io.Writer) {
+		row(w, "ID", "SLUG", "TRACKING NUMBER", "TAG", "TITLE", "UPDATED AT")
+		for _, tracking := range trackings {
+			row(w, tracking.ID, tracking.Slug, tracking.TrackingNumber, tracking.Tag, tracking.Title, formatTime(tracking.UpdatedAt))
+		}
+	})
+}
+
+func (c *cli) printTracking(tracking aftership.Tracking) error {
+	return c.printTable(tracking, func(w io.Writer) {
+		row(w, "ID:", tracking.ID)
+		row(w, "Slug:", tracking.Slug)
+		row(w, "Tracking number:", tracking.TrackingNumber)
+		row(w, "Title:", tracking.Title)
+		row(w, "Tag:", tracking.Tag, tracking.SubtagMessage)
+		row(w, "Active:", strconv.FormatBool(tracking.Active))
+		row(w, "Created at:", formatTime(tracking.CreatedAt))
+		row(w, "Updated at:", formatTime(tracking.UpdatedAt))
+		if edd := tracking.ExpectedDelivery; edd != "" {
+			row(w, "Expected delivery:", edd)
+		}
+		if delivered := tracking.ShipmentDeliveryDate; delivered != "" {
+			row(w, "Delivered at:", delivered)
+		}
+		if len(tracking.Checkpoints) == 0 {
+			return
+		}
+
+		row(w)
+		row(w, "CHECKPOINT TIME", "TAG", "LOCATION", "MESSAGE")
+		for _, checkpoint := range tracking.Checkpoints {
+			row(w, checkpoint.CheckpointTime, checkpoint.Tag, checkpoint.DisplayLocation(), checkpoint.Message)
+		}
+	})
+}
+
+func (c *cli) printLastCheckpoint(lastCheckpoint aftership.LastCheckpoint) error {
+	return c.printTable(lastCheckpoint, func(w io.Writer) {
+		checkpoint := lastCheckpoint.Checkpoint
+		row(w, "ID:", lastCheckpoint.ID)
+		row(w, "Slug:", lastCheckpoint.Slug)
+		row(w, "Tracking number:", lastCheckpoint.TrackingNumber)
+		row(w, "Tag:", lastCheckpoint.Tag, lastCheckpoint.SubtagMessage)
+		row(w, "Checkpoint time:", checkpoint.CheckpointTime)
+		row(w, "Location:", checkpoint.DisplayLocation())
+		row(w, "Message:", checkpoint.Message)
+	})
+}
+
+func (c *cli) printNotification(notification aftership.Notification) error {
+	return c.printTable(notification, func(w io.Writer) {
+		row(w, "Emails:", strings.Join(notification.Emails, ", "))
+		row(w, "SMSes:", strings.Join(notification.SMSes, ", "))
+		if len(notification.IOS) > 0 {
+			row(w, "iOS:", strings.Join(notification.IOS, ", "))
+		}
+		if len(notification.Android) > 0 {
+			row(w, "Android:", strings.Join(notification.Android, ", "))
+		}
+	})
+}
+
+func (c *cli) printCouriers(list aftership.CourierList) error {
+	return c.printTable(list, func(w io.Writer) {
+		row(w, "SLUG", "NAME", "REQUIRED FIELDS", "WEB URL")
+		for _, courier := range list.Couriers {
+			row(w, courier.Slug, courier.Name, strings.Join(courier.RequiredFields, ","), courier.WebURL)
+		}
+	})
+}
+
+func (c *cli) printEstimatedDeliveryDates(dates aftership.EstimatedDeliveryDates) error {
+	return c.printTable(dates, func(w io.Writer) {
+		row(w, "SLUG", "SERVICE TYPE", "ESTIMATED DELIVERY DATE", "MIN", "MAX", "CONFIDENCE")
+		for _, edd := range dates.Dates {
+			confidence := ""
+			if edd.ConfidenceScore > 0 {
+				confidence = strconv.FormatFloat(edd.ConfidenceScore, 'f', 2, 64)
+			}
+			row(w, edd.Slug, edd.ServiceTypeName, edd.EstimatedDeliveryDate,
+				edd.EstimatedDeliveryDateMin, edd.EstimatedDeliveryDateMax, confidence)
+		}
+	})
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func trackingList(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("tracking list", "")
+	var params aftership.GetTrackingsParams
+	flags.StringVar(&params.Slug, "slug", "", "comma separated slugs")
+	flags.StringVar(&params.Tag, "tag", "", "comma separated tags, e.g. InTransit,Exception")
+	flags.StringVar(&params.Keyword, "keyword", "", "search the tracking numbers, titles, order IDs, customer names, emails and SMSes")
+	flags.StringVar(&params.TrackingNumbers, "tracking-numbers", "", "comma separated tracking numbers")
+	flags.StringVar(&params.Origin, "origin", "", "comma separated origin countries (ISO Alpha-3)")
+	flags.StringVar(&params.Destination, "destination", "", "comma separated destination countries (ISO Alpha-3)")
+	flags.StringVar(&params.CreatedAtMin, "created-at-min", "", "earliest creation time, in RFC 3339 format")
+	flags.StringVar(&params.CreatedAtMax, "created-at-max", "", "latest creation time, in RFC 3339 format")
+	flags.StringVar(&params.UpdatedAtMin, "updated-at-min", "", "earliest update time, in RFC 3339 format")
+	flags.StringVar(&params.UpdatedAtMax, "updated-at-max", "", "latest update time, in RFC 3339 format")
+	flags.StringVar(&params.ShipmentTags, "shipment-tags", "", "comma separated shipment tags")
+	flags.StringVar(&params.Fields, "fields", "", "comma separated fields to include")
+	flags.IntVar(&params.Page, "page", 0, "page to show, from 1")
+	flags.IntVar(&params.Limit, "limit", 0, "number of trackings per page, up to 200")
+	all := flags.Bool("all", false, "list the trackings of all the pages")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	if *all {
+		var trackings []aftership.Tracking
+		err := aftership.WalkTrackings(ctx, c.client, params, func(tracking aftership.Tracking) error {
+			trackings = append(trackings, tracking)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return c.printTrackings(trackings)
+	}
+
+	paged, err := c.client.GetTrackings(ctx, params)
+	if err != nil {
+		return err
+	}
+	if c.format == formatJSON {
+		return c.printJSON(paged)
+	}
+	return c.printTrackings(paged.Trackings)
+}
+
+func trackingGet(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("tracking get", "<tracking>")
+	var params aftership.GetTrackingParams
+	flags.StringVar(&params.Fields, "fields", "", "comma separated fields to include")
+	flags.StringVar(&params.Lang, "lang", "", "language of the checkpoints of china-ems and china-post, e.g. en")
+	identifier, err := parseTrackingArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	tracking, err := c.client.GetTracking(ctx, identifier, params)
+	if err != nil {
+		return err
+	}
+	return c.printTracking(tracking)
+}
+
+// trackingFlags are the flags of the tracking fields shared by create and update
+type trackingFlags struct {
+	title        string
+	orderID      string
+	customerName string
+	note         string
+	language     string
+	emails       string
+	smses        string
+}
+
+func (f *trackingFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.title, "title", "", "title of the tracking")
+	flags.StringVar(&f.orderID, "order-id", "", "order ID")
+	flags.StringVar(&f.customerName, "customer-name", "", "customer name")
+	flags.StringVar(&f.note, "note", "", "note")
+	flags.StringVar(&f.language, "language", "", "language of the notifications, e.g. en")
+	flags.StringVar(&f.emails, "emails", "", "comma separated emails to notify")
+	flags.StringVar(&f.smses, "smses", "", "comma separated phone numbers to notify")
+}
+
+func trackingCreate(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("tracking create", "<tracking-number>")
+	var fields trackingFlags
+	fields.register(flags)
+	var params aftership.CreateTrackingParams
+	flags.StringVar(&params.Slug, "slug", "", "courier slug, detected from the tracking number when empty")
+	flags.StringVar(&params.OrderNumber, "order-number", "", "order number")
+	flags.StringVar(&params.OriginCountryISO3, "origin", "", "origin country (ISO Alpha-3)")
+	flags.StringVar(&params.DestinationCountryISO3, "destination", "", "destination country (ISO Alpha-3)")
+	flags.StringVar(&params.ShipmentType, "shipment-type", "", "shipment type")
+	flags.StringVar(&params.TrackingPostalCode, "postal-code", "", "tracking postal code, required by some couriers")
+	flags.StringVar(&params.TrackingShipDate, "ship-date", "", "tracking ship date in YYYYMMDD format, required by some couriers")
+	flags.StringVar(&params.TrackingAccountNumber, "account-number", "", "tracking account number, required by some couriers")
+	flags.StringVar(&params.TrackingKey, "key", "", "tracking key, required by some couriers")
+	flags.StringVar(&params.TrackingDestinationCountry, "tracking-destination-country", "", "tracking destination country, required by some couriers")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	params.TrackingNumber = rest[0]
+	params.Title = fields.title
+	params.OrderID = fields.orderID
+	params.CustomerName = fields.customerName
+	params.Note = fields.note
+	params.Language = fields.language
+	params.Emails = splitList(fields.emails)
+	params.SMSes = splitList(fields.smses)
+
+	if c.dryRun {
+		return c.printDryRun(http.MethodPost, "/trackings", map[string]interface{}{"tracking": params})
+	}
+
+	tracking, err := c.client.CreateTracking(ctx, params)
+	if err != nil {
+		return err
+	}
+	return c.printTracking(tracking)
+}
+
+func trackingUpdate(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("tracking update", "<tracking>")
+	var fields trackingFlags
+	fields.register(flags)
+	var params aftership.UpdateTrackingParams
+	flags.StringVar(&params.Slug, "slug", "", "new courier slug")
+	flags.StringVar(&params.OrderNumber, "order-number", "", "order number")
+	flags.StringVar(&params.ShipmentType, "shipment-type", "", "shipment type")
+	identifier, err := parseTrackingArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	params.Title = fields.title
+	params.OrderID = fields.orderID
+	params.CustomerName = fields.customerName
+	params.Note = fields.note
+	params.Language = fields.language
+	params.Emails = splitList(fields.emails)
+	params.SMSes = splitList(fields.smses)
+
+	if c.dryRun {
+		path, err := identifierPath("/trackings", identifier, "")
+		if err != nil {
+			return err
+		}
+		return c.printDryRun(http.MethodPut, path, map[string]interface{}{"tracking": params})
+	}
+
+	tracking, err := c.client.UpdateTracking(ctx, identifier, params)
+	if err != nil {
+		return err
+	}
+	return c.printTracking(tracking)
+}
+
+func trackingDelete(ctx context.Context, c *cli, args []string) error {
+	identifier, err := parseTrackingArgs(c.flagSet("tracking delete", "<tracking>"), args)
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		path, err := identifierPath("/trackings", identifier, "")
+		if err != nil {
+			return err
+		}
+		return c.printDryRun(http.MethodDelete, path, nil)
+	}
+
+	tracking, err := c.client.DeleteTracking(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	return c.printTracking(tracking)
+}
+
+func trackingRetrack(ctx context.Context, c *cli, args []string) error {
+	identifier, err := parseTrackingArgs(c.flagSet("tracking retrack", "<tracking>"), args)
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		path, err := identifierPath("/trackings", identifier, "/retrack")
+		if err != nil {
+			return err
+		}
+		return c.printDryRun(http.MethodPost, path, nil)
+	}
+
+	tracking, err := c.client.RetrackTracking(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	return c.printTracking(tracking)
+}
+
+func trackingComplete(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("tracking complete", "<tracking>")
+	reason := flags.String("reason", string(aftership.TrackingCompletedStatusDelivered), "DELIVERED, LOST or RETURNED_TO_SENDER")
+	identifier, err := parseTrackingArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	status := aftership.TrackingCompletedStatus(strings.ToUpper(*reason))
+	switch status {
+	case aftership.TrackingCompletedStatusDelivered, aftership.TrackingCompletedStatusLost, aftership.TrackingCompletedStatusReturnedToSender:
+	default:
+		return usageErrorf("invalid reason %q, use DELIVERED, LOST or RETURNED_TO_SENDER", *reason)
+	}
+
+	if c.dryRun {
+		path, err := identifierPath("/trackings", identifier, "/mark-as-completed")
+		if err != nil {
+			return err
+		}
+		return c.printDryRun(http.MethodPost, path, map[string]interface{}{"reason": status})
+	}
+
+	tracking, err := c.client.MarkTrackingAsCompleted(ctx, identifier, status)
+	if err != nil {
+		return err
+	}
+	return c.printTracking(tracking)
+}
+
+func lastCheckpoint(ctx context.Context, c *cli, args []string) error {
+	flags := c.flagSet("last-checkpoint", "<tracking>")
+	var params aftership.GetCheckpointParams
+	flags.StringVar(&params.Fields, "fields", "", "comma separated checkpoint fields to include")
+	flags.StringVar(&params.Lang, "lang", "", "language of the checkpoints of china-ems and china-post, e.g. en")
+	identifier, err := parseTrackingArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := c.client.GetLastCheckpoint(ctx, identifier, params)
+	if err != nil {
+		return err
+	}
+	return c.printLastCheckpoint(checkpoint)
+}
+
+// parseTrackingArgs parses the flags of args, followed by a single tracking identifier
+func parseTrackingArgs(flags *flag.FlagSet, args []string) (aftership.TrackingIdentifier, error) {
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return parseIdentifier(rest[0])
+}
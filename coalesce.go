@@ -0,0 +1,79 @@
+package aftership
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// inflightRequest is an HTTP request shared by the callers of identical GET requests
+type inflightRequest struct {
+	done    chan struct{} // Closed once resp and err are set
+	resp    *rawResponse
+	err     error
+	waiters int                // The callers still waiting for the response
+	cancel  context.CancelFunc // Cancels the request once every caller gave up
+}
+
+// requestGroup coalesces identical requests in flight into one HTTP request
+type requestGroup struct {
+	mu       sync.Mutex
+	requests map[string]*inflightRequest
+}
+
+func newRequestGroup() *requestGroup {
+	return &requestGroup{requests: make(map[string]*inflightRequest)}
+}
+
+// do sends req with send, unless a request with the same key is in flight, and returns the shared response.
+// The shared request is not bound to the context of any caller: a caller whose ctx is done stops waiting
+// and returns the error of ctx, and the request is cancelled only when no caller is waiting for it anymore.
+func (g *requestGroup) do(ctx context.Context, key string, req *http.Request,
+	send func(*http.Request) (*rawResponse, error)) (*rawResponse, error) {
+
+	g.mu.Lock()
+	request, ok := g.requests[key]
+	if !ok {
+		shared, cancel := context.WithCancel(context.Background())
+		request = &inflightRequest{done: make(chan struct{}), cancel: cancel}
+		g.requests[key] = request
+
+		go func() {
+			request.resp, request.err = send(req.Clone(shared))
+			g.forget(key, request)
+			cancel()
+			close(request.done)
+		}()
+	}
+	request.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-request.done:
+		return request.resp, request.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		request.waiters--
+		if request.waiters == 0 {
+			request.cancel()
+			g.forgetLocked(key, request)
+		}
+		g.mu.Unlock()
+		return nil, errors.Wrap(ctx.Err(), "HTTP request failed")
+	}
+}
+
+// forget removes a request from the requests in flight, so the next identical request is sent again
+func (g *requestGroup) forget(key string, request *inflightRequest) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.forgetLocked(key, request)
+}
+
+func (g *requestGroup) forgetLocked(key string, request *inflightRequest) {
+	if g.requests[key] == request {
+		delete(g.requests, key)
+	}
+}
@@ -0,0 +1,153 @@
+package aftership
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const coalesceTestResponse = `{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed",
+	"slug": "ups", "tracking_number": "1Z999AA10123456784", "checkpoints": [{"message": "Picked up"}]}}}`
+
+// waitForWaiters waits until n callers wait for the only request in flight
+func waitForWaiters(t *testing.T, n int) {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		client.reads.mu.Lock()
+		waiting := false
+		for _, request := range client.reads.requests {
+			waiting = len(client.reads.requests) == 1 && request.waiters == n
+		}
+		client.reads.mu.Unlock()
+		if waiting {
+			return
+		}
+	}
+	t.Errorf("%d callers are not waiting for the request", n)
+}
+
+func TestCoalesceReads(t *testing.T) {
+	setup()
+	defer teardown()
+	client.Config.CoalesceReads = true
+
+	var calls int32
+	release := make(chan struct{})
+	mux.HandleFunc("/trackings/ups/1Z999AA10123456784", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(coalesceTestResponse))
+	})
+
+	identifier := SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+	trackings := make([]Tracking, 10)
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range trackings {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			trackings[i], errs[i] = client.GetTracking(context.Background(), identifier, GetTrackingParams{Lang: "en"})
+		}(i)
+	}
+
+	waitForWaiters(t, len(trackings))
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for i := range trackings {
+		assert.Nil(t, errs[i])
+		assert.Equal(t, "Picked up", trackings[i].Checkpoints[0].Message)
+	}
+
+	// Every caller has its own copy
+	trackings[0].Checkpoints[0].Message = "changed"
+	assert.Equal(t, "Picked up", trackings[1].Checkpoints[0].Message)
+
+	// Requests are not shared once done
+	_, err := client.GetTracking(context.Background(), identifier, GetTrackingParams{Lang: "en"})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCoalesceReadsCancel(t *testing.T) {
+	setup()
+	defer teardown()
+	client.Config.CoalesceReads = true
+
+	release := make(chan struct{})
+	cancelled := make(chan struct{})
+	mux.HandleFunc("/last_checkpoint/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+			w.Write([]byte(`{"meta": {"code": 200}, "data": {"tag": "InTransit"}}`))
+		case <-r.Context().Done():
+			close(cancelled)
+		}
+	})
+
+	id := TrackingID("5b74f4958776db0e00b6f5ed")
+	get := func(ctx context.Context) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := client.GetLastCheckpoint(ctx, id, GetCheckpointParams{})
+			done <- err
+		}()
+		return done
+	}
+
+	// The first caller giving up does not cancel the request of the second one
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstDone := get(first)
+	waitForWaiters(t, 1)
+	secondDone := get(context.Background())
+	waitForWaiters(t, 2)
+
+	cancelFirst()
+	assert.Equal(t, context.Canceled, errors.Cause(<-firstDone))
+	close(release)
+	assert.Nil(t, <-secondDone)
+
+	// The request is cancelled when every caller gave up
+	release = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := get(ctx)
+	waitForWaiters(t, 1)
+	cancel()
+	assert.Equal(t, context.Canceled, errors.Cause(<-done))
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the request was not cancelled")
+	}
+}
+
+func TestCoalesceReadsDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls int32
+	mux.HandleFunc("/trackings/ups/1Z999AA10123456784", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(coalesceTestResponse))
+	})
+
+	identifier := SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.GetTracking(context.Background(), identifier, GetTrackingParams{})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
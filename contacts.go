@@ -0,0 +1,209 @@
+package aftership
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// e164Format is a phone number in E.164 format, at most 15 digits including the country code
+var e164Format = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+
+// phoneRegion is the dialing plan of a country
+type phoneRegion struct {
+	countryCode string // Country calling code
+	trunkPrefix string // National prefix dropped from numbers in international format
+}
+
+// phoneRegions maps ISO Alpha-3 country codes to their dialing plans
+var phoneRegions = map[string]phoneRegion{
+	"ARE": {"971", "0"},
+	"ARG": {"54", "0"},
+	"AUS": {"61", "0"},
+	"AUT": {"43", "0"},
+	"BEL": {"32", "0"},
+	"BRA": {"55", "0"},
+	"CAN": {"1", "1"},
+	"CHE": {"41", "0"},
+	"CHN": {"86", "0"},
+	"DEU": {"49", "0"},
+	"DNK": {"45", ""},
+	"ESP": {"34", ""},
+	"FIN": {"358", "0"},
+	"FRA": {"33", "0"},
+	"GBR": {"44", "0"},
+	"HKG": {"852", ""},
+	"IDN": {"62", "0"},
+	"IND": {"91", "0"},
+	"IRL": {"353", "0"},
+	"ISR": {"972", "0"},
+	"ITA": {"39", ""},
+	"JPN": {"81", "0"},
+	"KOR": {"82", "0"},
+	"MEX": {"52", ""},
+	"MYS": {"60", "0"},
+	"NLD": {"31", "0"},
+	"NOR": {"47", ""},
+	"NZL": {"64", "0"},
+	"PHL": {"63", "0"},
+	"POL": {"48", ""},
+	"PRT": {"351", ""},
+	"SAU": {"966", "0"},
+	"SGP": {"65", ""},
+	"SWE": {"46", "0"},
+	"THA": {"66", "0"},
+	"TUR": {"90", "0"},
+	"TWN": {"886", "0"},
+	"USA": {"1", "1"},
+	"VNM": {"84", "0"},
+	"ZAF": {"27", "0"},
+}
+
+// Contact validation error messages
+const (
+	errInvalidEmail       = "must be a valid email address"
+	errInvalidPhoneNumber = "must be a phone number in E.164 format, starting with + and the country code"
+	errUnknownPhoneRegion = "unknown default phone region %q, use an ISO Alpha-3 country code"
+)
+
+// NormalizeEmail validates an RFC 5322 email address without display name, e.g. "user@example.com",
+// and returns it trimmed with a lower case domain. The local part is case sensitive and kept as is.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return "", errors.New(errInvalidEmail)
+	}
+
+	at := strings.LastIndex(email, "@")
+	domain := strings.ToLower(email[at+1:])
+	if !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", errors.New(errInvalidEmail)
+	}
+
+	return email[:at+1] + domain, nil
+}
+
+// NormalizePhoneNumber returns number in E.164 format, e.g. "+85291239123".
+// Spaces, dashes, dots and parentheses are ignored and a leading 00 is read as +.
+// Numbers without country code are read in the defaultRegion, an ISO Alpha-3 country code,
+// with the national trunk prefix dropped. An empty defaultRegion only accepts international numbers.
+func NormalizePhoneNumber(number, defaultRegion string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(number) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			digits.WriteRune(r)
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", errors.New(errInvalidPhoneNumber)
+		}
+	}
+
+	normalized := digits.String()
+	switch {
+	case strings.HasPrefix(normalized, "+"):
+	case strings.HasPrefix(normalized, "00"):
+		normalized = "+" + normalized[2:]
+	case defaultRegion != "":
+		region, ok := phoneRegions[strings.ToUpper(defaultRegion)]
+		if !ok {
+			return "", fmt.Errorf(errUnknownPhoneRegion, defaultRegion)
+		}
+		if region.trunkPrefix != "" {
+			normalized = strings.TrimPrefix(normalized, region.trunkPrefix)
+		}
+		normalized = "+" + region.countryCode + normalized
+	}
+
+	if !e164Format.MatchString(normalized) {
+		return "", errors.New(errInvalidPhoneNumber)
+	}
+	return normalized, nil
+}
+
+// NormalizeContacts normalizes emails and SMS phone numbers with NormalizeEmail and NormalizePhoneNumber.
+// Every invalid entry is reported as a FieldError named after its JSON field and index, e.g. "smses[1]".
+func NormalizeContacts(emails, smses []string, defaultRegion string) ([]string, []string, []FieldError) {
+	var fieldErrors []FieldError
+
+	normalizedEmails := make([]string, 0, len(emails))
+	for i, email := range emails {
+		normalized, err := NormalizeEmail(email)
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: fmt.Sprintf("emails[%d]", i), Message: err.Error()})
+			continue
+		}
+		normalizedEmails = append(normalizedEmails, normalized)
+	}
+
+	normalizedSMSes := make([]string, 0, len(smses))
+	for i, sms := range smses {
+		normalized, err := NormalizePhoneNumber(sms, defaultRegion)
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: fmt.Sprintf("smses[%d]", i), Message: err.Error()})
+			continue
+		}
+		normalizedSMSes = append(normalizedSMSes, normalized)
+	}
+
+	if emails == nil {
+		normalizedEmails = nil
+	}
+	if smses == nil {
+		normalizedSMSes = nil
+	}
+	return normalizedEmails, normalizedSMSes, fieldErrors
+}
+
+// normalizeContacts applies NormalizeContacts when Config.ValidateContacts is enabled.
+// It returns a *ValidationError listing every invalid entry.
+func (client *Client) normalizeContacts(emails, smses []string) ([]string, []string, error) {
+	if !client.Config.ValidateContacts {
+		return emails, smses, nil
+	}
+
+	emails, smses, fieldErrors := NormalizeContacts(emails, smses, client.Config.DefaultPhoneRegion)
+	if len(fieldErrors) > 0 {
+		return nil, nil, &ValidationError{Fields: fieldErrors}
+	}
+	return emails, smses, nil
+}
+
+// normalizeRemovedContacts normalizes the contacts of a removal when Config.ValidateContacts is enabled,
+// for them to match the normalized contacts added. Invalid contacts are kept as given instead of failing,
+// as they may have been added before the validation was enabled.
+func (client *Client) normalizeRemovedContacts(emails, smses []string) ([]string, []string) {
+	if !client.Config.ValidateContacts {
+		return emails, smses
+	}
+
+	normalizedEmails := make([]string, len(emails))
+	for i, email := range emails {
+		normalizedEmails[i] = email
+		if normalized, err := NormalizeEmail(email); err == nil {
+			normalizedEmails[i] = normalized
+		}
+	}
+
+	normalizedSMSes := make([]string, len(smses))
+	for i, sms := range smses {
+		normalizedSMSes[i] = sms
+		if normalized, err := NormalizePhoneNumber(sms, client.Config.DefaultPhoneRegion); err == nil {
+			normalizedSMSes[i] = normalized
+		}
+	}
+
+	if emails == nil {
+		normalizedEmails = nil
+	}
+	if smses == nil {
+		normalizedSMSes = nil
+	}
+	return normalizedEmails, normalizedSMSes
+}
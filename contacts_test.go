@@ -0,0 +1,187 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	valid := map[string]string{
+		"user@example.com":            "user@example.com",
+		" User.Name+tag@Example.COM ": "User.Name+tag@example.com",
+		"user_name@Sub.Example.co.uk": "user_name@sub.example.co.uk",
+	}
+	for email, exp := range valid {
+		normalized, err := NormalizeEmail(email)
+		assert.Nil(t, err, email)
+		assert.Equal(t, exp, normalized)
+	}
+
+	for _, email := range []string{"", "invalid EMail @ Gmail. com", "user@localhost", "user@example.", "John <user@example.com>", "user@@example.com"} {
+		_, err := NormalizeEmail(email)
+		assert.NotNil(t, err, email)
+	}
+}
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	cases := []struct {
+		number string
+		region string
+		exp    string
+	}{
+		{"+85291239123", "", "+85291239123"},
+		{"+852 9123-9123", "USA", "+85291239123"},
+		{"00852 (9123) 9123", "", "+85291239123"},
+		{"9123 9123", "HKG", "+85291239123"},
+		{"(555) 507-2509", "usa", "+15555072509"},
+		{"1-555-507-2509", "USA", "+15555072509"},
+		{"07911 123456", "GBR", "+447911123456"},
+		{"030 123456", "DEU", "+4930123456"},
+	}
+	for _, c := range cases {
+		normalized, err := NormalizePhoneNumber(c.number, c.region)
+		assert.Nil(t, err, c.number)
+		assert.Equal(t, c.exp, normalized, c.number)
+	}
+
+	for _, number := range []string{"", "Invalid Mobile Phone Number", "91239123", "+0123456789", "+1234567890123456", "+852+91239123"} {
+		_, err := NormalizePhoneNumber(number, "")
+		assert.NotNil(t, err, number)
+	}
+
+	_, err := NormalizePhoneNumber("91239123", "XXX")
+	assert.Equal(t, `unknown default phone region "XXX", use an ISO Alpha-3 country code`, err.Error())
+}
+
+func TestNormalizeContacts(t *testing.T) {
+	emails, smses, fieldErrors := NormalizeContacts(
+		[]string{"user@Example.com", "invalid"},
+		[]string{"9123 9123", "+852 6123 6123", "call me"},
+		"HKG",
+	)
+	assert.Equal(t, []string{"user@example.com"}, emails)
+	assert.Equal(t, []string{"+85291239123", "+85261236123"}, smses)
+	assert.Equal(t, []FieldError{
+		{Field: "emails[1]", Message: errInvalidEmail},
+		{Field: "smses[2]", Message: errInvalidPhoneNumber},
+	}, fieldErrors)
+
+	emails, smses, fieldErrors = NormalizeContacts(nil, nil, "")
+	assert.Nil(t, emails)
+	assert.Nil(t, smses)
+	assert.Nil(t, fieldErrors)
+}
+
+func TestCreateTrackingValidateContacts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var req createTrackingRequest
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"meta": {"code": 201}, "data": {"tracking": {}}}`))
+	})
+
+	client.Config.ValidateContacts = true
+	client.Config.DefaultPhoneRegion = "USA"
+
+	_, err := client.CreateTracking(context.Background(), CreateTrackingParams{
+		TrackingNumber: "1111111111111",
+		Emails:         []string{"bad", "also bad"},
+		SMSes:          []string{"555"},
+	})
+	assert.NotNil(t, err)
+	assert.Len(t, err.(*ValidationError).Fields, 3)
+
+	_, err = client.CreateTracking(context.Background(), CreateTrackingParams{
+		TrackingNumber: "1111111111111",
+		Emails:         []string{"email@YourDomain.com"},
+		SMSes:          []string{"(855) 507-2509"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"email@yourdomain.com"}, req.Tracking.Emails)
+	assert.Equal(t, []string{"+18555072509"}, req.Tracking.SMSes)
+}
+
+func TestUpdateTrackingValidateContacts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Config.ValidateContacts = true
+
+	_, err := client.UpdateTracking(context.Background(), TrackingID("5b74f4958776db0e00b6f5ed"), UpdateTrackingParams{
+		SMSes: []string{"85291239123"},
+	})
+	assert.Equal(t, &ValidationError{
+		Fields: []FieldError{{Field: "smses[0]", Message: errInvalidPhoneNumber}},
+	}, err)
+}
+
+func TestAddNotificationValidateContacts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Config.ValidateContacts = true
+
+	p := SlugTrackingNumber{Slug: "xq-express", TrackingNumber: "LS404494276CN"}
+	_, err := client.AddNotification(context.Background(), p, Notification{
+		Emails: []string{"invalid EMail @ Gmail. com"},
+	})
+	assert.NotNil(t, err)
+
+	_, err = client.SetNotification(context.Background(), p, Notification{
+		SMSes: []string{"Invalid Mobile Phone Number"},
+	})
+	assert.NotNil(t, err)
+	_, ok := err.(*ValidationError)
+	assert.True(t, ok)
+}
+
+func TestAddThenRemoveNotificationContacts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client.Config.ValidateContacts = true
+	client.Config.DefaultPhoneRegion = "GBR"
+
+	// The receivers are stored as added, normalized
+	receivers := map[string]bool{}
+	handle := func(w http.ResponseWriter, r *http.Request, add bool) {
+		var body notificationWrapper
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		for _, contact := range append(body.Notification.Emails, body.Notification.SMSes...) {
+			if add {
+				receivers[contact] = true
+			} else {
+				delete(receivers, contact)
+			}
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"notification": {"emails": [], "smses": []}}}`))
+	}
+	mux.HandleFunc("/notifications/5b74f4958776db0e00b6f5ed/add", func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, true)
+	})
+	mux.HandleFunc("/notifications/5b74f4958776db0e00b6f5ed/remove", func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, false)
+	})
+
+	ctx := context.Background()
+	id := TrackingID("5b74f4958776db0e00b6f5ed")
+	_, err := client.AddNotification(ctx, id, Notification{Emails: []string{"Jane@EXAMPLE.com"}, SMSes: []string{"+44 7700 900123"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"Jane@example.com": true, "+447700900123": true}, receivers)
+
+	// Removing with the same input removes the normalized receivers, and invalid ones are sent as given
+	receivers["not a number"] = true
+	_, err = client.RemoveNotification(ctx, id, Notification{
+		Emails: []string{"Jane@EXAMPLE.com"},
+		SMSes:  []string{"+44 7700 900123", "not a number"},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, receivers)
+}
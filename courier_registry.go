@@ -0,0 +1,288 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCourierRegistryTTL is the default time the courier list is served before it is refreshed
+const DefaultCourierRegistryTTL = 24 * time.Hour
+
+// courierRegistryRetryInterval is the minimum time between refreshes after a failed one
+const courierRegistryRetryInterval = time.Minute
+
+// CourierLoader loads the full courier list, e.g. Client.GetAllCouriers
+type CourierLoader func(ctx context.Context) (CourierList, error)
+
+// CourierRegistry caches the courier list in process and refreshes it on a TTL.
+// When a refresh fails, the last loaded list keeps being served.
+type CourierRegistry struct {
+	load CourierLoader
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu          sync.RWMutex
+	index       *CourierIndex
+	loadedAt    time.Time
+	nextRefresh time.Time
+	lastError   error
+
+	// refreshMu makes concurrent callers wait for a single refresh
+	refreshMu sync.Mutex
+}
+
+// NewCourierRegistry returns a CourierRegistry loading couriers with load.
+// DefaultCourierRegistryTTL is used when ttl is not positive. load may be nil for a registry
+// that is only seeded from a snapshot.
+func NewCourierRegistry(load CourierLoader, ttl time.Duration) *CourierRegistry {
+	if ttl <= 0 {
+		ttl = DefaultCourierRegistryTTL
+	}
+
+	return &CourierRegistry{
+		load: load,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// NewAPICourierRegistry returns a CourierRegistry backed by the GetAllCouriers method of api, usually a Client.
+func NewAPICourierRegistry(api CouriersAPI, ttl time.Duration) *CourierRegistry {
+	return NewCourierRegistry(api.GetAllCouriers, ttl)
+}
+
+// ReadCourierList reads a courier list snapshot in JSON. Both a bare courier list and
+// a full API response envelope, as saved from /couriers/all, are accepted.
+func ReadCourierList(r io.Reader) (CourierList, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return CourierList{}, errors.Wrap(err, "could not read courier list")
+	}
+
+	var envelope struct {
+		Data *CourierList `json:"data"`
+		CourierList
+	}
+	if err := json.Unmarshal(contents, &envelope); err != nil {
+		return CourierList{}, errors.Wrap(err, "error unmarshalling the courier list")
+	}
+
+	if envelope.Data != nil {
+		return *envelope.Data, nil
+	}
+	return envelope.CourierList, nil
+}
+
+// Seed replaces the cached couriers with list, e.g. from an offline snapshot read by ReadCourierList.
+// A seeded list is served until the TTL expires, and afterwards for as long as refreshing fails.
+func (r *CourierRegistry) Seed(list CourierList) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.index = NewCourierIndex(list.Couriers)
+	r.loadedAt = r.now()
+	r.nextRefresh = r.loadedAt.Add(r.ttl)
+}
+
+// Refresh loads the courier list regardless of the TTL. On failure the cached list is kept.
+// Failures caused by ctx being cancelled or timing out are not recorded, and do not delay the next refresh.
+func (r *CourierRegistry) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	return r.refresh(ctx)
+}
+
+func (r *CourierRegistry) refresh(ctx context.Context) error {
+	if r.load == nil {
+		return errors.New("courier registry has no loader")
+	}
+
+	list, err := r.load(ctx)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// The caller gave up: the next callers refresh without waiting for the retry interval
+		return errors.Wrap(err, "error refreshing couriers")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastError = err
+	if err != nil {
+		retry := courierRegistryRetryInterval
+		if r.ttl < retry {
+			retry = r.ttl
+		}
+		r.nextRefresh = r.now().Add(retry)
+		return errors.Wrap(err, "error refreshing couriers")
+	}
+
+	r.index = NewCourierIndex(list.Couriers)
+	r.loadedAt = r.now()
+	r.nextRefresh = r.loadedAt.Add(r.ttl)
+	return nil
+}
+
+// Index returns the cached couriers, refreshing them first when the TTL has expired.
+// When refreshing fails but couriers were loaded before, the stale index is returned with a nil error
+// and the failure is available from LastError. A failed refresh is retried after at most a minute,
+// and until then the calls of a registry without couriers return the error without refreshing.
+func (r *CourierRegistry) Index(ctx context.Context) (*CourierIndex, error) {
+	index, due, lastErr := r.cached()
+	var err error
+	if due {
+		r.refreshMu.Lock()
+		// Another caller may have refreshed while we were waiting
+		if _, due, _ = r.cached(); due {
+			err = r.refresh(ctx)
+		}
+		r.refreshMu.Unlock()
+		index, _, lastErr = r.cached()
+	}
+
+	switch {
+	case index != nil:
+		return index, nil
+	case err != nil:
+		return nil, err
+	case lastErr != nil:
+		return nil, errors.Wrap(lastErr, "error refreshing couriers")
+	}
+	return nil, errors.New(errEmptyCourierRegistry)
+}
+
+// cached returns the cached index, whether it is due for a refresh, and the error of the last refresh.
+// A refresh is due once the TTL expired, or the retry interval after a failed refresh elapsed.
+func (r *CourierRegistry) cached() (*CourierIndex, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.index, !r.now().Before(r.nextRefresh), r.lastError
+}
+
+// LoadedAt returns when the cached couriers were loaded, or the zero time if nothing is loaded.
+func (r *CourierRegistry) LoadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.loadedAt
+}
+
+// LastError returns the error of the last refresh, or nil if it succeeded.
+func (r *CourierRegistry) LastError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lastError
+}
+
+// Courier returns the courier with slug.
+func (r *CourierRegistry) Courier(ctx context.Context, slug string) (Courier, bool, error) {
+	index, err := r.Index(ctx)
+	if err != nil {
+		return Courier{}, false, err
+	}
+
+	courier, ok := index.BySlug(slug)
+	return courier, ok, nil
+}
+
+// CourierIndex is an immutable set of couriers indexed by slug, name and service country
+type CourierIndex struct {
+	couriers  []Courier
+	bySlug    map[string]int
+	byName    map[string][]int
+	byCountry map[string][]int
+}
+
+// NewCourierIndex indexes couriers. Slugs are matched exactly, names and countries case-insensitively.
+func NewCourierIndex(couriers []Courier) *CourierIndex {
+	index := &CourierIndex{
+		couriers:  couriers,
+		bySlug:    make(map[string]int, len(couriers)),
+		byName:    make(map[string][]int),
+		byCountry: make(map[string][]int),
+	}
+
+	for i, courier := range couriers {
+		index.bySlug[courier.Slug] = i
+
+		names := map[string]bool{}
+		for _, name := range courierNames(courier) {
+			key := normalizeCourierName(name)
+			if key == "" || names[key] {
+				continue
+			}
+			names[key] = true
+			index.byName[key] = append(index.byName[key], i)
+		}
+
+		for _, country := range courier.ServiceFromCountryISO3 {
+			key := strings.ToUpper(country)
+			index.byCountry[key] = append(index.byCountry[key], i)
+		}
+	}
+
+	return index
+}
+
+// All returns every courier in the index.
+func (index *CourierIndex) All() []Courier {
+	return index.couriers
+}
+
+// Len returns the number of couriers in the index.
+func (index *CourierIndex) Len() int {
+	return len(index.couriers)
+}
+
+// BySlug returns the courier with slug.
+func (index *CourierIndex) BySlug(slug string) (Courier, bool) {
+	i, ok := index.bySlug[slug]
+	if !ok {
+		return Courier{}, false
+	}
+	return index.couriers[i], true
+}
+
+// ByName returns the couriers whose Name or one of its OtherName aliases equals name, ignoring case.
+func (index *CourierIndex) ByName(name string) []Courier {
+	return index.collect(index.byName[normalizeCourierName(name)])
+}
+
+// ByServiceCountry returns the couriers providing service from the ISO Alpha-3 country.
+func (index *CourierIndex) ByServiceCountry(iso3 string) []Courier {
+	return index.collect(index.byCountry[strings.ToUpper(iso3)])
+}
+
+func (index *CourierIndex) collect(positions []int) []Courier {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	couriers := make([]Courier, len(positions))
+	for i, position := range positions {
+		couriers[i] = index.couriers[position]
+	}
+	return couriers
+}
+
+// courierNames returns the Name and the comma separated OtherName aliases of courier
+func courierNames(courier Courier) []string {
+	names := []string{courier.Name}
+	if courier.OtherName != "" {
+		names = append(names, strings.Split(courier.OtherName, ",")...)
+	}
+	return names
+}
+
+func normalizeCourierName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
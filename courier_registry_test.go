@@ -0,0 +1,244 @@
+package aftership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var registryTestCouriers = CourierList{
+	Total: 3,
+	Couriers: []Courier{
+		{
+			Slug:                   "deutsch-post",
+			Name:                   "Deutsche Post Mail",
+			OtherName:              "dpdhl, Deutsche Post",
+			RequiredFields:         []string{"tracking_ship_date", "tracking_postal_code"},
+			ServiceFromCountryISO3: []string{"DEU"},
+		},
+		{
+			Slug:                   "dhl-germany",
+			Name:                   "Deutsche Post DHL",
+			OtherName:              "DHL Germany",
+			ServiceFromCountryISO3: []string{"DEU"},
+		},
+		{
+			Slug:                   "royal-mail",
+			Name:                   "Royal Mail",
+			OtherName:              "Royal Mail United Kingdom",
+			ServiceFromCountryISO3: []string{"GBR"},
+		},
+	},
+}
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestCourierIndex(t *testing.T) {
+	index := NewCourierIndex(registryTestCouriers.Couriers)
+	assert.Equal(t, 3, index.Len())
+
+	courier, ok := index.BySlug("royal-mail")
+	assert.True(t, ok)
+	assert.Equal(t, "Royal Mail", courier.Name)
+
+	_, ok = index.BySlug("Royal-Mail")
+	assert.False(t, ok)
+
+	assert.Equal(t, []Courier{registryTestCouriers.Couriers[0]}, index.ByName(" deutsche  POST "))
+	assert.Equal(t, []Courier{registryTestCouriers.Couriers[1]}, index.ByName("dhl germany"))
+	assert.Nil(t, index.ByName("unknown"))
+
+	assert.Len(t, index.ByServiceCountry("deu"), 2)
+	assert.Len(t, index.ByServiceCountry("GBR"), 1)
+	assert.Nil(t, index.ByServiceCountry("USA"))
+}
+
+func TestCourierRegistryTTL(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1600000000, 0)}
+	calls := 0
+	registry := NewCourierRegistry(func(ctx context.Context) (CourierList, error) {
+		calls++
+		return registryTestCouriers, nil
+	}, time.Hour)
+	registry.now = clock.now
+
+	index, err := registry.Index(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, index.Len())
+	assert.Equal(t, 1, calls)
+
+	clock.t = clock.t.Add(59 * time.Minute)
+	_, err = registry.Index(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+
+	clock.t = clock.t.Add(time.Minute)
+	_, err = registry.Index(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, clock.t, registry.LoadedAt())
+}
+
+func TestCourierRegistryServesStale(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1600000000, 0)}
+	calls := 0
+	registry := NewCourierRegistry(func(ctx context.Context) (CourierList, error) {
+		calls++
+		return CourierList{}, errors.New("boom")
+	}, time.Hour)
+	registry.now = clock.now
+
+	// Nothing to serve yet
+	_, err := registry.Index(context.Background())
+	assert.NotNil(t, err)
+
+	registry.Seed(registryTestCouriers)
+	seededAt := registry.LoadedAt()
+
+	clock.t = clock.t.Add(2 * time.Hour)
+	courier, ok, err := registry.Courier(context.Background(), "deutsch-post")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Deutsche Post Mail", courier.Name)
+	assert.NotNil(t, registry.LastError())
+	assert.Equal(t, seededAt, registry.LoadedAt())
+	assert.Equal(t, 2, calls)
+
+	// A failed refresh is not retried right away
+	_, _, err = registry.Courier(context.Background(), "deutsch-post")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+
+	clock.t = clock.t.Add(time.Minute)
+	_, _, err = registry.Courier(context.Background(), "deutsch-post")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCourierRegistryRetryWhenEmpty(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1600000000, 0)}
+	calls := 0
+	registry := NewCourierRegistry(func(ctx context.Context) (CourierList, error) {
+		calls++
+		if calls < 2 {
+			return CourierList{}, errors.New("boom")
+		}
+		return registryTestCouriers, nil
+	}, time.Hour)
+	registry.now = clock.now
+
+	// Without couriers, the lookups return the error of the failed refresh until the retry interval elapsed
+	for i := 0; i < 3; i++ {
+		_, _, err := registry.Courier(context.Background(), "royal-mail")
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "boom")
+		}
+	}
+	assert.Equal(t, 1, calls)
+
+	clock.t = clock.t.Add(time.Minute)
+	_, ok, err := registry.Courier(context.Background(), "royal-mail")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCourierRegistryWithoutLoader(t *testing.T) {
+	registry := NewCourierRegistry(nil, 0)
+	assert.Equal(t, DefaultCourierRegistryTTL, registry.ttl)
+	assert.NotNil(t, registry.Refresh(context.Background()))
+
+	registry.Seed(registryTestCouriers)
+	_, ok, err := registry.Courier(context.Background(), "royal-mail")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestReadCourierList(t *testing.T) {
+	list, err := ReadCourierList(strings.NewReader(`{
+		"meta": {
+			"code": 200
+		},
+		"data": {
+			"total": 1,
+			"couriers": [{"slug": "dhl", "name": "DHL"}]
+		}
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, CourierList{Total: 1, Couriers: []Courier{{Slug: "dhl", Name: "DHL"}}}, list)
+
+	list, err = ReadCourierList(strings.NewReader(`{"total": 1, "couriers": [{"slug": "ups", "name": "UPS"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, CourierList{Total: 1, Couriers: []Courier{{Slug: "ups", Name: "UPS"}}}, list)
+
+	_, err = ReadCourierList(strings.NewReader(`{`))
+	assert.NotNil(t, err)
+}
+
+func TestClientCourierRegistry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/couriers/all", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"total": 1,
+					"couriers": [
+							{
+									"slug": "india-post-int",
+									"name": "India Post International",
+									"other_name": "भारतीय डाक, Speed Post & eMO, EMS, IPS Web",
+									"service_from_country_iso3": ["IND"]
+							}
+					]
+			}
+		}`))
+	})
+
+	registry := NewAPICourierRegistry(client, time.Minute)
+	index, err := registry.Index(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, index.ByName("EMS"), 1)
+	assert.Len(t, index.ByServiceCountry("IND"), 1)
+}
+
+func TestCourierRegistryCancelledRefresh(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1600000000, 0)}
+	calls := 0
+	registry := NewCourierRegistry(func(ctx context.Context) (CourierList, error) {
+		calls++
+		if err := ctx.Err(); err != nil {
+			return CourierList{}, fmt.Errorf("HTTP request failed: %w", err)
+		}
+		return registryTestCouriers, nil
+	}, time.Hour)
+	registry.now = clock.now
+
+	// A caller giving up does not make the other callers wait for the retry interval
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := registry.Courier(cancelled, "royal-mail")
+	assert.NotNil(t, err)
+	assert.Nil(t, registry.LastError())
+
+	_, ok, err := registry.Courier(context.Background(), "royal-mail")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,207 @@
+package aftership
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Match quality scores of a courier search, highest is best
+const (
+	courierMatchExact      = 100
+	courierMatchPrefix     = 80
+	courierMatchWordPrefix = 60
+	courierMatchSubstring  = 40
+	courierMatchFuzzy      = 20
+)
+
+// CourierSearchParams are the criteria of a courier search
+type CourierSearchParams struct {
+	// Query is fuzzy matched against the slug, Name and OtherName aliases of couriers.
+	// All couriers passing the filters are returned, sorted by name, when empty.
+	Query string
+
+	// ServiceFromCountryISO3 keeps couriers providing service from the ISO Alpha-3 country.
+	ServiceFromCountryISO3 string
+
+	// Language keeps couriers with the ISO 639-1 language as default or supported language.
+	Language string
+
+	// Limit is the maximum number of couriers returned. No limit when zero.
+	Limit int
+}
+
+// courierMatch is a courier with the quality of its best name match
+type courierMatch struct {
+	courier Courier
+	score   int
+}
+
+// Search returns the couriers matching params, best matches first.
+func (index *CourierIndex) Search(params CourierSearchParams) []Courier {
+	query := searchableCourierName(params.Query)
+	candidates := index.couriers
+	if params.ServiceFromCountryISO3 != "" {
+		candidates = index.ByServiceCountry(params.ServiceFromCountryISO3)
+	}
+
+	var matches []courierMatch
+	for _, courier := range candidates {
+		if params.Language != "" && !courierSupportsLanguage(courier, params.Language) {
+			continue
+		}
+
+		score := courierMatchExact
+		if query != "" {
+			score = scoreCourier(courier, query)
+		}
+		if score > 0 {
+			matches = append(matches, courierMatch{courier: courier, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return strings.ToLower(matches[i].courier.Name) < strings.ToLower(matches[j].courier.Name)
+	})
+
+	if params.Limit > 0 && len(matches) > params.Limit {
+		matches = matches[:params.Limit]
+	}
+
+	couriers := make([]Courier, len(matches))
+	for i, match := range matches {
+		couriers[i] = match.courier
+	}
+	return couriers
+}
+
+// SearchCouriers searches the cached couriers, see CourierIndex.Search.
+func (r *CourierRegistry) SearchCouriers(ctx context.Context, params CourierSearchParams) ([]Courier, error) {
+	index, err := r.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.Search(params), nil
+}
+
+func courierSupportsLanguage(courier Courier, language string) bool {
+	if strings.EqualFold(courier.DefaultLanguage, language) {
+		return true
+	}
+	for _, supported := range courier.SupportedLanguages {
+		if strings.EqualFold(supported, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreCourier returns the best match quality of query against the names of courier, or 0 for no match.
+// A match on Name ranks above an equal match on the slug or an OtherName alias.
+func scoreCourier(courier Courier, query string) int {
+	best := scoreCourierName(searchableCourierName(courier.Slug), query)
+	for i, name := range courierNames(courier) {
+		score := scoreCourierName(searchableCourierName(name), query)
+		if i == 0 && score > 0 {
+			score++
+		}
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func scoreCourierName(name, query string) int {
+	switch {
+	case name == "":
+		return 0
+	case name == query:
+		return courierMatchExact
+	case strings.HasPrefix(name, query):
+		return courierMatchPrefix
+	case wordsHavePrefixes(strings.Fields(name), strings.Fields(query)):
+		return courierMatchWordPrefix
+	case strings.Contains(name, query):
+		return courierMatchSubstring
+	}
+
+	// Allow about one typo per four characters
+	maxDistance := len([]rune(query)) / 4
+	if maxDistance == 0 {
+		return 0
+	}
+
+	// Compare with the whole name, its beginning and each of its words
+	distance := levenshtein(name, query)
+	candidates := strings.Fields(name)
+	if runes := []rune(name); len(runes) > len([]rune(query)) {
+		candidates = append(candidates, string(runes[:len([]rune(query))]))
+	}
+	for _, candidate := range candidates {
+		if d := levenshtein(candidate, query); d < distance {
+			distance = d
+		}
+	}
+	if distance > maxDistance {
+		return 0
+	}
+	return courierMatchFuzzy - distance
+}
+
+// wordsHavePrefixes reports whether every query word is a prefix of a distinct name word, in order
+func wordsHavePrefixes(words, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return false
+	}
+
+	i := 0
+	for _, word := range words {
+		if i < len(prefixes) && strings.HasPrefix(word, prefixes[i]) {
+			i++
+		}
+	}
+	return i == len(prefixes)
+}
+
+// searchableCourierName lower cases name and replaces punctuation with single spaces
+func searchableCourierName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+package aftership
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var searchTestCouriers = []Courier{
+	{
+		Slug:                   "deutsch-post",
+		Name:                   "Deutsche Post Mail",
+		OtherName:              "dpdhl",
+		DefaultLanguage:        "de",
+		SupportedLanguages:     []string{"en"},
+		ServiceFromCountryISO3: []string{"DEU"},
+	},
+	{
+		Slug:                   "dhl-germany",
+		Name:                   "Deutsche Post DHL",
+		OtherName:              "DHL Germany",
+		DefaultLanguage:        "de",
+		ServiceFromCountryISO3: []string{"DEU"},
+	},
+	{
+		Slug:                   "royal-mail",
+		Name:                   "Royal Mail",
+		OtherName:              "Royal Mail United Kingdom",
+		DefaultLanguage:        "en",
+		ServiceFromCountryISO3: []string{"GBR"},
+	},
+	{
+		Slug:                   "parcel-force",
+		Name:                   "Parcel Force",
+		OtherName:              "Parcelforce Worldwide, Royal Mail Group",
+		DefaultLanguage:        "en",
+		ServiceFromCountryISO3: []string{"GBR"},
+	},
+}
+
+func searchedSlugs(couriers []Courier) []string {
+	var slugs []string
+	for _, courier := range couriers {
+		slugs = append(slugs, courier.Slug)
+	}
+	return slugs
+}
+
+func TestCourierIndexSearch(t *testing.T) {
+	index := NewCourierIndex(searchTestCouriers)
+
+	cases := []struct {
+		params CourierSearchParams
+		slugs  []string
+	}{
+		// exact name first, then alias prefix
+		{CourierSearchParams{Query: "Royal Mail"}, []string{"royal-mail", "parcel-force"}},
+		// equal matches are sorted by name
+		{CourierSearchParams{Query: "deutsche post"}, []string{"dhl-germany", "deutsch-post"}},
+		{CourierSearchParams{Query: "post dhl"}, []string{"dhl-germany"}},
+		{CourierSearchParams{Query: "dhl"}, []string{"dhl-germany", "deutsch-post"}},
+		// typos
+		{CourierSearchParams{Query: "Deutche Post"}, []string{"dhl-germany", "deutsch-post"}},
+		{CourierSearchParams{Query: "parcelfroce"}, []string{"parcel-force"}},
+		{CourierSearchParams{Query: "ups"}, nil},
+		// filters
+		{CourierSearchParams{Query: "mail", ServiceFromCountryISO3: "gbr"}, []string{"royal-mail", "parcel-force"}},
+		{CourierSearchParams{Query: "mail", ServiceFromCountryISO3: "DEU"}, []string{"deutsch-post"}},
+		{CourierSearchParams{Query: "mail", Language: "de"}, []string{"deutsch-post"}},
+		{CourierSearchParams{Language: "EN"}, []string{"deutsch-post", "parcel-force", "royal-mail"}},
+		{CourierSearchParams{Query: "post", Limit: 1}, []string{"dhl-germany"}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.slugs, searchedSlugs(index.Search(c.params)), c.params.Query)
+	}
+}
+
+func TestCourierRegistrySearchCouriers(t *testing.T) {
+	registry := NewCourierRegistry(nil, 0)
+	_, err := registry.SearchCouriers(context.Background(), CourierSearchParams{Query: "royal"})
+	assert.NotNil(t, err)
+
+	registry.Seed(CourierList{Total: len(searchTestCouriers), Couriers: searchTestCouriers})
+	couriers, err := registry.SearchCouriers(context.Background(), CourierSearchParams{Query: "royal"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"royal-mail", "parcel-force"}, searchedSlugs(couriers))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("", ""))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 2, levenshtein("straße", "strasse"))
+}
@@ -45,7 +45,7 @@ type CourierDetectionParams struct {
 // GetCouriers returns a list of couriers activated at your AfterShip account.
 func (client *Client) GetCouriers(ctx context.Context) (CourierList, error) {
 	var courierList CourierList
-	err := client.makeRequest(ctx, http.MethodGet, "/couriers", nil, nil, &courierList)
+	err := client.makeReadRequest(ctx, "/couriers", nil, &courierList)
 	return courierList, err
 }
 
@@ -3,6 +3,7 @@ package aftership_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aftership/aftership-sdk-go/v2"
 )
@@ -70,3 +71,57 @@ func ExampleClient_DetectCouriers() {
 
 	fmt.Println(list)
 }
+
+func ExampleNewAPICourierRegistry() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Cache all couriers for an hour
+	registry := aftership.NewAPICourierRegistry(cli, time.Hour)
+
+	courier, ok, err := registry.Courier(context.Background(), "deutsch-post")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if ok {
+		fmt.Println(courier.RequiredFields)
+	}
+}
+
+func ExampleOfflineDetector_DetectCouriers() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	detector, err := aftership.NewOfflineDetector(aftership.DefaultTrackingNumberPatterns)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Detect courier offline, calling the API only when ambiguous
+	params := aftership.CourierDetectionParams{
+		TrackingNumber: "1Z999AA10123456784",
+	}
+
+	list, err := detector.DetectCouriers(context.Background(), cli, params)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(list)
+}
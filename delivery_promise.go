@@ -0,0 +1,285 @@
+package aftership
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PromiseStatus is the classification of a tracking against its promised delivery date
+type PromiseStatus string
+
+// Promise statuses
+const (
+	PromiseOnTrack PromiseStatus = "on_track" // Expected to arrive, or delivered, by the promised date
+	PromiseAtRisk  PromiseStatus = "at_risk"  // Estimated to arrive after the promised date, or in an exception state
+	PromiseLate    PromiseStatus = "late"     // Delivered after the promised date, or the promised date has passed
+	PromiseUnknown PromiseStatus = "unknown"  // The tracking has no promised delivery date
+)
+
+// EstimatedDeliverySource is the tracking field the estimated delivery of a PromiseEvaluation was taken from
+type EstimatedDeliverySource string
+
+// Estimated delivery sources, in the order they are tried
+const (
+	EstimatedDeliverySourceNone      EstimatedDeliverySource = ""
+	EstimatedDeliverySourceOnTime    EstimatedDeliverySource = "on_time_status"
+	EstimatedDeliverySourceDelivered EstimatedDeliverySource = "shipment_delivery_date"
+	EstimatedDeliverySourceLatest    EstimatedDeliverySource = "latest_estimated_delivery"
+	EstimatedDeliverySourceAfterShip EstimatedDeliverySource = "aftership_estimated_delivery_date"
+	EstimatedDeliverySourceExpected  EstimatedDeliverySource = "expected_delivery"
+)
+
+const estimatedDeliveryDateLayout = "2006-01-02"
+
+// The OnTimeStatus values of the delivered trackings
+const (
+	onTimeStatusOnTime = "on-time"
+	onTimeStatusLate   = "late"
+)
+
+// PromiseEvaluation is the result of evaluating a tracking against its promised delivery date
+type PromiseEvaluation struct {
+	Tracking Tracking      `json:"tracking"`
+	Status   PromiseStatus `json:"status"`
+
+	// Days is the number of days the tracking is, or is estimated to be, late. It is 0 when on track.
+	Days int `json:"days"`
+
+	// Reason explains the status in a human readable sentence
+	Reason string `json:"reason"`
+
+	// Source is the field the estimated delivery was taken from
+	Source EstimatedDeliverySource `json:"source,omitempty"`
+
+	// PromisedDelivery is the promised delivery date, zero when the tracking has none
+	PromisedDelivery time.Time `json:"promised_delivery"`
+
+	// EstimatedDeliveryMin and EstimatedDeliveryMax are the estimated delivery dates.
+	// They are equal for a single date estimate and zero when there is no estimate.
+	EstimatedDeliveryMin time.Time `json:"estimated_delivery_min"`
+	EstimatedDeliveryMax time.Time `json:"estimated_delivery_max"`
+}
+
+// PromiseEvaluatorOptions configures a PromiseEvaluator. The zero value uses the defaults.
+type PromiseEvaluatorOptions struct {
+	// Location is the time zone of "today" when comparing with the promised dates. Defaults to UTC.
+	Location *time.Location
+
+	// AtRiskTags are the tags that put an undelivered tracking at risk whatever its estimate.
+	// Defaults to AttemptFail, Exception and Expired.
+	AtRiskTags []string
+}
+
+// PromiseEvaluator classifies trackings as on track, at risk or late against their OrderPromisedDeliveryDate.
+// All dates are compared as calendar days; the time and time zone of the estimates are ignored.
+type PromiseEvaluator struct {
+	options PromiseEvaluatorOptions
+	now     func() time.Time
+}
+
+// NewPromiseEvaluator returns a PromiseEvaluator with options.
+func NewPromiseEvaluator(options PromiseEvaluatorOptions) *PromiseEvaluator {
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+	if options.AtRiskTags == nil {
+		options.AtRiskTags = []string{TagAttemptFail, TagException, TagExpired}
+	}
+
+	return &PromiseEvaluator{
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// Evaluate classifies tracking against its promised delivery date.
+// Delivered trackings are classified by their OnTimeStatus and OnTimeDifference when AfterShip set them,
+// and compared by ShipmentDeliveryDate otherwise. Other trackings are late once the promised date
+// has passed, and at risk when the end of the estimated delivery is after the promised date.
+// The estimate is taken from LatestEstimatedDelivery, then EstimatedDeliveryDate, then ExpectedDelivery.
+func (e *PromiseEvaluator) Evaluate(tracking Tracking) PromiseEvaluation {
+	evaluation := PromiseEvaluation{Tracking: tracking}
+
+	promised, ok := parseEstimatedDeliveryDate(tracking.OrderPromisedDeliveryDate)
+	if !ok {
+		evaluation.Status = PromiseUnknown
+		evaluation.Reason = "no promised delivery date"
+		return evaluation
+	}
+	evaluation.PromisedDelivery = promised
+
+	if tracking.Tag == TagDelivered {
+		delivered, ok := parseEstimatedDeliveryDate(tracking.ShipmentDeliveryDate)
+		if ok {
+			evaluation.EstimatedDeliveryMin, evaluation.EstimatedDeliveryMax = delivered, delivered
+		}
+
+		switch strings.ToLower(tracking.OnTimeStatus) {
+		case onTimeStatusLate:
+			evaluation.Source = EstimatedDeliverySourceOnTime
+			evaluation.Status = PromiseLate
+			evaluation.Days = tracking.OnTimeDifference
+			if evaluation.Days <= 0 && ok {
+				evaluation.Days = daysBetween(promised, delivered)
+			}
+			if evaluation.Days > 0 {
+				evaluation.Reason = fmt.Sprintf("delivered %s after the promised date", pluralDays(evaluation.Days))
+			} else {
+				evaluation.Days = 0
+				evaluation.Reason = "delivered after the promised date"
+			}
+			return evaluation
+		case onTimeStatusOnTime:
+			evaluation.Source = EstimatedDeliverySourceOnTime
+			evaluation.Status = PromiseOnTrack
+			evaluation.Reason = "delivered by the promised date"
+			return evaluation
+		}
+
+		if ok {
+			evaluation.Source = EstimatedDeliverySourceDelivered
+			if days := daysBetween(promised, delivered); days > 0 {
+				evaluation.Status = PromiseLate
+				evaluation.Days = days
+				evaluation.Reason = fmt.Sprintf("delivered %s after the promised date", pluralDays(days))
+				return evaluation
+			}
+		}
+		evaluation.Status = PromiseOnTrack
+		evaluation.Reason = "delivered by the promised date"
+		return evaluation
+	}
+
+	evaluation.Source, evaluation.EstimatedDeliveryMin, evaluation.EstimatedDeliveryMax = estimatedDelivery(tracking)
+
+	now := e.now().In(e.options.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if days := daysBetween(promised, today); days > 0 {
+		evaluation.Status = PromiseLate
+		evaluation.Days = days
+		evaluation.Reason = fmt.Sprintf("not delivered %s after the promised date", pluralDays(days))
+		return evaluation
+	}
+
+	if evaluation.Source != EstimatedDeliverySourceNone {
+		if days := daysBetween(promised, evaluation.EstimatedDeliveryMax); days > 0 {
+			evaluation.Status = PromiseAtRisk
+			evaluation.Days = days
+			if evaluation.EstimatedDeliveryMin.After(promised) {
+				evaluation.Reason = fmt.Sprintf("estimated delivery is %s after the promised date", pluralDays(days))
+			} else {
+				evaluation.Reason = fmt.Sprintf("estimated delivery range ends %s after the promised date", pluralDays(days))
+			}
+			return evaluation
+		}
+	}
+
+	for _, tag := range e.options.AtRiskTags {
+		if tracking.Tag == tag {
+			evaluation.Status = PromiseAtRisk
+			evaluation.Reason = fmt.Sprintf("tracking is %s", tracking.Tag)
+			return evaluation
+		}
+	}
+
+	evaluation.Status = PromiseOnTrack
+	if evaluation.Source == EstimatedDeliverySourceNone {
+		evaluation.Reason = "no estimated delivery, promised date not passed"
+	} else {
+		evaluation.Reason = "estimated delivery is by the promised date"
+	}
+	return evaluation
+}
+
+// EvaluateTrackings evaluates every tracking matched by params, fetching one page at a time, and calls fn with each evaluation.
+// It stops at the first error returned by fn.
+func (e *PromiseEvaluator) EvaluateTrackings(ctx context.Context, api TrackingsAPI, params GetTrackingsParams, fn func(PromiseEvaluation) error) error {
+	return WalkTrackings(ctx, api, params, func(tracking Tracking) error {
+		return fn(e.Evaluate(tracking))
+	})
+}
+
+// AtRisk returns the at risk and late evaluations of the trackings matched by params, most days late first.
+// It is the base of a daily at-risk report.
+func (e *PromiseEvaluator) AtRisk(ctx context.Context, api TrackingsAPI, params GetTrackingsParams) ([]PromiseEvaluation, error) {
+	var evaluations []PromiseEvaluation
+	err := e.EvaluateTrackings(ctx, api, params, func(evaluation PromiseEvaluation) error {
+		if evaluation.Status == PromiseAtRisk || evaluation.Status == PromiseLate {
+			evaluations = append(evaluations, evaluation)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		return evaluations[i].Days > evaluations[j].Days
+	})
+	return evaluations, nil
+}
+
+// estimatedDelivery returns the first available estimated delivery of tracking, as a range of dates
+func estimatedDelivery(tracking Tracking) (EstimatedDeliverySource, time.Time, time.Time) {
+	led := tracking.LatestEstimatedDelivery
+	if earliest, latest, ok := estimatedDeliveryRange(led.Datetime, led.DatetimeMin, led.DatetimeMax); ok {
+		return EstimatedDeliverySourceLatest, earliest, latest
+	}
+
+	edd := tracking.EstimatedDeliveryDate
+	if earliest, latest, ok := estimatedDeliveryRange(edd.EstimatedDeliveryDate, edd.EstimatedDeliveryDateMin, edd.EstimatedDeliveryDateMax); ok {
+		return EstimatedDeliverySourceAfterShip, earliest, latest
+	}
+
+	if expected, ok := parseEstimatedDeliveryDate(tracking.ExpectedDelivery); ok {
+		return EstimatedDeliverySourceExpected, expected, expected
+	}
+
+	return EstimatedDeliverySourceNone, time.Time{}, time.Time{}
+}
+
+// estimatedDeliveryRange returns the range of a single date or date range estimate.
+// A range missing one end is treated as a single date.
+func estimatedDeliveryRange(datetime, datetimeMin, datetimeMax string) (time.Time, time.Time, bool) {
+	earliest, okEarliest := parseEstimatedDeliveryDate(datetimeMin)
+	latest, okLatest := parseEstimatedDeliveryDate(datetimeMax)
+	switch {
+	case okEarliest && okLatest:
+		if latest.Before(earliest) {
+			earliest, latest = latest, earliest
+		}
+		return earliest, latest, true
+	case okEarliest:
+		return earliest, earliest, true
+	case okLatest:
+		return latest, latest, true
+	}
+
+	date, ok := parseEstimatedDeliveryDate(datetime)
+	return date, date, ok
+}
+
+// parseEstimatedDeliveryDate parses the calendar date of a YYYY-MM-DD, YYYY-MM-DDTHH:mm:ss
+// or YYYY-MM-DDTHH:mm:ssZ value, ignoring its time and time zone
+func parseEstimatedDeliveryDate(value string) (time.Time, bool) {
+	if len(value) < len(estimatedDeliveryDateLayout) {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(estimatedDeliveryDateLayout, value[:len(estimatedDeliveryDateLayout)])
+	return date, err == nil
+}
+
+// daysBetween returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func pluralDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
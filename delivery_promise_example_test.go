@@ -0,0 +1,33 @@
+package aftership_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func ExamplePromiseEvaluator_AtRisk() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Daily report of the undelivered trackings that will miss, or have missed, their promised delivery date.
+	evaluator := aftership.NewPromiseEvaluator(aftership.PromiseEvaluatorOptions{})
+	evaluations, err := evaluator.AtRisk(context.Background(), cli, aftership.GetTrackingsParams{
+		Tag: aftership.TagInTransit,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, evaluation := range evaluations {
+		fmt.Println(evaluation.Tracking.TrackingNumber, evaluation.Status, evaluation.Days, evaluation.Reason, evaluation.Source)
+	}
+}
@@ -0,0 +1,169 @@
+package aftership
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPromiseEvaluator() *PromiseEvaluator {
+	e := NewPromiseEvaluator(PromiseEvaluatorOptions{})
+	e.now = func() time.Time {
+		return time.Date(2022, 11, 10, 15, 0, 0, 0, time.UTC)
+	}
+	return e
+}
+
+func TestPromiseEvaluatorEvaluate(t *testing.T) {
+	e := newTestPromiseEvaluator()
+
+	cases := []struct {
+		name     string
+		tracking Tracking
+		status   PromiseStatus
+		days     int
+		source   EstimatedDeliverySource
+		reason   string
+	}{
+		{
+			name:     "no promise",
+			tracking: Tracking{Tag: TagInTransit},
+			status:   PromiseUnknown,
+			reason:   "no promised delivery date",
+		},
+		{
+			name:     "delivered on time",
+			tracking: Tracking{Tag: TagDelivered, OrderPromisedDeliveryDate: "2022-11-08", ShipmentDeliveryDate: "2022-11-08T18:30:00+08:00"},
+			status:   PromiseOnTrack,
+			source:   EstimatedDeliverySourceDelivered,
+			reason:   "delivered by the promised date",
+		},
+		{
+			name:     "delivered late",
+			tracking: Tracking{Tag: TagDelivered, OrderPromisedDeliveryDate: "2022-11-05", ShipmentDeliveryDate: "2022-11-08T09:00:00"},
+			status:   PromiseLate,
+			days:     3,
+			source:   EstimatedDeliverySourceDelivered,
+			reason:   "delivered 3 days after the promised date",
+		},
+		{
+			name: "delivered late per on time status",
+			tracking: Tracking{Tag: TagDelivered, OrderPromisedDeliveryDate: "2022-11-08", ShipmentDeliveryDate: "2022-11-08T18:30:00",
+				OnTimeStatus: "late", OnTimeDifference: 2},
+			status: PromiseLate,
+			days:   2,
+			source: EstimatedDeliverySourceOnTime,
+			reason: "delivered 2 days after the promised date",
+		},
+		{
+			name: "delivered on time per on time status",
+			tracking: Tracking{Tag: TagDelivered, OrderPromisedDeliveryDate: "2022-11-05", ShipmentDeliveryDate: "2022-11-08T09:00:00",
+				OnTimeStatus: "on-time"},
+			status: PromiseOnTrack,
+			source: EstimatedDeliverySourceOnTime,
+			reason: "delivered by the promised date",
+		},
+		{
+			name:     "promise passed",
+			tracking: Tracking{Tag: TagInTransit, OrderPromisedDeliveryDate: "2022-11-09", ExpectedDelivery: "2022-11-09"},
+			status:   PromiseLate,
+			days:     1,
+			source:   EstimatedDeliverySourceExpected,
+			reason:   "not delivered 1 day after the promised date",
+		},
+		{
+			name: "single date estimate after promise",
+			tracking: Tracking{
+				Tag:                       TagInTransit,
+				OrderPromisedDeliveryDate: "2022-11-12",
+				LatestEstimatedDelivery:   LatestEstimatedDelivery{Type: "specific", Datetime: "2022-11-14T12:00:00Z"},
+			},
+			status: PromiseAtRisk,
+			days:   2,
+			source: EstimatedDeliverySourceLatest,
+			reason: "estimated delivery is 2 days after the promised date",
+		},
+		{
+			name: "range estimate ending after promise",
+			tracking: Tracking{
+				Tag:                       TagInTransit,
+				OrderPromisedDeliveryDate: "2022-11-12",
+				LatestEstimatedDelivery:   LatestEstimatedDelivery{Type: "range", DatetimeMin: "2022-11-11", DatetimeMax: "2022-11-13"},
+			},
+			status: PromiseAtRisk,
+			days:   1,
+			source: EstimatedDeliverySourceLatest,
+			reason: "estimated delivery range ends 1 day after the promised date",
+		},
+		{
+			name: "aftership estimate within promise",
+			tracking: Tracking{
+				Tag:                       TagInTransit,
+				OrderPromisedDeliveryDate: "2022-11-12",
+				EstimatedDeliveryDate:     EstimatedDeliveryDate{EstimatedDeliveryDateMin: "2022-11-10", EstimatedDeliveryDateMax: "2022-11-12"},
+			},
+			status: PromiseOnTrack,
+			source: EstimatedDeliverySourceAfterShip,
+			reason: "estimated delivery is by the promised date",
+		},
+		{
+			name:     "exception",
+			tracking: Tracking{Tag: TagException, OrderPromisedDeliveryDate: "2022-11-12"},
+			status:   PromiseAtRisk,
+			reason:   "tracking is Exception",
+		},
+		{
+			name:     "no estimate",
+			tracking: Tracking{Tag: TagInfoReceived, OrderPromisedDeliveryDate: "2022-11-10"},
+			status:   PromiseOnTrack,
+			reason:   "no estimated delivery, promised date not passed",
+		},
+	}
+
+	for _, c := range cases {
+		evaluation := e.Evaluate(c.tracking)
+		assert.Equal(t, c.status, evaluation.Status, c.name)
+		assert.Equal(t, c.days, evaluation.Days, c.name)
+		assert.Equal(t, c.source, evaluation.Source, c.name)
+		assert.Equal(t, c.reason, evaluation.Reason, c.name)
+	}
+}
+
+func TestPromiseEvaluatorLocation(t *testing.T) {
+	e := newTestPromiseEvaluator()
+	tracking := Tracking{Tag: TagInTransit, OrderPromisedDeliveryDate: "2022-11-10"}
+	assert.Equal(t, PromiseOnTrack, e.Evaluate(tracking).Status)
+
+	// 2022-11-10T15:00:00Z is already 2022-11-11 in Tokyo
+	e.options.Location = time.FixedZone("JST", 9*60*60)
+	assert.Equal(t, PromiseLate, e.Evaluate(tracking).Status)
+}
+
+func TestPromiseEvaluatorAtRisk(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		page := r.URL.Query().Get("page")
+		trackings := `{"tracking_number": "1", "tag": "InTransit", "order_promised_delivery_date": "2022-11-12", "latest_estimated_delivery": {"type": "specific", "datetime": "2022-11-13"}},
+			{"tracking_number": "2", "tag": "InTransit", "order_promised_delivery_date": "2022-11-12"}`
+		if page == "2" {
+			trackings = `{"tracking_number": "3", "tag": "InTransit", "order_promised_delivery_date": "2022-11-01"}`
+		}
+		w.Write([]byte(fmt.Sprintf(`{"meta": {"code": 200}, "data": {"page": %s, "limit": 2, "count": 3, "trackings": [%s]}}`, page, trackings)))
+	})
+
+	evaluations, err := newTestPromiseEvaluator().AtRisk(context.Background(), client, GetTrackingsParams{Tag: TagInTransit})
+	assert.Nil(t, err)
+	assert.Len(t, evaluations, 2)
+	assert.Equal(t, "3", evaluations[0].Tracking.TrackingNumber)
+	assert.Equal(t, PromiseLate, evaluations[0].Status)
+	assert.Equal(t, 9, evaluations[0].Days)
+	assert.Equal(t, "1", evaluations[1].Tracking.TrackingNumber)
+	assert.Equal(t, PromiseAtRisk, evaluations[1].Status)
+}
@@ -0,0 +1,186 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/aftership/aftership-sdk-go/v2/checkdigit"
+	"github.com/pkg/errors"
+)
+
+// TrackingNumberPattern describes the tracking number format of a courier
+type TrackingNumberPattern struct {
+	Slug     string `json:"slug"`     // Unique code of courier
+	Name     string `json:"name"`     // Name of courier
+	Pattern  string `json:"pattern"`  // Regular expression matched against the upper case tracking number without spaces and dashes
+	Priority int    `json:"priority"` // Candidates are ranked by priority, highest first. Use high values for distinctive formats.
+}
+
+// DefaultTrackingNumberPatterns is the bundled table of tracking number formats of major couriers.
+// Formats shared by several couriers match all of them, leaving the result ambiguous.
+var DefaultTrackingNumberPatterns = []TrackingNumberPattern{
+	// UPS
+	{Slug: "ups", Name: "UPS", Pattern: `^1Z[0-9A-Z]{16}$`, Priority: 100},
+	{Slug: "ups", Name: "UPS", Pattern: `^T\d{10}$`, Priority: 60},
+
+	// USPS Intelligent Mail package barcode, with and without the routing ZIP code
+	{Slug: "usps", Name: "USPS", Pattern: `^(420\d{5}(\d{4})?)?9[1-5]\d{20}$`, Priority: 90},
+	{Slug: "usps", Name: "USPS", Pattern: `^(420\d{5}(\d{4})?)?9[1-5]\d{24}$`, Priority: 90},
+	{Slug: "usps", Name: "USPS", Pattern: `^82\d{8}$`, Priority: 30},
+
+	// FedEx Express, Ground and SmartPost
+	{Slug: "fedex", Name: "FedEx", Pattern: `^\d{12}$`, Priority: 50},
+	{Slug: "fedex", Name: "FedEx", Pattern: `^\d{15}$`, Priority: 60},
+	{Slug: "fedex", Name: "FedEx", Pattern: `^96\d{20}$`, Priority: 80},
+	{Slug: "fedex", Name: "FedEx", Pattern: `^(\d{20}|\d{34})$`, Priority: 40},
+
+	// DHL Express waybills and DHL eCommerce / Parcel
+	{Slug: "dhl", Name: "DHL Express", Pattern: `^\d{10}$`, Priority: 50},
+	{Slug: "dhl", Name: "DHL Express", Pattern: `^J{1,2}D\d{18}$`, Priority: 90},
+	{Slug: "dhl-germany", Name: "Deutsche Post DHL", Pattern: `^(\d{12}|\d{20})$`, Priority: 40},
+	{Slug: "dhl-global-mail", Name: "DHL eCommerce", Pattern: `^GM\d{16,18}$`, Priority: 90},
+
+	// UPU S10 international postal items, resolved by the issuing country suffix
+	{Slug: "usps", Name: "USPS", Pattern: `^[A-Z]{2}\d{9}US$`, Priority: 90},
+	{Slug: "royal-mail", Name: "Royal Mail", Pattern: `^[A-Z]{2}\d{9}GB$`, Priority: 90},
+	{Slug: "deutsch-post", Name: "Deutsche Post Mail", Pattern: `^[A-Z]{2}\d{9}DE$`, Priority: 90},
+	{Slug: "china-post", Name: "China Post", Pattern: `^[A-Z]{2}\d{9}CN$`, Priority: 80},
+	{Slug: "china-ems", Name: "China EMS (ePacket)", Pattern: `^E[A-Z]\d{9}CN$`, Priority: 85},
+	{Slug: "hong-kong-post", Name: "Hong Kong Post", Pattern: `^[A-Z]{2}\d{9}HK$`, Priority: 90},
+	{Slug: "canada-post", Name: "Canada Post", Pattern: `^[A-Z]{2}\d{9}CA$`, Priority: 90},
+	{Slug: "australia-post", Name: "Australia Post", Pattern: `^[A-Z]{2}\d{9}AU$`, Priority: 90},
+	{Slug: "la-poste-colissimo", Name: "La Poste", Pattern: `^[A-Z]{2}\d{9}FR$`, Priority: 90},
+	{Slug: "postnl-international", Name: "PostNL International", Pattern: `^[A-Z]{2}\d{9}NL$`, Priority: 90},
+	{Slug: "japan-post", Name: "Japan Post", Pattern: `^[A-Z]{2}\d{9}JP$`, Priority: 90},
+	{Slug: "singapore-post", Name: "Singapore Post", Pattern: `^[A-Z]{2}\d{9}SG$`, Priority: 90},
+
+	// Other national carriers
+	{Slug: "canada-post", Name: "Canada Post", Pattern: `^\d{16}$`, Priority: 40},
+	{Slug: "dpd", Name: "DPD", Pattern: `^\d{14}$`, Priority: 50},
+	{Slug: "ontrac", Name: "OnTrac", Pattern: `^[CD]\d{14}$`, Priority: 90},
+	{Slug: "lasership", Name: "LaserShip", Pattern: `^1LS\d{12,15}$`, Priority: 90},
+}
+
+// compiledPattern is a TrackingNumberPattern with its compiled regular expression
+type compiledPattern struct {
+	TrackingNumberPattern
+	re *regexp.Regexp
+}
+
+// OfflineDetector detects couriers from the tracking number format without calling the AfterShip API
+type OfflineDetector struct {
+	patterns []compiledPattern
+}
+
+// NewOfflineDetector compiles patterns into an OfflineDetector.
+// DefaultTrackingNumberPatterns is used when patterns is empty.
+func NewOfflineDetector(patterns []TrackingNumberPattern) (*OfflineDetector, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultTrackingNumberPatterns
+	}
+
+	detector := &OfflineDetector{
+		patterns: make([]compiledPattern, len(patterns)),
+	}
+	for i, pattern := range patterns {
+		if pattern.Slug == "" {
+			return nil, errors.Errorf("tracking number pattern %q has no slug", pattern.Pattern)
+		}
+
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tracking number pattern for %s", pattern.Slug)
+		}
+		detector.patterns[i] = compiledPattern{TrackingNumberPattern: pattern, re: re}
+	}
+
+	return detector, nil
+}
+
+// ReadTrackingNumberPatterns reads a JSON array of TrackingNumberPattern, e.g. an updated pattern table.
+func ReadTrackingNumberPatterns(r io.Reader) ([]TrackingNumberPattern, error) {
+	var patterns []TrackingNumberPattern
+	if err := json.NewDecoder(r).Decode(&patterns); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling tracking number patterns")
+	}
+	return patterns, nil
+}
+
+// Detect returns the couriers whose format matches trackingNumber, ranked by priority.
+// Each slug is listed once. The result is ambiguous when it holds more than one courier.
+func (d *OfflineDetector) Detect(trackingNumber string) CourierList {
+	normalized := checkdigit.Normalize(trackingNumber)
+
+	var matches []compiledPattern
+	seen := make(map[string]int)
+	for _, pattern := range d.patterns {
+		if !pattern.re.MatchString(normalized) {
+			continue
+		}
+
+		if i, ok := seen[pattern.Slug]; ok {
+			if pattern.Priority > matches[i].Priority {
+				matches[i] = pattern
+			}
+			continue
+		}
+		seen[pattern.Slug] = len(matches)
+		matches = append(matches, pattern)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Priority > matches[j].Priority
+	})
+
+	couriers := make([]Courier, len(matches))
+	for i, match := range matches {
+		couriers[i] = Courier{Slug: match.Slug, Name: match.Name}
+	}
+
+	return CourierList{
+		Total:    len(couriers),
+		Couriers: couriers,
+	}
+}
+
+// DetectCouriers detects couriers offline and only calls the DetectCouriers method of api when the result is ambiguous,
+// that is when no courier or more than one courier matches. Offline candidates are limited to params.Slug when given.
+func (d *OfflineDetector) DetectCouriers(ctx context.Context, api CouriersAPI, params CourierDetectionParams) (CourierList, error) {
+	if params.TrackingNumber == "" {
+		return CourierList{}, errors.New(errMissingTrackingNumber)
+	}
+
+	list := d.Detect(params.TrackingNumber)
+	if len(params.Slug) > 0 {
+		list = filterCourierList(list, params.Slug)
+	}
+
+	if list.Total == 1 {
+		return list, nil
+	}
+
+	return api.DetectCouriers(ctx, params)
+}
+
+// filterCourierList keeps the couriers of list with one of slugs
+func filterCourierList(list CourierList, slugs []string) CourierList {
+	allowed := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		allowed[slug] = true
+	}
+
+	var couriers []Courier
+	for _, courier := range list.Couriers {
+		if allowed[courier.Slug] {
+			couriers = append(couriers, courier)
+		}
+	}
+
+	return CourierList{
+		Total:    len(couriers),
+		Couriers: couriers,
+	}
+}
@@ -0,0 +1,121 @@
+package aftership
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func detectedSlugs(list CourierList) []string {
+	var slugs []string
+	for _, courier := range list.Couriers {
+		slugs = append(slugs, courier.Slug)
+	}
+	return slugs
+}
+
+func TestOfflineDetectorDetect(t *testing.T) {
+	detector, err := NewOfflineDetector(nil)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		trackingNumber string
+		slugs          []string
+	}{
+		{"1Z999AA10123456784", []string{"ups"}},
+		{"1z 999 aa1 0123 4567 84", []string{"ups"}},
+		{"9400111899223197428490", []string{"usps"}},
+		{"420921559505500020804701099999", []string{"usps"}},
+		{"RA123456785GB", []string{"royal-mail"}},
+		{"LS404494276CN", []string{"china-post"}},
+		{"EA123456785CN", []string{"china-ems", "china-post"}},
+		{"JJD000390007811587474", []string{"dhl"}},
+		{"1234567890", []string{"dhl"}},
+		{"123456789012", []string{"fedex", "dhl-germany"}},
+		{"96123456789012345678901", nil},
+		{"9612345678901234567890", []string{"fedex"}},
+		{"not a tracking number", nil},
+	}
+
+	for _, c := range cases {
+		list := detector.Detect(c.trackingNumber)
+		assert.Equal(t, c.slugs, detectedSlugs(list), c.trackingNumber)
+		assert.Equal(t, len(c.slugs), list.Total, c.trackingNumber)
+	}
+}
+
+func TestNewOfflineDetectorError(t *testing.T) {
+	_, err := NewOfflineDetector([]TrackingNumberPattern{{Slug: "ups", Pattern: "("}})
+	assert.NotNil(t, err)
+
+	_, err = NewOfflineDetector([]TrackingNumberPattern{{Pattern: "^1Z"}})
+	assert.NotNil(t, err)
+}
+
+func TestReadTrackingNumberPatterns(t *testing.T) {
+	patterns, err := ReadTrackingNumberPatterns(strings.NewReader(`[
+		{"slug": "acme", "name": "ACME", "pattern": "^AC\\d{8}$", "priority": 10},
+		{"slug": "acme-express", "name": "ACME Express", "pattern": "^AC\\d{8}$", "priority": 20}
+	]`))
+	assert.Nil(t, err)
+
+	detector, err := NewOfflineDetector(patterns)
+	assert.Nil(t, err)
+	assert.Equal(t, CourierList{
+		Total: 2,
+		Couriers: []Courier{
+			{Slug: "acme-express", Name: "ACME Express"},
+			{Slug: "acme", Name: "ACME"},
+		},
+	}, detector.Detect("AC12345678"))
+
+	_, err = ReadTrackingNumberPatterns(strings.NewReader(`{}`))
+	assert.NotNil(t, err)
+}
+
+func TestOfflineDetectorDetectCouriers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/couriers/detect", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		calls++
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"total": 1,
+					"couriers": [{"slug": "fedex", "name": "FedEx"}]
+			}
+		}`))
+	})
+
+	detector, _ := NewOfflineDetector(nil)
+	ctx := context.Background()
+
+	// unambiguous, answered offline
+	list, err := detector.DetectCouriers(ctx, client, CourierDetectionParams{TrackingNumber: "1Z999AA10123456784"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ups"}, detectedSlugs(list))
+	assert.Equal(t, 0, calls)
+
+	// ambiguous, narrowed down by the requested slugs
+	list, err = detector.DetectCouriers(ctx, client, CourierDetectionParams{TrackingNumber: "123456789012", Slug: []string{"dhl-germany"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dhl-germany"}, detectedSlugs(list))
+	assert.Equal(t, 0, calls)
+
+	// ambiguous, falls back to the API
+	list, err = detector.DetectCouriers(ctx, client, CourierDetectionParams{TrackingNumber: "123456789012"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fedex"}, detectedSlugs(list))
+	assert.Equal(t, 1, calls)
+
+	_, err = detector.DetectCouriers(ctx, client, CourierDetectionParams{})
+	assert.NotNil(t, err)
+}
@@ -2,6 +2,8 @@ package aftership
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 )
 
 // Error messages
@@ -12,6 +14,10 @@ const (
 	errMissingTrackingID           = "tracking id is empty and must be provided"
 	errMissingSlugOrTrackingNumber = "slug or tracking number is empty, both of them must be provided"
 	errExceedRateLimt              = "rate limit is exceeded, please wait util %s"
+	errMissingRequiredField        = "required by the courier and must be provided"
+	errInvalidShipDate             = "must be a valid date in YYYYMMDD format"
+	errInvalidAPIVersion           = "invalid API version %q, use v4 or a date version such as 2024-07"
+	errEmptyCourierRegistry        = "courier registry is empty and could not be loaded"
 )
 
 // APIError is the error in AfterShip API calls
@@ -39,3 +45,41 @@ func (e *TooManyRequestsError) Error() string {
 	ret, _ := json.Marshal(e)
 	return string(ret)
 }
+
+// FieldError describes a single invalid field found before calling the AfterShip API
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when params are rejected locally, before any API call is made
+type ValidationError struct {
+	Slug   string       `json:"slug,omitempty"`
+	Fields []FieldError `json:"fields"`
+}
+
+// Error serializes the error object to JSON and returns it as a string.
+func (e *ValidationError) Error() string {
+	ret, _ := json.Marshal(e)
+	return string(ret)
+}
+
+// StaleResponseError is returned by GetTracking, GetTrackings, GetLastCheckpoint and GetCouriers
+// when StaleIfError is set and the API is unavailable or rate limited. The result of the call is then
+// the last successful response, received Age ago, instead of the zero value.
+type StaleResponseError struct {
+	Path     string        `json:"path"`
+	StoredAt time.Time     `json:"stored_at"` // When the stale response was received
+	Age      time.Duration `json:"age"`       // How old the stale response was when returned
+	Err      error         `json:"-"`         // The error of the API call
+}
+
+// Error returns the error of the API call, and the age of the stale response.
+func (e *StaleResponseError) Error() string {
+	return fmt.Sprintf("stale response of %s from %s ago: %v", e.Path, e.Age.Round(time.Second), e.Err)
+}
+
+// Unwrap returns the error of the API call
+func (e *StaleResponseError) Unwrap() error {
+	return e.Err
+}
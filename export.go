@@ -0,0 +1,370 @@
+package aftership
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Column prefixes for the nested data flattened by the tracking writers
+const (
+	ExportPrefixAdditionalFields        = "additional_fields."
+	ExportPrefixLatestEstimatedDelivery = "latest_estimated_delivery."
+	ExportPrefixLastCheckpoint          = "last_checkpoint."
+	ExportPrefixCustomFields            = "custom_fields."
+)
+
+// DefaultExportColumns is the column set used by the tracking writers when no columns are given
+var DefaultExportColumns = []string{
+	"id",
+	"tracking_number",
+	"slug",
+	"tag",
+	"subtag",
+	"title",
+	"order_id",
+	"order_number",
+	"origin_country_iso3",
+	"destination_country_iso3",
+	"shipment_type",
+	"shipment_pickup_date",
+	"shipment_delivery_date",
+	"order_promised_delivery_date",
+	"additional_fields.tracking_postal_code",
+	"additional_fields.tracking_ship_date",
+	"latest_estimated_delivery.type",
+	"latest_estimated_delivery.datetime",
+	"latest_estimated_delivery.datetime_min",
+	"latest_estimated_delivery.datetime_max",
+	"last_checkpoint.checkpoint_time",
+	"last_checkpoint.tag",
+	"last_checkpoint.message",
+	"last_checkpoint.location",
+	"created_at",
+	"updated_at",
+}
+
+// TrackingWriter streams Tracking values into an export format, one record per tracking
+type TrackingWriter interface {
+	// Write writes a single tracking as one record.
+	Write(tracking Tracking) error
+
+	// Flush writes any buffered data to the underlying io.Writer.
+	Flush() error
+}
+
+// exportColumn resolves the value of a single column from a tracking
+type exportColumn func(tracking *Tracking) string
+
+// CSVTrackingWriter writes trackings as CSV records, after a header line written even when there are no trackings
+type CSVTrackingWriter struct {
+	w           *csv.Writer
+	columns     []string
+	extractors  []exportColumn
+	wroteHeader bool
+}
+
+// NewCSVTrackingWriter returns a CSVTrackingWriter writing the given columns to w.
+// DefaultExportColumns is used when columns is empty.
+func NewCSVTrackingWriter(w io.Writer, columns []string) (*CSVTrackingWriter, error) {
+	columns, extractors, err := resolveExportColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CSVTrackingWriter{
+		w:          csv.NewWriter(w),
+		columns:    columns,
+		extractors: extractors,
+	}, nil
+}
+
+// Write writes a single tracking as one CSV record.
+func (cw *CSVTrackingWriter) Write(tracking Tracking) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(cw.extractors))
+	for i, extract := range cw.extractors {
+		record[i] = extract(&tracking)
+	}
+
+	if err := cw.w.Write(record); err != nil {
+		return errors.Wrap(err, "error writing CSV record")
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying io.Writer, and the header line when nothing was written.
+func (cw *CSVTrackingWriter) Flush() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// writeHeader writes the header line once
+func (cw *CSVTrackingWriter) writeHeader() error {
+	if cw.wroteHeader {
+		return nil
+	}
+	if err := cw.w.Write(cw.columns); err != nil {
+		return errors.Wrap(err, "error writing CSV header")
+	}
+	cw.wroteHeader = true
+	return nil
+}
+
+// JSONLTrackingWriter writes trackings as flat JSON objects, one per line
+type JSONLTrackingWriter struct {
+	w          io.Writer
+	keys       [][]byte
+	extractors []exportColumn
+	buf        bytes.Buffer
+}
+
+// NewJSONLTrackingWriter returns a JSONLTrackingWriter writing the given columns to w.
+// DefaultExportColumns is used when columns is empty.
+func NewJSONLTrackingWriter(w io.Writer, columns []string) (*JSONLTrackingWriter, error) {
+	columns, extractors, err := resolveExportColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]byte, len(columns))
+	for i, column := range columns {
+		keys[i], _ = json.Marshal(column)
+	}
+
+	return &JSONLTrackingWriter{
+		w:          w,
+		keys:       keys,
+		extractors: extractors,
+	}, nil
+}
+
+// Write writes a single tracking as one JSON line. Keys keep the configured column order.
+func (jw *JSONLTrackingWriter) Write(tracking Tracking) error {
+	jw.buf.Reset()
+	jw.buf.WriteByte('{')
+	for i, extract := range jw.extractors {
+		if i > 0 {
+			jw.buf.WriteByte(',')
+		}
+		value, _ := json.Marshal(extract(&tracking))
+		jw.buf.Write(jw.keys[i])
+		jw.buf.WriteByte(':')
+		jw.buf.Write(value)
+	}
+	jw.buf.WriteString("}\n")
+
+	if _, err := jw.w.Write(jw.buf.Bytes()); err != nil {
+		return errors.Wrap(err, "error writing JSON line")
+	}
+	return nil
+}
+
+// Flush is a no-op, every line is written to the underlying io.Writer as is.
+func (jw *JSONLTrackingWriter) Flush() error {
+	return nil
+}
+
+// ExportTrackings pages through the GetTrackings of api with params and writes every tracking to w.
+// Only one page is held in memory at a time. It returns the number of trackings written.
+func ExportTrackings(ctx context.Context, api TrackingsAPI, params GetTrackingsParams, w TrackingWriter) (int, error) {
+	count := 0
+	err := WalkTrackings(ctx, api, params, func(tracking Tracking) error {
+		if err := w.Write(tracking); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, w.Flush()
+}
+
+// WalkTrackings calls fn for every tracking of api matched by params, fetching one page at a time from params.Page,
+// or following the cursors from params.Cursor with the date-versioned APIs. It stops after the first page short of
+// the limit or without a next cursor, and at the first error returned by GetTrackings or fn.
+func WalkTrackings(ctx context.Context, api TrackingsAPI, params GetTrackingsParams, fn func(Tracking) error) error {
+	dated := apiVersionOf(api).dated()
+	if !dated && params.Page <= 0 {
+		params.Page = 1
+	}
+
+	for {
+		paged, err := api.GetTrackings(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		for _, tracking := range paged.Trackings {
+			if err := fn(tracking); err != nil {
+				return err
+			}
+		}
+
+		if dated || paged.HasNextPage || paged.NextCursor != "" {
+			if !paged.HasNextPage || paged.NextCursor == "" {
+				return nil
+			}
+			params.Cursor = paged.NextCursor
+			continue
+		}
+
+		// Count is capped at 10,000: the last page is the first one short of the limit
+		limit := paged.Limit
+		if limit <= 0 {
+			limit = params.Limit
+		}
+		if len(paged.Trackings) == 0 || len(paged.Trackings) < limit {
+			return nil
+		}
+		params.Page++
+	}
+}
+
+// resolveExportColumns maps every column name to its extractor
+func resolveExportColumns(columns []string) ([]string, []exportColumn, error) {
+	if len(columns) == 0 {
+		columns = DefaultExportColumns
+	}
+
+	extractors := make([]exportColumn, len(columns))
+	for i, column := range columns {
+		extract, err := resolveExportColumn(column)
+		if err != nil {
+			return nil, nil, err
+		}
+		extractors[i] = extract
+	}
+
+	return columns, extractors, nil
+}
+
+// additionalFieldIndex is the index of the AdditionalField embedded in Tracking, whose fields use ExportPrefixAdditionalFields
+var additionalFieldIndex = func() []int {
+	field, _ := reflect.TypeOf(Tracking{}).FieldByName("AdditionalField")
+	return field.Index
+}()
+
+func resolveExportColumn(column string) (exportColumn, error) {
+	switch {
+	case strings.HasPrefix(column, ExportPrefixAdditionalFields):
+		index, ok := jsonFieldIndex(reflect.TypeOf(AdditionalField{}), strings.TrimPrefix(column, ExportPrefixAdditionalFields))
+		if !ok {
+			break
+		}
+		index = append(append([]int{}, additionalFieldIndex...), index...)
+		return func(tracking *Tracking) string {
+			return formatExportValue(reflect.ValueOf(tracking).Elem().FieldByIndex(index))
+		}, nil
+	case strings.HasPrefix(column, ExportPrefixCustomFields):
+		key := strings.TrimPrefix(column, ExportPrefixCustomFields)
+		return func(tracking *Tracking) string {
+			return tracking.CustomFields[key]
+		}, nil
+	case strings.HasPrefix(column, ExportPrefixLatestEstimatedDelivery):
+		index, ok := jsonFieldIndex(reflect.TypeOf(LatestEstimatedDelivery{}), strings.TrimPrefix(column, ExportPrefixLatestEstimatedDelivery))
+		if !ok {
+			break
+		}
+		return func(tracking *Tracking) string {
+			return formatExportValue(reflect.ValueOf(tracking.LatestEstimatedDelivery).FieldByIndex(index))
+		}, nil
+	case strings.HasPrefix(column, ExportPrefixLastCheckpoint):
+		index, ok := jsonFieldIndex(reflect.TypeOf(Checkpoint{}), strings.TrimPrefix(column, ExportPrefixLastCheckpoint))
+		if !ok {
+			break
+		}
+		return func(tracking *Tracking) string {
+			if len(tracking.Checkpoints) == 0 {
+				return ""
+			}
+			checkpoint := tracking.Checkpoints[len(tracking.Checkpoints)-1]
+			return formatExportValue(reflect.ValueOf(checkpoint).FieldByIndex(index))
+		}, nil
+	default:
+		index, ok := jsonFieldIndex(reflect.TypeOf(Tracking{}), column)
+		if !ok || index[0] == additionalFieldIndex[0] {
+			break
+		}
+		return func(tracking *Tracking) string {
+			return formatExportValue(reflect.ValueOf(tracking).Elem().FieldByIndex(index))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown export column %q", column)
+}
+
+// jsonFieldIndex finds the scalar field of t tagged with the JSON name, including fields of embedded structs
+func jsonFieldIndex(t reflect.Type, name string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if index, ok := jsonFieldIndex(field.Type, name); ok {
+				return append([]int{i}, index...), true
+			}
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag != name {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct, reflect.Map:
+			return nil, false
+		case reflect.Slice:
+			if field.Type.Elem().Kind() == reflect.Struct {
+				return nil, false
+			}
+		}
+		return []int{i}, true
+	}
+	return nil, false
+}
+
+func formatExportValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = formatExportValue(v.Index(i))
+		}
+		return strings.Join(values, ",")
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		if t, ok := v.Interface().(*time.Time); ok {
+			return t.Format(time.RFC3339)
+		}
+		return formatExportValue(v.Elem())
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package aftership_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func ExampleExportTrackings() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Export delivered trackings as CSV
+	w, err := aftership.NewCSVTrackingWriter(os.Stdout, []string{
+		"tracking_number",
+		"slug",
+		"shipment_delivery_date",
+		"last_checkpoint.location",
+		"custom_fields.product_name",
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	count, err := aftership.ExportTrackings(context.Background(), cli, aftership.GetTrackingsParams{
+		Tag:   "Delivered",
+		Limit: 200,
+	}, w)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(count)
+}
@@ -0,0 +1,175 @@
+package aftership
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportTestTracking() Tracking {
+	createdAt := time.Date(2018, 8, 17, 6, 25, 32, 0, time.UTC)
+	return Tracking{
+		ID:             "5b7658cec7c33c0e007de3c5",
+		CreatedAt:      &createdAt,
+		TrackingNumber: "1234567890",
+		Slug:           "deutsch-post",
+		Active:         true,
+		Tag:            "InTransit",
+		ShipmentTags:   []string{"a", "b"},
+		CustomFields: map[string]string{
+			"product_name": "iPhone Case, \"blue\"",
+		},
+		AdditionalField: AdditionalField{
+			TrackingPostalCode: "10115",
+		},
+		LatestEstimatedDelivery: LatestEstimatedDelivery{
+			Type:        "range",
+			DatetimeMin: "2022-11-20",
+			DatetimeMax: "2022-11-22",
+		},
+		Checkpoints: []Checkpoint{
+			{Tag: "InfoReceived", Message: "Shipment information received"},
+			{Tag: "InTransit", Message: "Arrived at hub", Location: "Leipzig"},
+		},
+	}
+}
+
+func TestCSVTrackingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVTrackingWriter(&buf, []string{
+		"id", "tracking_number", "active", "shipment_tags", "created_at", "additional_fields.tracking_postal_code",
+		"latest_estimated_delivery.datetime_max", "last_checkpoint.location",
+		"custom_fields.product_name", "custom_fields.missing",
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.Write(exportTestTracking()))
+	assert.Nil(t, w.Write(Tracking{TrackingNumber: "2"}))
+	assert.Nil(t, w.Flush())
+
+	exp := "id,tracking_number,active,shipment_tags,created_at,additional_fields.tracking_postal_code," +
+		"latest_estimated_delivery.datetime_max,last_checkpoint.location," +
+		"custom_fields.product_name,custom_fields.missing\n" +
+		"5b7658cec7c33c0e007de3c5,1234567890,true,\"a,b\",2018-08-17T06:25:32Z,10115," +
+		"2022-11-22,Leipzig,\"iPhone Case, \"\"blue\"\"\",\n" +
+		",2,false,,,,,,,\n"
+	assert.Equal(t, exp, buf.String())
+}
+
+func TestCSVTrackingWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVTrackingWriter(&buf, []string{"id", "tracking_number"})
+	assert.Nil(t, err)
+
+	// An export without trackings still has its header, once
+	assert.Nil(t, w.Flush())
+	assert.Nil(t, w.Flush())
+	assert.Equal(t, "id,tracking_number\n", buf.String())
+}
+
+func TestTrackingWriterAdditionalFields(t *testing.T) {
+	tracking := exportTestTracking()
+	tracking.AdditionalField.TrackingKey = "K123"
+
+	// The fields of AdditionalField are prefixed, and not found without the prefix
+	var buf bytes.Buffer
+	w, err := NewJSONLTrackingWriter(&buf, []string{"slug", "additional_fields.tracking_key", "additional_fields.tracking_postal_code"})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(tracking))
+	_, err = NewJSONLTrackingWriter(&buf, []string{"tracking_key"})
+	assert.NotNil(t, err)
+
+	exp := `{"slug":"deutsch-post","additional_fields.tracking_key":"K123","additional_fields.tracking_postal_code":"10115"}` + "\n"
+	assert.Equal(t, exp, buf.String())
+}
+
+func TestJSONLTrackingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewJSONLTrackingWriter(&buf, []string{
+		"tracking_number", "slug", "last_checkpoint.message", "custom_fields.product_name",
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.Write(exportTestTracking()))
+	assert.Nil(t, w.Flush())
+
+	exp := `{"tracking_number":"1234567890","slug":"deutsch-post",` +
+		`"last_checkpoint.message":"Arrived at hub","custom_fields.product_name":"iPhone Case, \"blue\""}` + "\n"
+	assert.Equal(t, exp, buf.String())
+}
+
+func TestTrackingWriterDefaultColumns(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVTrackingWriter(&buf, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultExportColumns, w.columns)
+}
+
+func TestTrackingWriterUnknownColumn(t *testing.T) {
+	var buf bytes.Buffer
+	for _, column := range []string{"unknown", "checkpoints", "custom_fields", "latest_estimated_delivery", "last_checkpoint.unknown",
+		"tracking_postal_code", "additional_fields.unknown"} {
+		_, err := NewCSVTrackingWriter(&buf, []string{column})
+		assert.NotNil(t, err, column)
+
+		_, err = NewJSONLTrackingWriter(&buf, []string{column})
+		assert.NotNil(t, err, column)
+	}
+}
+
+func TestExportTrackings(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// The count is capped below the number of trackings, as it is at 10,000 by the API
+	var pages []string
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		trackings := fmt.Sprintf(`{"tracking_number": "page%s-1"}, {"tracking_number": "page%s-2"}`, page, page)
+		if page == "3" {
+			trackings = `{"tracking_number": "page3-1"}`
+		}
+		w.Write([]byte(fmt.Sprintf(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"page": %s,
+					"limit": 2,
+					"count": 3,
+					"trackings": [%s]
+			}
+		}`, page, trackings)))
+	})
+
+	var buf bytes.Buffer
+	w, _ := NewCSVTrackingWriter(&buf, []string{"tracking_number"})
+	count, err := ExportTrackings(context.Background(), client, GetTrackingsParams{Limit: 2}, w)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, []string{"1", "2", "3"}, pages)
+	assert.Equal(t, "tracking_number\npage1-1\npage1-2\npage2-1\npage2-2\npage3-1\n", buf.String())
+}
+
+func TestExportTrackingsError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"meta": {"code": 500, "type": "InternalError", "message": "Something went wrong on AfterShip's end."}, "data": {}}`))
+	})
+
+	var buf bytes.Buffer
+	w, _ := NewJSONLTrackingWriter(&buf, nil)
+	count, err := ExportTrackings(context.Background(), client, GetTrackingsParams{}, w)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,162 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileStore is a Store keeping every tracking in a JSON file of a directory, to survive restarts.
+// The directory must not be shared by several processes.
+type FileStore struct {
+	mu    sync.Mutex
+	dir   string
+	index *storeIndex
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore returns a FileStore in dir, created if needed, and loads the index of the trackings it holds.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "error creating store directory")
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing store directory")
+	}
+
+	s := &FileStore{dir: dir, index: newStoreIndex()}
+	for _, name := range names {
+		stored, err := readStoredTracking(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, err := s.index.add(stored.Tracking); err != nil {
+			return nil, errors.Wrapf(err, "invalid tracking in %s", name)
+		}
+	}
+	return s, nil
+}
+
+// Get returns the stored tracking of identifier, and false when there is none
+func (s *FileStore) Get(ctx context.Context, identifier TrackingIdentifier) (StoredTracking, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	primary, ok, err := s.index.lookup(identifier)
+	if !ok || err != nil {
+		return StoredTracking{}, false, err
+	}
+	stored, err := readStoredTracking(s.path(primary))
+	if err != nil {
+		return StoredTracking{}, false, err
+	}
+	return stored, true, nil
+}
+
+// Put stores a tracking, replacing its file atomically. The index is only updated once the file is written,
+// so a failed write leaves the previous tracking, if any, in the store.
+func (s *FileStore) Put(ctx context.Context, stored StoredTracking) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := trackingKeys(stored.Tracking)
+	if err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling tracking")
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, ".tracking-*")
+	if err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "error storing tracking")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+	if err := os.Rename(tmp.Name(), s.path(keys[0])); err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+
+	_, replaced, err := s.index.add(stored.Tracking)
+	if err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+	for _, key := range replaced {
+		if err := removeFile(s.path(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the tracking of identifier, if any
+func (s *FileStore) Delete(ctx context.Context, identifier TrackingIdentifier) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	primary, ok, err := s.index.lookup(identifier)
+	if !ok || err != nil {
+		return err
+	}
+	s.index.remove(primary)
+	return removeFile(s.path(primary))
+}
+
+// ListByOrderID returns the stored trackings of an order
+func (s *FileStore) ListByOrderID(ctx context.Context, orderID string) ([]StoredTracking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var trackings []StoredTracking
+	for _, primary := range s.index.list(orderID) {
+		stored, err := readStoredTracking(s.path(primary))
+		if err != nil {
+			return nil, err
+		}
+		trackings = append(trackings, stored)
+	}
+	return trackings, nil
+}
+
+// path returns the file of a primary key
+func (s *FileStore) path(primary string) string {
+	return filepath.Join(s.dir, url.QueryEscape(strings.TrimPrefix(primary, "/"))+".json")
+}
+
+func readStoredTracking(name string) (StoredTracking, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return StoredTracking{}, errors.Wrap(err, "error reading stored tracking")
+	}
+
+	var stored StoredTracking
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return StoredTracking{}, errors.Wrapf(err, "error unmarshalling stored tracking %s", name)
+	}
+	return stored, nil
+}
+
+func removeFile(name string) error {
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "error deleting stored tracking")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package aftership
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NotificationChanges is the result of SetNotification
+type NotificationChanges struct {
+	Added        Notification `json:"added"`        // Receivers added to the tracking
+	Removed      Notification `json:"removed"`      // Receivers removed from the tracking
+	Notification Notification `json:"notification"` // Receivers of the tracking after the changes
+}
+
+// Changed reports whether any receiver was added or removed.
+func (changes NotificationChanges) Changed() bool {
+	return !changes.Added.isEmpty() || !changes.Removed.isEmpty()
+}
+
+func (notification Notification) isEmpty() bool {
+	return len(notification.Emails) == 0 && len(notification.SMSes) == 0 &&
+		len(notification.IOS) == 0 && len(notification.Android) == 0
+}
+
+// SetNotification makes the receivers of a tracking equal to desired. It fetches the current receivers,
+// adds the missing ones and removes the extra ones, calling the API only for non-empty changes.
+// Emails are compared case-insensitively, phone numbers without formatting and push device IDs exactly,
+// so equivalent entries are kept as is.
+// Receivers are added before they are removed, so a failure never leaves the tracking with fewer receivers than asked for.
+func (client *Client) SetNotification(ctx context.Context, identifier TrackingIdentifier, desired Notification) (NotificationChanges, error) {
+	emails, smses, err := client.normalizeContacts(desired.Emails, desired.SMSes)
+	if err != nil {
+		return NotificationChanges{}, err
+	}
+	desired.Emails, desired.SMSes = emails, smses
+
+	current, err := client.GetNotification(ctx, identifier)
+	if err != nil {
+		return NotificationChanges{}, errors.Wrap(err, "error setting notification")
+	}
+
+	changes := NotificationChanges{
+		Added: Notification{
+			Emails:  missingContacts(desired.Emails, current.Emails, emailKey),
+			SMSes:   missingContacts(desired.SMSes, current.SMSes, phoneNumberKey),
+			IOS:     missingContacts(desired.IOS, current.IOS, strings.TrimSpace),
+			Android: missingContacts(desired.Android, current.Android, strings.TrimSpace),
+		},
+		Removed: Notification{
+			Emails:  missingContacts(current.Emails, desired.Emails, emailKey),
+			SMSes:   missingContacts(current.SMSes, desired.SMSes, phoneNumberKey),
+			IOS:     missingContacts(current.IOS, desired.IOS, strings.TrimSpace),
+			Android: missingContacts(current.Android, desired.Android, strings.TrimSpace),
+		},
+		Notification: current,
+	}
+
+	if !changes.Added.isEmpty() {
+		changes.Notification, err = client.AddNotification(ctx, identifier, changes.Added)
+		if err != nil {
+			return NotificationChanges{Notification: current}, errors.Wrap(err, "error setting notification")
+		}
+	}
+
+	if !changes.Removed.isEmpty() {
+		changes.Notification, err = client.RemoveNotification(ctx, identifier, changes.Removed)
+		if err != nil {
+			changes.Removed = Notification{}
+			return changes, errors.Wrap(err, "error setting notification")
+		}
+	}
+
+	return changes, nil
+}
+
+// missingContacts returns the entries of contacts without an equivalent entry in existing.
+// Entries are compared by their normalized form and returned as given, without duplicates.
+func missingContacts(contacts, existing []string, normalize func(string) string) []string {
+	seen := make(map[string]bool, len(existing)+len(contacts))
+	for _, contact := range existing {
+		seen[normalize(contact)] = true
+	}
+
+	var missing []string
+	for _, contact := range contacts {
+		key := normalize(contact)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		missing = append(missing, strings.TrimSpace(contact))
+	}
+	return missing
+}
+
+// emailKey lower cases email and trims surrounding spaces, for comparison
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// phoneNumberKey strips the formatting from a phone number for comparison,
+// e.g. "+852 9123-9123" and "00852 (9123) 9123" both become "+85291239123"
+func phoneNumberKey(number string) string {
+	number = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r == '+':
+			return r
+		}
+		return -1
+	}, number)
+
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	return number
+}
@@ -0,0 +1,201 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetNotification(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := SlugTrackingNumber{
+		Slug:           "xq-express",
+		TrackingNumber: "LS404494276CN",
+	}
+
+	uri := fmt.Sprintf("/notifications/%s/%s", p.Slug, p.TrackingNumber)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"notification": {
+							"emails": ["User1@Gmail.com", "user2@gmail.com"],
+							"smses": ["+85291239123", "+85261236123"]
+					}
+			}
+		}`))
+	})
+
+	var added, removed notificationWrapper
+	mux.HandleFunc(uri+"/add", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		json.NewDecoder(r.Body).Decode(&added)
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"notification": {
+							"emails": ["User1@Gmail.com", "user2@gmail.com", "user3@gmail.com"],
+							"smses": ["+85291239123", "+85261236123"]
+					}
+			}
+		}`))
+	})
+	mux.HandleFunc(uri+"/remove", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		json.NewDecoder(r.Body).Decode(&removed)
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"notification": {
+							"emails": ["User1@Gmail.com", "user3@gmail.com"],
+							"smses": ["+85291239123"]
+					}
+			}
+		}`))
+	})
+
+	desired := Notification{
+		Emails: []string{" user1@gmail.com", "user3@gmail.com", "USER3@gmail.com"},
+		SMSes:  []string{"+852 9123-9123"},
+	}
+
+	changes, err := client.SetNotification(context.Background(), p, desired)
+	assert.Nil(t, err)
+	assert.True(t, changes.Changed())
+	assert.Equal(t, Notification{Emails: []string{"user3@gmail.com"}}, changes.Added)
+	assert.Equal(t, Notification{Emails: []string{"user2@gmail.com"}, SMSes: []string{"+85261236123"}}, changes.Removed)
+	assert.Equal(t, changes.Added, added.Notification)
+	assert.Equal(t, changes.Removed, removed.Notification)
+	assert.Equal(t, Notification{
+		Emails: []string{"User1@Gmail.com", "user3@gmail.com"},
+		SMSes:  []string{"+85291239123"},
+	}, changes.Notification)
+}
+
+func TestSetNotificationUnchanged(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := SlugTrackingNumber{
+		Slug:           "xq-express",
+		TrackingNumber: "LS404494276CN",
+	}
+
+	calls := 0
+	uri := fmt.Sprintf("/notifications/%s/%s", p.Slug, p.TrackingNumber)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"notification": {
+							"emails": ["user1@gmail.com"],
+							"smses": ["+85291239123"]
+					}
+			}
+		}`))
+	})
+	mux.HandleFunc(uri+"/", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	changes, err := client.SetNotification(context.Background(), p, Notification{
+		Emails: []string{"USER1@gmail.com"},
+		SMSes:  []string{"00852 9123 9123"},
+	})
+	assert.Nil(t, err)
+	assert.False(t, changes.Changed())
+	assert.Equal(t, 1, calls)
+}
+
+func TestSetNotificationDevices(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := TrackingID("5b74f4958776db0e00b6f5ed")
+
+	mux.HandleFunc("/notifications/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"meta": {
+					"code": 200
+			},
+			"data": {
+					"notification": {
+							"emails": [],
+							"smses": [],
+							"ios": ["cec7c33c0e007de3c9"],
+							"android": []
+					}
+			}
+		}`))
+	})
+
+	var added, removed notificationWrapper
+	mux.HandleFunc("/notifications/5b74f4958776db0e00b6f5ed/add", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&added)
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"notification": {"emails": [], "smses": [], "ios": ["cec7c33c0e007de3c9"], "android": ["5b766a5cc7c33c0e"]}}}`))
+	})
+	mux.HandleFunc("/notifications/5b74f4958776db0e00b6f5ed/remove", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&removed)
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"notification": {"emails": [], "smses": [], "ios": [], "android": ["5b766a5cc7c33c0e"]}}}`))
+	})
+
+	changes, err := client.SetNotification(context.Background(), p, Notification{
+		Android: []string{"5b766a5cc7c33c0e"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, Notification{Android: []string{"5b766a5cc7c33c0e"}}, added.Notification)
+	assert.Equal(t, Notification{IOS: []string{"cec7c33c0e007de3c9"}}, removed.Notification)
+	assert.Equal(t, []string{"5b766a5cc7c33c0e"}, changes.Notification.Android)
+	assert.Empty(t, changes.Notification.IOS)
+}
+
+func TestSetNotificationError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := SlugTrackingNumber{
+		Slug:           "xq-express",
+		TrackingNumber: "LS404494276CN",
+	}
+
+	uri := fmt.Sprintf("/notifications/%s/%s", p.Slug, p.TrackingNumber)
+	mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"notification": {"emails": ["user1@gmail.com"], "smses": []}}}`))
+	})
+	mux.HandleFunc(uri+"/add", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"meta": {"code": 4001, "type": "BadRequest", "message": "Invalid JSON data."}, "data": {}}`))
+	})
+
+	changes, err := client.SetNotification(context.Background(), p, Notification{
+		Emails: []string{"user2@gmail.com"},
+	})
+	assert.NotNil(t, err)
+	assert.False(t, changes.Changed())
+	assert.Equal(t, []string{"user1@gmail.com"}, changes.Notification.Emails)
+
+	_, err = client.SetNotification(context.Background(), SlugTrackingNumber{}, Notification{})
+	assert.NotNil(t, err)
+}
+
+func TestPhoneNumberKey(t *testing.T) {
+	assert.Equal(t, "+85291239123", phoneNumberKey("+852 9123-9123"))
+	assert.Equal(t, "+85291239123", phoneNumberKey("00852 (9123) 9123"))
+	assert.Equal(t, "", phoneNumberKey("Invalid Mobile Phone Number"))
+}
@@ -4,14 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-
-	"github.com/pkg/errors"
 )
 
 // Notification is the model describing an AfterShip notification
 type Notification struct {
-	Emails []string `json:"emails"`
-	SMSes  []string `json:"smses"`
+	Emails  []string `json:"emails"`
+	SMSes   []string `json:"smses"`
+	IOS     []string `json:"ios,omitempty"`     // Apple iOS device IDs to receive the push notifications.
+	Android []string `json:"android,omitempty"` // Google cloud message registration IDs to receive the push notifications.
 }
 
 // notificationWrapper is the notification wrapper.
@@ -23,9 +23,9 @@ type notificationWrapper struct {
 // Please note that only customer receivers will be returned.
 // Any email, sms or webhook that belongs to the Store will not be returned.
 func (client *Client) GetNotification(ctx context.Context, identifier TrackingIdentifier) (Notification, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error getting notification")
 	if err != nil {
-		return Notification{}, errors.Wrap(err, "error getting notification")
+		return Notification{}, err
 	}
 
 	uriPath = fmt.Sprintf("/notifications%s", uriPath)
@@ -36,9 +36,14 @@ func (client *Client) GetNotification(ctx context.Context, identifier TrackingId
 
 // AddNotification adds notifications to a single tracking.
 func (client *Client) AddNotification(ctx context.Context, identifier TrackingIdentifier, notification Notification) (Notification, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error adding notification")
 	if err != nil {
-		return Notification{}, errors.Wrap(err, "error adding notification")
+		return Notification{}, err
+	}
+
+	notification.Emails, notification.SMSes, err = client.normalizeContacts(notification.Emails, notification.SMSes)
+	if err != nil {
+		return Notification{}, err
 	}
 
 	uriPath = fmt.Sprintf("/notifications%s/add", uriPath)
@@ -50,11 +55,13 @@ func (client *Client) AddNotification(ctx context.Context, identifier TrackingId
 
 // RemoveNotification removes notifications from a single tracking.
 func (client *Client) RemoveNotification(ctx context.Context, identifier TrackingIdentifier, notification Notification) (Notification, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error removing notification")
 	if err != nil {
-		return Notification{}, errors.Wrap(err, "error removing notification")
+		return Notification{}, err
 	}
 
+	notification.Emails, notification.SMSes = client.normalizeRemovedContacts(notification.Emails, notification.SMSes)
+
 	uriPath = fmt.Sprintf("/notifications%s/remove", uriPath)
 	var wrapper notificationWrapper
 	err = client.makeRequest(ctx, http.MethodPost, uriPath, nil,
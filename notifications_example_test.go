@@ -49,8 +49,10 @@ func ExampleClient_AddNotification() {
 	}
 
 	data := aftership.Notification{
-		Emails: []string{"user1@gmail.com", "user2@gmail.com", "invalid EMail @ Gmail. com"},
-		SMSes:  []string{"+85291239123", "+85261236123", "Invalid Mobile Phone Number"},
+		Emails:  []string{"user1@gmail.com", "user2@gmail.com", "invalid EMail @ Gmail. com"},
+		SMSes:   []string{"+85291239123", "+85261236123", "Invalid Mobile Phone Number"},
+		IOS:     []string{"cec7c33c0e007de3c9"},
+		Android: []string{"5b766a5cc7c33c0e"},
 	}
 
 	result, err := cli.AddNotification(context.Background(), param, data)
@@ -79,8 +81,9 @@ func ExampleClient_RemoveNotification() {
 	}
 
 	data := aftership.Notification{
-		Emails: []string{"user2@gmail.com"},
-		SMSes:  []string{"+85261236123"},
+		Emails:  []string{"user2@gmail.com"},
+		SMSes:   []string{"+85261236123"},
+		Android: []string{"5b766a5cc7c33c0e"},
 	}
 
 	result, err := cli.RemoveNotification(context.Background(), param, data)
@@ -91,3 +94,31 @@ func ExampleClient_RemoveNotification() {
 
 	fmt.Println(result)
 }
+
+func ExampleClient_SetNotification() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Replace the receivers of the tracking
+	param := aftership.SlugTrackingNumber{
+		Slug:           "dhl",
+		TrackingNumber: "1588226550",
+	}
+
+	changes, err := cli.SetNotification(context.Background(), param, aftership.Notification{
+		Emails: []string{"user1@gmail.com"},
+		SMSes:  []string{"+85291239123"},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(changes)
+}
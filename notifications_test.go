@@ -28,15 +28,19 @@ func TestGetNotification(t *testing.T) {
 			"data": {
 					"notification": {
 							"emails": ["user1@gmail.com","user2@gmail.com"],
-							"smses": ["+85291239123", "+85261236123"]
+							"smses": ["+85291239123", "+85261236123"],
+							"ios": ["cec7c33c0e007de3c9"],
+							"android": ["5b766a5cc7c33c0e"]
 					}
 			}
 	}`))
 	})
 
 	exp := Notification{
-		[]string{"user1@gmail.com", "user2@gmail.com"},
-		[]string{"+85291239123", "+85261236123"},
+		Emails:  []string{"user1@gmail.com", "user2@gmail.com"},
+		SMSes:   []string{"+85291239123", "+85261236123"},
+		IOS:     []string{"cec7c33c0e007de3c9"},
+		Android: []string{"5b766a5cc7c33c0e"},
 	}
 
 	res, err := client.GetNotification(context.Background(), p)
@@ -74,20 +78,26 @@ func TestAddNotification(t *testing.T) {
 			"data": {
 					"notification": {
 							"emails": ["user1@gmail.com","user2@gmail.com"],
-							"smses": ["+85291239123", "+85261236123"]
+							"smses": ["+85291239123", "+85261236123"],
+							"ios": ["cec7c33c0e007de3c9"],
+							"android": ["5b766a5cc7c33c0e"]
 					}
 			}
 	}`))
 	})
 
 	req := Notification{
-		[]string{"user1@gmail.com", "user2@gmail.com", "invalid EMail @ Gmail. com"},
-		[]string{"+85291239123", "+85261236123", "Invalid Mobile Phone Number"},
+		Emails:  []string{"user1@gmail.com", "user2@gmail.com", "invalid EMail @ Gmail. com"},
+		SMSes:   []string{"+85291239123", "+85261236123", "Invalid Mobile Phone Number"},
+		IOS:     []string{"cec7c33c0e007de3c9"},
+		Android: []string{"5b766a5cc7c33c0e"},
 	}
 
 	exp := Notification{
-		[]string{"user1@gmail.com", "user2@gmail.com"},
-		[]string{"+85291239123", "+85261236123"},
+		Emails:  []string{"user1@gmail.com", "user2@gmail.com"},
+		SMSes:   []string{"+85291239123", "+85261236123"},
+		IOS:     []string{"cec7c33c0e007de3c9"},
+		Android: []string{"5b766a5cc7c33c0e"},
 	}
 
 	res, err := client.AddNotification(context.Background(), p, req)
@@ -125,20 +135,25 @@ func TestRemoveNotification(t *testing.T) {
 			"data": {
 					"notification": {
 							"emails": [],
-							"smses": ["+85261236888"]
+							"smses": ["+85261236888"],
+							"ios": ["cec7c33c0e007de3c9"],
+							"android": []
 					}
 			}
 	}`))
 	})
 
 	req := Notification{
-		[]string{"user1@gmail.com", "user2@gmail.com", "invalid EMail @ Gmail. com"},
-		[]string{"+85291239123", "Invalid Mobile Phone Number"},
+		Emails:  []string{"user1@gmail.com", "user2@gmail.com", "invalid EMail @ Gmail. com"},
+		SMSes:   []string{"+85291239123", "Invalid Mobile Phone Number"},
+		Android: []string{"5b766a5cc7c33c0e"},
 	}
 
 	exp := Notification{
-		[]string{},
-		[]string{"+85261236888"},
+		Emails:  []string{},
+		SMSes:   []string{"+85261236888"},
+		IOS:     []string{"cec7c33c0e007de3c9"},
+		Android: []string{},
 	}
 
 	res, err := client.RemoveNotification(context.Background(), p, req)
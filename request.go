@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"strconv"
 	"time"
 
@@ -20,18 +21,111 @@ import (
 func (client *Client) makeRequest(ctx context.Context, method string, path string,
 	queryParams interface{}, inputData interface{}, resultData interface{}) error {
 
+	req, err := client.newRequest(ctx, method, path, queryParams, inputData)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.sendRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return client.parseResponse(path, resp, resultData)
+}
+
+// makeReadRequest makes a GET AfterShip API call. When StaleIfError is set, successful responses are stored,
+// and the stored response is returned with a *StaleResponseError when the API is unavailable or rate limited.
+func (client *Client) makeReadRequest(ctx context.Context, path string, queryParams interface{}, resultData interface{}) error {
+	store := client.Config.StaleIfError
+	if store == nil {
+		return client.makeRequest(ctx, http.MethodGet, path, queryParams, nil, resultData)
+	}
+
+	req, err := client.newRequest(ctx, http.MethodGet, path, queryParams, nil)
+	if err != nil {
+		return err
+	}
+	key := req.URL.String()
+
+	resp, err := client.sendRequest(ctx, req)
+	if err == nil {
+		err = client.parseResponse(path, resp, resultData)
+		if err == nil {
+			// The store is best effort: failing to keep the response must not fail the live read
+			_ = store.PutResponse(ctx, key, StoredResponse{Body: resp.body, StoredAt: time.Now()})
+			return nil
+		}
+	}
+
+	if !client.servesStale(ctx, resp) {
+		return err
+	}
+	return client.readStaleResponse(ctx, key, path, err, resultData)
+}
+
+// servesStale returns true when a read request failed because the API is unavailable or rate limited:
+// it returned a 5xx or 429 status, or could not be called, unless the caller cancelled the request.
+func (client *Client) servesStale(ctx context.Context, resp *rawResponse) bool {
+	if resp == nil {
+		return ctx.Err() != context.Canceled
+	}
+	return resp.statusCode >= http.StatusInternalServerError || resp.statusCode == http.StatusTooManyRequests
+}
+
+// readStaleResponse unmarshals the stored response of key into resultData and returns a *StaleResponseError
+// wrapping err, or returns err when there is no stored response younger than MaxStale.
+func (client *Client) readStaleResponse(ctx context.Context, key string, path string, err error, resultData interface{}) error {
+	stored, ok, storeErr := client.Config.StaleIfError.GetResponse(ctx, key)
+	if storeErr != nil || !ok {
+		return err
+	}
+
+	age := time.Since(stored.StoredAt)
+	if client.Config.MaxStale > 0 && age > client.Config.MaxStale {
+		return err
+	}
+
+	// Forget what the failed response set
+	result := reflect.ValueOf(resultData).Elem()
+	result.Set(reflect.Zero(result.Type()))
+	if parseErr := client.parseResponse(path, &rawResponse{statusCode: http.StatusOK, body: stored.Body}, resultData); parseErr != nil {
+		return err
+	}
+
+	return &StaleResponseError{
+		Path:     path,
+		StoredAt: stored.StoredAt,
+		Age:      age,
+		Err:      err,
+	}
+}
+
+// sendRequest sends a request unless the rate limit is exceeded,
+// sharing the identical GET requests in flight when CoalesceReads is set
+func (client *Client) sendRequest(ctx context.Context, req *http.Request) (*rawResponse, error) {
 	// Check if rate limit is exceeded
 	if client.rateLimit != nil && client.rateLimit.isExceeded() {
-		return fmt.Errorf(errExceedRateLimt, time.Unix(client.rateLimit.Reset, 0))
+		return nil, fmt.Errorf(errExceedRateLimt, time.Unix(client.rateLimit.Reset, 0))
 	}
 
+	if req.Method == http.MethodGet && client.Config.CoalesceReads {
+		return client.reads.do(ctx, req.URL.String(), req, client.send)
+	}
+	return client.send(req)
+}
+
+// newRequest returns the signed HTTP request of an API call
+func (client *Client) newRequest(ctx context.Context, method string, path string,
+	queryParams interface{}, inputData interface{}) (*http.Request, error) {
+
 	// Read input data
 	var body io.Reader
 	var bodyStr string
 	if inputData != nil {
 		jsonData, err := json.Marshal(inputData)
 		if err != nil {
-			return errors.Wrap(err, "error marshalling params to JSON")
+			return nil, errors.Wrap(err, "error marshalling params to JSON")
 		}
 
 		bodyStr = string(jsonData)
@@ -40,7 +134,7 @@ func (client *Client) makeRequest(ctx context.Context, method string, path strin
 
 	req, err := http.NewRequestWithContext(ctx, method, client.Config.BaseURL+path, body)
 	if err != nil {
-		return errors.Wrap(err, "HTTP request creation failed")
+		return nil, errors.Wrap(err, "HTTP request creation failed")
 	}
 
 	// Add headers
@@ -53,7 +147,7 @@ func (client *Client) makeRequest(ctx context.Context, method string, path strin
 	if queryParams != nil {
 		queryStringObj, err := query.Values(queryParams)
 		if err != nil {
-			return errors.Wrap(err, "error parsing query params")
+			return nil, errors.Wrap(err, "error parsing query params")
 		}
 		req.URL.RawQuery = queryStringObj.Encode()
 	}
@@ -75,41 +169,58 @@ func (client *Client) makeRequest(ctx context.Context, method string, path strin
 			authenticationType, []byte(client.Config.APISecret), asHeaders,
 			contentType, req.URL.RequestURI(), req.Method, date, bodyStr)
 		if err != nil {
-			return errors.Wrap(err, "generate signature error")
+			return nil, errors.Wrap(err, "generate signature error")
 		}
 
 		req.Header.Add("date", date)
 		req.Header.Add(signatureHeader, signature)
+	} else if client.Config.APIVersion.dated() {
+		req.Header.Add("as-api-key", apiKey)
 	} else {
 		req.Header.Add("aftership-api-key", apiKey)
 	}
 
-	// Send request
+	return req, nil
+}
+
+// rawResponse is a response read from the API, before its JSON is unmarshalled
+type rawResponse struct {
+	statusCode int
+	body       []byte
+}
+
+// send sends an HTTP request and reads its response, updating the rate limit
+func (client *Client) send(req *http.Request) (*rawResponse, error) {
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return errors.Wrap(err, "HTTP request failed")
+		return nil, errors.Wrap(err, "HTTP request failed")
 	}
 
 	defer resp.Body.Close()
 	contents, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return errors.Wrap(err, "could not read response body")
+		return nil, errors.Wrap(err, "could not read response body")
 	}
 
 	// Rate Limit
 	setRateLimit(client.rateLimit, resp)
 
+	return &rawResponse{statusCode: resp.StatusCode, body: contents}, nil
+}
+
+// parseResponse unmarshals the data of a response into resultData, or returns its error
+func (client *Client) parseResponse(path string, resp *rawResponse, resultData interface{}) error {
 	result := &Response{
 		Meta: Meta{},
 		Data: resultData,
 	}
 	// Unmarshal response object
-	err = json.Unmarshal(contents, result)
+	err := json.Unmarshal(resp.body, result)
 	if err != nil {
 		return errors.Wrap(err, "error unmarshalling the JSON response")
 	}
 
-	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+	if resp.statusCode >= http.StatusOK && resp.statusCode < http.StatusMultipleChoices {
 		// The 2xx range indicate success
 		return nil
 	}
@@ -122,7 +233,7 @@ func (client *Client) makeRequest(ctx context.Context, method string, path strin
 	}
 
 	// Too many requests error
-	if resp.StatusCode == http.StatusTooManyRequests {
+	if resp.statusCode == http.StatusTooManyRequests {
 		return &TooManyRequestsError{
 			APIError:  apiError,
 			RateLimit: client.rateLimit,
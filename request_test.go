@@ -3,6 +3,7 @@ package aftership
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -237,3 +238,150 @@ func TestBlockRequestWhenReachLimit(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, exp, err.Error())
 }
+
+func TestStaleIfError(t *testing.T) {
+	setup()
+	defer teardown()
+	store := NewMemoryResponseStore(0)
+	client.Config.StaleIfError = store
+
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusOK, `{"meta": {"code": 200}, "data": {"tracking": {"id": "5b74f4958776db0e00b6f5ed", "tag": "InTransit"}}}`},
+		{http.StatusInternalServerError, `{"meta": {"code": 500, "type": "InternalError"}, "data": {"tracking": {"tag": "Pending"}}}`},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`},
+		{http.StatusNotFound, `{"meta": {"code": 4004, "type": "NotFound"}, "data": {}}`},
+	}
+	calls := 0
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		response := responses[calls%len(responses)]
+		calls++
+		w.WriteHeader(response.status)
+		w.Write([]byte(response.body))
+	})
+
+	ctx := context.Background()
+	id := TrackingID("5b74f4958776db0e00b6f5ed")
+	tracking, err := client.GetTracking(ctx, id, GetTrackingParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, TagInTransit, tracking.Tag)
+
+	// Server errors return the stored response
+	tracking, err = client.GetTracking(ctx, id, GetTrackingParams{})
+	var staleErr *StaleResponseError
+	if assert.True(t, errors.As(err, &staleErr)) {
+		assert.Equal(t, "/trackings/5b74f4958776db0e00b6f5ed", staleErr.Path)
+		assert.True(t, staleErr.Age >= 0 && staleErr.Age < time.Minute)
+		assert.Contains(t, err.Error(), "InternalError")
+	}
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "5b74f4958776db0e00b6f5ed", tracking.ID)
+	assert.Equal(t, TagInTransit, tracking.Tag)
+
+	tracking, err = client.GetTracking(ctx, id, GetTrackingParams{})
+	assert.True(t, errors.As(err, &staleErr))
+	assert.Equal(t, TagInTransit, tracking.Tag)
+
+	// Client errors do not
+	tracking, err = client.GetTracking(ctx, id, GetTrackingParams{})
+	assert.False(t, errors.As(err, &staleErr))
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 4004, apiErr.Code)
+
+	// Nor responses older than MaxStale
+	client.Config.MaxStale = time.Hour
+	req, _ := client.newRequest(ctx, http.MethodGet, "/trackings/5b74f4958776db0e00b6f5ed", GetTrackingParams{}, nil)
+	key := req.URL.String()
+	stored, _, _ := store.GetResponse(ctx, key)
+	stored.StoredAt = stored.StoredAt.Add(-2 * time.Hour)
+	store.PutResponse(ctx, key, stored)
+	calls = 1
+	_, err = client.GetTracking(ctx, id, GetTrackingParams{})
+	assert.False(t, errors.As(err, &staleErr))
+	assert.True(t, errors.As(err, &apiErr))
+
+	// Nor calls without a stored response
+	_, err = client.GetTracking(ctx, TrackingID("5b74f4958776db0e00b6f5ee"), GetTrackingParams{})
+	assert.False(t, errors.As(err, &staleErr))
+}
+
+func TestStaleIfErrorRateLimitAndTimeout(t *testing.T) {
+	setup()
+	defer teardown()
+	client.Config.StaleIfError = NewMemoryResponseStore(0)
+
+	calls := 0
+	mux.HandleFunc("/couriers", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.Write([]byte(`{"meta": {"code": 200}, "data": {"total": 1, "couriers": [{"slug": "ups"}]}}`))
+		case 2:
+			w.Header().Set("x-ratelimit-reset", "4102444800")
+			w.Header().Set("x-ratelimit-remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"meta": {"code": 429, "type": "TooManyRequests"}, "data": {}}`))
+		default:
+			<-r.Context().Done()
+		}
+	})
+
+	ctx := context.Background()
+	list, err := client.GetCouriers(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, list.Total)
+
+	var staleErr *StaleResponseError
+	var tooManyErr *TooManyRequestsError
+	list, err = client.GetCouriers(ctx)
+	assert.True(t, errors.As(err, &staleErr))
+	assert.True(t, errors.As(err, &tooManyErr))
+	assert.Equal(t, "ups", list.Couriers[0].Slug)
+
+	// Exceeding the rate limit locally
+	list, err = client.GetCouriers(ctx)
+	assert.True(t, errors.As(err, &staleErr))
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, 2, calls)
+
+	// Timeouts, but not cancellations
+	client.rateLimit.Remaining = 1
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	list, err = client.GetCouriers(timeout)
+	assert.True(t, errors.As(err, &staleErr))
+	assert.Equal(t, 1, list.Total)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = client.GetCouriers(cancelled)
+	assert.NotNil(t, err)
+	assert.False(t, errors.As(err, &staleErr))
+}
+
+// failingResponseStore is a ResponseStore whose writes fail
+type failingResponseStore struct {
+	*MemoryResponseStore
+}
+
+func (s failingResponseStore) PutResponse(ctx context.Context, key string, response StoredResponse) error {
+	return errors.New("disk full")
+}
+
+func TestStaleIfErrorStoreFailure(t *testing.T) {
+	setup()
+	defer teardown()
+	client.Config.StaleIfError = failingResponseStore{NewMemoryResponseStore(0)}
+
+	mux.HandleFunc("/couriers", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"total": 1, "couriers": [{"slug": "ups"}]}}`))
+	})
+
+	// The live response is returned when it cannot be stored
+	list, err := client.GetCouriers(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ups", list.Couriers[0].Slug)
+}
@@ -0,0 +1,110 @@
+package aftership
+
+import (
+	"context"
+	"time"
+)
+
+// shipDateLayout is the layout of AdditionalField.TrackingShipDate
+const shipDateLayout = "20060102"
+
+// additionalFieldValue returns the value of the AdditionalField member with the JSON name.
+// ok is false for names unknown to this SDK.
+func additionalFieldValue(fields AdditionalField, name string) (value string, ok bool) {
+	switch name {
+	case "tracking_account_number":
+		return fields.TrackingAccountNumber, true
+	case "tracking_origin_country":
+		return fields.TrackingOriginCountry, true
+	case "tracking_destination_country":
+		return fields.TrackingDestinationCountry, true
+	case "tracking_key":
+		return fields.TrackingKey, true
+	case "tracking_postal_code":
+		return fields.TrackingPostalCode, true
+	case "tracking_ship_date":
+		return fields.TrackingShipDate, true
+	case "tracking_state":
+		return fields.TrackingState, true
+	}
+	return "", false
+}
+
+// CheckRequiredFields returns the fields of courier.RequiredFields missing from fields,
+// and an error for TrackingShipDate when it is set but not in YYYYMMDD format.
+// Required fields unknown to this SDK are skipped.
+func CheckRequiredFields(courier Courier, fields AdditionalField) []FieldError {
+	var fieldErrors []FieldError
+	for _, name := range courier.RequiredFields {
+		value, ok := additionalFieldValue(fields, name)
+		if ok && value == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: errMissingRequiredField})
+		}
+	}
+
+	if fields.TrackingShipDate != "" {
+		if _, err := time.Parse(shipDateLayout, fields.TrackingShipDate); err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: "tracking_ship_date", Message: errInvalidShipDate})
+		}
+	}
+
+	return fieldErrors
+}
+
+// ValidateRequiredFields checks fields against the RequiredFields of courier.
+// It returns a *ValidationError listing every missing or malformed field, or nil.
+func ValidateRequiredFields(courier Courier, fields AdditionalField) error {
+	fieldErrors := CheckRequiredFields(courier, fields)
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	return &ValidationError{
+		Slug:   courier.Slug,
+		Fields: fieldErrors,
+	}
+}
+
+// ValidateRequiredFields checks fields against the RequiredFields of the courier with slug.
+// Only the TrackingShipDate format is checked when slug is empty or unknown to the registry.
+func (r *CourierRegistry) ValidateRequiredFields(ctx context.Context, slug string, fields AdditionalField) error {
+	courier := Courier{Slug: slug}
+	if slug != "" {
+		found, ok, err := r.Courier(ctx, slug)
+		if err != nil {
+			return err
+		}
+		if ok {
+			courier = found
+		}
+	}
+
+	return ValidateRequiredFields(courier, fields)
+}
+
+// ValidateCreateTrackingParams checks params against the RequiredFields of the courier in params.Slug.
+func (r *CourierRegistry) ValidateCreateTrackingParams(ctx context.Context, params CreateTrackingParams) error {
+	return r.ValidateRequiredFields(ctx, params.Slug, params.AdditionalField)
+}
+
+// ValidateGetTrackingParams checks params against the RequiredFields of the courier of identifier.
+// The courier is only known when identifier is a SlugTrackingNumber.
+func (r *CourierRegistry) ValidateGetTrackingParams(ctx context.Context, identifier TrackingIdentifier, params GetTrackingParams) error {
+	var slug string
+	if stn, ok := identifier.(SlugTrackingNumber); ok {
+		slug = stn.Slug
+	}
+
+	return r.ValidateRequiredFields(ctx, slug, params.AdditionalField)
+}
+
+// ValidateCourierDetectionParams checks params against the RequiredFields of the courier in params.Slug.
+// Required fields are only checked when exactly one slug is given, since any of several slugs may be detected.
+func (r *CourierRegistry) ValidateCourierDetectionParams(ctx context.Context, params CourierDetectionParams) error {
+	var slug string
+	if len(params.Slug) == 1 {
+		slug = params.Slug[0]
+	}
+
+	return r.ValidateRequiredFields(ctx, slug, params.AdditionalField)
+}
@@ -0,0 +1,103 @@
+package aftership
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRequiredFields(t *testing.T) {
+	courier := Courier{
+		Slug:           "deutsch-post",
+		RequiredFields: []string{"tracking_ship_date", "tracking_postal_code", "tracking_something_new"},
+	}
+
+	fieldErrors := CheckRequiredFields(courier, AdditionalField{})
+	assert.Equal(t, []FieldError{
+		{Field: "tracking_ship_date", Message: errMissingRequiredField},
+		{Field: "tracking_postal_code", Message: errMissingRequiredField},
+	}, fieldErrors)
+
+	fieldErrors = CheckRequiredFields(courier, AdditionalField{
+		TrackingShipDate:   "2022-11-17",
+		TrackingPostalCode: "10115",
+	})
+	assert.Equal(t, []FieldError{
+		{Field: "tracking_ship_date", Message: errInvalidShipDate},
+	}, fieldErrors)
+
+	fieldErrors = CheckRequiredFields(courier, AdditionalField{
+		TrackingShipDate:   "20221117",
+		TrackingPostalCode: "10115",
+	})
+	assert.Nil(t, fieldErrors)
+
+	// The ship date format is checked even when not required
+	fieldErrors = CheckRequiredFields(Courier{Slug: "dhl"}, AdditionalField{TrackingShipDate: "20221332"})
+	assert.Equal(t, []FieldError{
+		{Field: "tracking_ship_date", Message: errInvalidShipDate},
+	}, fieldErrors)
+}
+
+func TestValidateRequiredFields(t *testing.T) {
+	courier := Courier{
+		Slug:           "dynamic-logistics",
+		RequiredFields: []string{"tracking_account_number"},
+	}
+
+	err := ValidateRequiredFields(courier, AdditionalField{})
+	assert.NotNil(t, err)
+	validationErr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, "dynamic-logistics", validationErr.Slug)
+	assert.Equal(t, `{"slug":"dynamic-logistics","fields":[{"field":"tracking_account_number","message":"required by the courier and must be provided"}]}`, err.Error())
+
+	assert.Nil(t, ValidateRequiredFields(courier, AdditionalField{TrackingAccountNumber: "123"}))
+}
+
+func TestCourierRegistryValidateParams(t *testing.T) {
+	registry := NewCourierRegistry(nil, 0)
+	registry.Seed(registryTestCouriers)
+	ctx := context.Background()
+
+	err := registry.ValidateCreateTrackingParams(ctx, CreateTrackingParams{
+		TrackingNumber: "123",
+		Slug:           "deutsch-post",
+		AdditionalField: AdditionalField{
+			TrackingShipDate: "20221117",
+		},
+	})
+	assert.Equal(t, &ValidationError{
+		Slug:   "deutsch-post",
+		Fields: []FieldError{{Field: "tracking_postal_code", Message: errMissingRequiredField}},
+	}, err)
+
+	// unknown or auto detected couriers only get the ship date checked
+	assert.Nil(t, registry.ValidateCreateTrackingParams(ctx, CreateTrackingParams{TrackingNumber: "123", Slug: "unknown"}))
+	assert.NotNil(t, registry.ValidateCreateTrackingParams(ctx, CreateTrackingParams{
+		TrackingNumber:  "123",
+		AdditionalField: AdditionalField{TrackingShipDate: "17-11-2022"},
+	}))
+
+	err = registry.ValidateGetTrackingParams(ctx, SlugTrackingNumber{Slug: "deutsch-post", TrackingNumber: "123"}, GetTrackingParams{})
+	assert.NotNil(t, err)
+	assert.Len(t, err.(*ValidationError).Fields, 2)
+	assert.Nil(t, registry.ValidateGetTrackingParams(ctx, TrackingID("5b74f4958776db0e00b6f5ed"), GetTrackingParams{}))
+
+	err = registry.ValidateCourierDetectionParams(ctx, CourierDetectionParams{TrackingNumber: "123", Slug: []string{"deutsch-post"}})
+	assert.NotNil(t, err)
+	assert.Nil(t, registry.ValidateCourierDetectionParams(ctx, CourierDetectionParams{TrackingNumber: "123", Slug: []string{"deutsch-post", "dhl-germany"}}))
+}
+
+func TestCourierRegistryValidateParamsError(t *testing.T) {
+	registry := NewCourierRegistry(func(ctx context.Context) (CourierList, error) {
+		return CourierList{}, errors.New("boom")
+	}, 0)
+
+	err := registry.ValidateCreateTrackingParams(context.Background(), CreateTrackingParams{Slug: "deutsch-post"})
+	assert.NotNil(t, err)
+	_, ok := err.(*ValidationError)
+	assert.False(t, ok)
+}
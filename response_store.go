@@ -0,0 +1,154 @@
+package aftership
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StoredResponse is an API response kept in a ResponseStore
+type StoredResponse struct {
+	Body     json.RawMessage `json:"body"`      // The JSON body of the response
+	StoredAt time.Time       `json:"stored_at"` // When the response was received
+}
+
+// ResponseStore keeps the last successful API responses, keyed by the URL of their request
+type ResponseStore interface {
+	// GetResponse returns the stored response of key, and false when there is none
+	GetResponse(ctx context.Context, key string) (StoredResponse, bool, error)
+
+	// PutResponse stores the response of key, replacing the previous one
+	PutResponse(ctx context.Context, key string, response StoredResponse) error
+}
+
+// storedResponseEntry is an entry of the LRU list of a MemoryResponseStore
+type storedResponseEntry struct {
+	key      string
+	response StoredResponse
+}
+
+// MemoryResponseStore is a ResponseStore kept in memory, evicting the least recently used responses beyond its capacity
+type MemoryResponseStore struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // Of *storedResponseEntry, the most recently used first
+	entries map[string]*list.Element
+}
+
+var _ ResponseStore = (*MemoryResponseStore)(nil)
+
+// NewMemoryResponseStore returns an empty MemoryResponseStore holding up to size responses,
+// or DefaultMemoryStoreSize when size is 0.
+func NewMemoryResponseStore(size int) *MemoryResponseStore {
+	if size <= 0 {
+		size = DefaultMemoryStoreSize
+	}
+	return &MemoryResponseStore{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// GetResponse returns the stored response of key, and false when there is none
+func (s *MemoryResponseStore) GetResponse(ctx context.Context, key string) (StoredResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return StoredResponse{}, false, nil
+	}
+	s.lru.MoveToFront(element)
+	return element.Value.(*storedResponseEntry).response, true, nil
+}
+
+// PutResponse stores the response of key, evicting the least recently used one when the store is full
+func (s *MemoryResponseStore) PutResponse(ctx context.Context, key string, response StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		element.Value.(*storedResponseEntry).response = response
+		s.lru.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.lru.PushFront(&storedResponseEntry{key: key, response: response})
+	for s.lru.Len() > s.size {
+		oldest := s.lru.Back()
+		delete(s.entries, oldest.Value.(*storedResponseEntry).key)
+		s.lru.Remove(oldest)
+	}
+	return nil
+}
+
+// FileResponseStore is a ResponseStore keeping every response in a JSON file of a directory, to survive restarts
+type FileResponseStore struct {
+	dir string
+}
+
+var _ ResponseStore = (*FileResponseStore)(nil)
+
+// NewFileResponseStore returns a FileResponseStore in dir, created if needed.
+func NewFileResponseStore(dir string) (*FileResponseStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "error creating store directory")
+	}
+	return &FileResponseStore{dir: dir}, nil
+}
+
+// GetResponse returns the stored response of key, and false when there is none
+func (s *FileResponseStore) GetResponse(ctx context.Context, key string) (StoredResponse, bool, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return StoredResponse{}, false, nil
+	}
+	if err != nil {
+		return StoredResponse{}, false, errors.Wrap(err, "error reading stored response")
+	}
+
+	var response StoredResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return StoredResponse{}, false, errors.Wrap(err, "error unmarshalling stored response")
+	}
+	return response, true, nil
+}
+
+// PutResponse stores the response of key, replacing its file atomically
+func (s *FileResponseStore) PutResponse(ctx context.Context, key string, response StoredResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling response")
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, ".response-*")
+	if err != nil {
+		return errors.Wrap(err, "error storing response")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "error storing response")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "error storing response")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), s.path(key)), "error storing response")
+}
+
+// path returns the file of a key, named after its hash as URLs are too long for file names
+func (s *FileResponseStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,45 @@
+package aftership_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func ExampleStaleResponseError() {
+	responses, err := aftership.NewFileResponseStore("/var/lib/aftership/responses")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Show trackings up to a day old while AfterShip is unavailable or rate limited
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey:       "YOUR_API_KEY",
+		StaleIfError: responses,
+		MaxStale:     24 * time.Hour,
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	tracking, err := cli.GetTracking(context.Background(), aftership.SlugTrackingNumber{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+	}, aftership.GetTrackingParams{})
+
+	var stale *aftership.StaleResponseError
+	switch {
+	case errors.As(err, &stale):
+		fmt.Printf("%s, as of %s ago\n", tracking.Tag, stale.Age.Round(time.Minute))
+	case err != nil:
+		fmt.Println(err)
+	default:
+		fmt.Println(tracking.Tag)
+	}
+}
@@ -0,0 +1,54 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testResponseStore(t *testing.T, store ResponseStore) {
+	ctx := context.Background()
+	_, ok, err := store.GetResponse(ctx, "https://api.aftership.com/v4/couriers")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, store.PutResponse(ctx, "https://api.aftership.com/v4/couriers",
+		StoredResponse{Body: json.RawMessage(`{"meta":{"code":200}}`), StoredAt: storeTestTime}))
+	assert.Nil(t, store.PutResponse(ctx, "https://api.aftership.com/v4/couriers",
+		StoredResponse{Body: json.RawMessage(`{"meta":{"code":201}}`), StoredAt: storeTestTime}))
+
+	response, ok, err := store.GetResponse(ctx, "https://api.aftership.com/v4/couriers")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"meta":{"code":201}}`, string(response.Body))
+	assert.True(t, storeTestTime.Equal(response.StoredAt))
+}
+
+func TestMemoryResponseStore(t *testing.T) {
+	store := NewMemoryResponseStore(2)
+	testResponseStore(t, store)
+
+	ctx := context.Background()
+	store.PutResponse(ctx, "a", StoredResponse{Body: json.RawMessage(`{}`)})
+	store.GetResponse(ctx, "https://api.aftership.com/v4/couriers")
+	store.PutResponse(ctx, "b", StoredResponse{Body: json.RawMessage(`{}`)})
+	_, ok, _ := store.GetResponse(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestFileResponseStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aftership-responses")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileResponseStore(dir)
+	assert.Nil(t, err)
+	testResponseStore(t, store)
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,53 @@
+package aftership
+
+import (
+	"context"
+)
+
+// TrackingsAPI is the trackings endpoint of the AfterShip API
+type TrackingsAPI interface {
+	CreateTracking(ctx context.Context, params CreateTrackingParams) (Tracking, error)
+	DeleteTracking(ctx context.Context, identifier TrackingIdentifier) (Tracking, error)
+	GetTrackings(ctx context.Context, params GetTrackingsParams) (PagedTrackings, error)
+	GetTracking(ctx context.Context, identifier TrackingIdentifier, params GetTrackingParams) (Tracking, error)
+	UpdateTracking(ctx context.Context, identifier TrackingIdentifier, params UpdateTrackingParams) (Tracking, error)
+	RetrackTracking(ctx context.Context, identifier TrackingIdentifier) (Tracking, error)
+	MarkTrackingAsCompleted(ctx context.Context, identifier TrackingIdentifier, status TrackingCompletedStatus) (Tracking, error)
+}
+
+// CheckpointsAPI is the last checkpoint endpoint of the AfterShip API
+type CheckpointsAPI interface {
+	GetLastCheckpoint(ctx context.Context, identifier TrackingIdentifier, params GetCheckpointParams) (LastCheckpoint, error)
+}
+
+// CouriersAPI is the couriers endpoint of the AfterShip API
+type CouriersAPI interface {
+	GetCouriers(ctx context.Context) (CourierList, error)
+	GetAllCouriers(ctx context.Context) (CourierList, error)
+	DetectCouriers(ctx context.Context, params CourierDetectionParams) (CourierList, error)
+}
+
+// NotificationsAPI is the notifications endpoint of the AfterShip API
+type NotificationsAPI interface {
+	GetNotification(ctx context.Context, identifier TrackingIdentifier) (Notification, error)
+	AddNotification(ctx context.Context, identifier TrackingIdentifier, notification Notification) (Notification, error)
+	RemoveNotification(ctx context.Context, identifier TrackingIdentifier, notification Notification) (Notification, error)
+}
+
+// EstimatedDeliveryDatesAPI is the estimated delivery date endpoint of the AfterShip API
+type EstimatedDeliveryDatesAPI interface {
+	BatchPredictEstimatedDeliveryDate(ctx context.Context, params []EstimatedDeliveryDate) (EstimatedDeliveryDates, error)
+}
+
+// API is the AfterShip API, as implemented by Client.
+// Code depending on API, or on the interface of a single endpoint as the helpers of this package do,
+// can be tested with a mock such as aftershiptest.Mock instead of a Client.
+type API interface {
+	TrackingsAPI
+	CheckpointsAPI
+	CouriersAPI
+	NotificationsAPI
+	EstimatedDeliveryDatesAPI
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,327 @@
+package aftership
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StaleReason is why a tracking is considered stale
+type StaleReason string
+
+// Stale reasons
+const (
+	// StaleReasonExpired is a tracking with the Expired tag
+	StaleReasonExpired StaleReason = "expired"
+
+	// StaleReasonNoRecentCheckpoint is an active, undelivered tracking without a new checkpoint for StaleAfter
+	StaleReasonNoRecentCheckpoint StaleReason = "no_recent_checkpoint"
+
+	// StaleReasonNoCheckpoints is an active tracking that AfterShip tracked only a few times without finding any checkpoint,
+	// usually because of a wrong courier or tracking number
+	StaleReasonNoCheckpoints StaleReason = "no_checkpoints"
+)
+
+// StaleAction is what a StalePolicy does with a stale tracking
+type StaleAction string
+
+// Stale actions
+const (
+	StaleActionRetrack  StaleAction = "retrack"  // Retrack the tracking with RetrackTracking
+	StaleActionComplete StaleAction = "complete" // Mark the tracking as completed with MarkTrackingAsCompleted
+	StaleActionAlert    StaleAction = "alert"    // Leave the tracking as is for a human to look at
+)
+
+// MaxRetracks is the number of times the AfterShip API allows a tracking to be retracked
+const MaxRetracks = 3
+
+// codeRetrackNotAllowed is the meta code of the API refusing a retrack
+const codeRetrackNotAllowed = 4016
+
+// StaleDecision is the action decided for a stale tracking
+type StaleDecision struct {
+	Tracking Tracking    `json:"tracking"`
+	Reason   StaleReason `json:"reason"`
+	Action   StaleAction `json:"action"`
+
+	// CompletedStatus is the status of StaleActionComplete
+	CompletedStatus TrackingCompletedStatus `json:"completed_status,omitempty"`
+
+	// Retracks is the number of retracks of the tracking before the action
+	Retracks int `json:"retracks"`
+
+	// LastActivity is the time of the last checkpoint, or the creation of the tracking when it has none
+	LastActivity time.Time `json:"last_activity"`
+
+	// Err is the error of applying the action, set by StalePolicy.Run only
+	Err error `json:"-"`
+}
+
+// RetrackCounter counts the retracks of every tracking.
+// Trackings are keyed by ID, or by "slug/tracking_number" when the ID is unknown.
+type RetrackCounter interface {
+	// Retracks returns the number of retracks of a tracking
+	Retracks(ctx context.Context, key string) (int, error)
+
+	// AddRetrack counts one more retrack of a tracking and returns the new count
+	AddRetrack(ctx context.Context, key string) (int, error)
+}
+
+// MemoryRetrackCounter is a RetrackCounter kept in memory
+type MemoryRetrackCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewMemoryRetrackCounter returns an empty MemoryRetrackCounter.
+func NewMemoryRetrackCounter() *MemoryRetrackCounter {
+	return &MemoryRetrackCounter{counts: make(map[string]int)}
+}
+
+// Retracks returns the number of retracks of a tracking
+func (c *MemoryRetrackCounter) Retracks(ctx context.Context, key string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[key], nil
+}
+
+// AddRetrack counts one more retrack of a tracking and returns the new count
+func (c *MemoryRetrackCounter) AddRetrack(ctx context.Context, key string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+// StalePolicyOptions configures a StalePolicy. The zero value uses the defaults.
+type StalePolicyOptions struct {
+	// StaleAfter is how long an active tracking can go without a new checkpoint. Defaults to 7 days.
+	StaleAfter time.Duration
+
+	// NoCheckpointsAfter is how long after its creation a tracking without checkpoints is stale. Defaults to 3 days.
+	NoCheckpointsAfter time.Duration
+
+	// LowTrackedCount is the highest TrackedCount of a stale tracking without checkpoints. Defaults to 5.
+	LowTrackedCount int
+
+	// MaxRetracks is the number of retracks before giving up on a tracking. Defaults to, and is capped at, MaxRetracks.
+	MaxRetracks int
+
+	// LostAfter is how long without activity a tracking that cannot be retracked anymore is marked as completed.
+	// More recent trackings are alerted instead. Defaults to 30 days.
+	LostAfter time.Duration
+
+	// Counter counts the retracks of every tracking. Defaults to a MemoryRetrackCounter, which forgets the counts
+	// when the process exits: use a persistent counter when the policy runs in separate processes, such as a daily job.
+	// Without one, every run tries the trackings at the API limit once more, before counting them as out of retracks.
+	Counter RetrackCounter
+
+	// DryRun makes Run decide the actions without applying them
+	DryRun bool
+}
+
+// StalePolicy finds trackings that stopped updating and decides whether to retrack them,
+// mark them as completed or alert about them. Retracks are counted so the API limit is never hit.
+type StalePolicy struct {
+	options StalePolicyOptions
+	now     func() time.Time
+}
+
+// NewStalePolicy returns a StalePolicy with options.
+func NewStalePolicy(options StalePolicyOptions) *StalePolicy {
+	if options.StaleAfter <= 0 {
+		options.StaleAfter = 7 * 24 * time.Hour
+	}
+	if options.NoCheckpointsAfter <= 0 {
+		options.NoCheckpointsAfter = 3 * 24 * time.Hour
+	}
+	if options.LowTrackedCount <= 0 {
+		options.LowTrackedCount = 5
+	}
+	if options.MaxRetracks <= 0 || options.MaxRetracks > MaxRetracks {
+		options.MaxRetracks = MaxRetracks
+	}
+	if options.LostAfter <= 0 {
+		options.LostAfter = 30 * 24 * time.Hour
+	}
+	if options.Counter == nil {
+		options.Counter = NewMemoryRetrackCounter()
+	}
+
+	return &StalePolicy{
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// Evaluate decides the action for tracking. It returns false when the tracking is not stale.
+// Expired trackings are retracked while retracks are left. Trackings that cannot be retracked
+// are marked as completed once inactive for LostAfter, and alerted before that.
+func (p *StalePolicy) Evaluate(ctx context.Context, tracking Tracking) (StaleDecision, bool, error) {
+	now := p.now()
+	decision := StaleDecision{
+		Tracking:     tracking,
+		LastActivity: lastActivity(tracking),
+	}
+
+	switch {
+	case tracking.Tag == TagExpired:
+		decision.Reason = StaleReasonExpired
+	case !tracking.Active || tracking.Tag == TagDelivered:
+		return StaleDecision{}, false, nil
+	case len(tracking.Checkpoints) == 0:
+		if tracking.TrackedCount > p.options.LowTrackedCount || now.Sub(decision.LastActivity) < p.options.NoCheckpointsAfter {
+			return StaleDecision{}, false, nil
+		}
+		decision.Reason = StaleReasonNoCheckpoints
+	case now.Sub(decision.LastActivity) >= p.options.StaleAfter:
+		decision.Reason = StaleReasonNoRecentCheckpoint
+	default:
+		return StaleDecision{}, false, nil
+	}
+
+	retracks, err := p.options.Counter.Retracks(ctx, retrackKey(tracking))
+	if err != nil {
+		return StaleDecision{}, false, errors.Wrap(err, "error counting retracks")
+	}
+	decision.Retracks = retracks
+
+	switch {
+	case decision.Reason == StaleReasonExpired && retracks < p.options.MaxRetracks:
+		// Only inactive trackings can be retracked
+		decision.Action = StaleActionRetrack
+	case decision.Reason != StaleReasonNoCheckpoints && now.Sub(decision.LastActivity) >= p.options.LostAfter:
+		decision.Action = StaleActionComplete
+		decision.CompletedStatus = TrackingCompletedStatusLost
+		if tracking.ReturnToSender {
+			decision.CompletedStatus = TrackingCompletedStatusReturnedToSender
+		}
+	default:
+		decision.Action = StaleActionAlert
+	}
+
+	return decision, true, nil
+}
+
+// Apply applies the action of decision and returns the updated tracking.
+// Successful retracks are counted, and retracks refused by the API count the tracking as out of retracks.
+// Alerts are left to the caller and return the tracking unchanged.
+func (p *StalePolicy) Apply(ctx context.Context, api TrackingsAPI, decision StaleDecision) (Tracking, error) {
+	identifier := staleTrackingIdentifier(decision.Tracking)
+
+	switch decision.Action {
+	case StaleActionRetrack:
+		tracking, err := api.RetrackTracking(ctx, identifier)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == codeRetrackNotAllowed {
+			// Only inactive trackings are retracked, so the API refused them for reaching its limit,
+			// with retracks not counted by Counter, such as those of previous runs
+			if countErr := p.countRetracksLeft(ctx, decision); countErr != nil {
+				return decision.Tracking, countErr
+			}
+		}
+		if err != nil {
+			return decision.Tracking, err
+		}
+		if _, err := p.options.Counter.AddRetrack(ctx, retrackKey(decision.Tracking)); err != nil {
+			return tracking, errors.Wrap(err, "error counting retracks")
+		}
+		return tracking, nil
+	case StaleActionComplete:
+		return api.MarkTrackingAsCompleted(ctx, identifier, decision.CompletedStatus)
+	}
+
+	return decision.Tracking, nil
+}
+
+// Run evaluates every tracking matched by params, fetching one page at a time, applies the action of the stale ones
+// unless DryRun is set, and calls fn with every decision. A failed action is reported in StaleDecision.Err
+// and does not stop Run; Run stops at the first error fetching the trackings or counting the retracks.
+//
+// The actions are applied once every page is fetched: they change the tag and update time of the trackings,
+// which would move them out of the pages of params filtering on those, and make the next pages skip trackings.
+func (p *StalePolicy) Run(ctx context.Context, api TrackingsAPI, params GetTrackingsParams, fn func(StaleDecision)) error {
+	var decisions []StaleDecision
+	err := WalkTrackings(ctx, api, params, func(tracking Tracking) error {
+		decision, stale, err := p.Evaluate(ctx, tracking)
+		if err != nil || !stale {
+			return err
+		}
+		decisions = append(decisions, decision)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, decision := range decisions {
+		if !p.options.DryRun {
+			decision.Tracking, decision.Err = p.Apply(ctx, api, decision)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+
+		fn(decision)
+	}
+	return nil
+}
+
+// lastActivity returns the time of the last checkpoint of tracking, or its creation time
+func lastActivity(tracking Tracking) time.Time {
+	for i := len(tracking.Checkpoints) - 1; i >= 0; i-- {
+		if t, ok := checkpointTime(tracking.Checkpoints[i]); ok {
+			return t
+		}
+	}
+
+	if tracking.CreatedAt != nil {
+		return *tracking.CreatedAt
+	}
+	return time.Time{}
+}
+
+// checkpointTime returns the time of checkpoint. CheckpointTime is in the local time of the checkpoint
+// and read as UTC when it has no time zone; the creation time is used when it is missing.
+func checkpointTime(checkpoint Checkpoint) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, checkpoint.CheckpointTime); err == nil {
+			return t, true
+		}
+	}
+
+	if checkpoint.CreatedAt != nil {
+		return *checkpoint.CreatedAt, true
+	}
+	return time.Time{}, false
+}
+
+// staleTrackingIdentifier returns the identifier of tracking, by ID when it has one
+func staleTrackingIdentifier(tracking Tracking) TrackingIdentifier {
+	if tracking.ID != "" {
+		return TrackingID(tracking.ID)
+	}
+	return SlugTrackingNumber{Slug: tracking.Slug, TrackingNumber: tracking.TrackingNumber}
+}
+
+// countRetracksLeft counts retracks of the tracking of decision until it reaches MaxRetracks
+func (p *StalePolicy) countRetracksLeft(ctx context.Context, decision StaleDecision) error {
+	key := retrackKey(decision.Tracking)
+	for count := decision.Retracks; count < MaxRetracks; {
+		var err error
+		if count, err = p.options.Counter.AddRetrack(ctx, key); err != nil {
+			return errors.Wrap(err, "error counting retracks")
+		}
+	}
+	return nil
+}
+
+// retrackKey is the key of tracking in a RetrackCounter
+func retrackKey(tracking Tracking) string {
+	if tracking.ID != "" {
+		return tracking.ID
+	}
+	return tracking.Slug + "/" + tracking.TrackingNumber
+}
@@ -0,0 +1,39 @@
+package aftership_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func ExampleStalePolicy_Run() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Retrack expired trackings, and mark the ones quiet for 45 days as lost.
+	policy := aftership.NewStalePolicy(aftership.StalePolicyOptions{
+		StaleAfter: 5 * 24 * time.Hour,
+		LostAfter:  45 * 24 * time.Hour,
+	})
+
+	err = policy.Run(context.Background(), cli, aftership.GetTrackingsParams{}, func(decision aftership.StaleDecision) {
+		if decision.Err != nil {
+			fmt.Println(decision.Tracking.TrackingNumber, decision.Action, decision.Err)
+			return
+		}
+		if decision.Action == aftership.StaleActionAlert {
+			fmt.Println(decision.Tracking.TrackingNumber, decision.Reason, "last activity", decision.LastActivity)
+		}
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,278 @@
+package aftership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var staleTestNow = time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStalePolicy(options StalePolicyOptions) *StalePolicy {
+	p := NewStalePolicy(options)
+	p.now = func() time.Time {
+		return staleTestNow
+	}
+	return p
+}
+
+func TestStalePolicyEvaluate(t *testing.T) {
+	counter := NewMemoryRetrackCounter()
+	counter.AddRetrack(context.Background(), "exhausted")
+	counter.AddRetrack(context.Background(), "exhausted")
+	counter.AddRetrack(context.Background(), "exhausted")
+	p := newTestStalePolicy(StalePolicyOptions{Counter: counter})
+
+	created := staleTestNow.AddDate(0, 0, -4)
+	cases := []struct {
+		name     string
+		tracking Tracking
+		stale    bool
+		reason   StaleReason
+		action   StaleAction
+		status   TrackingCompletedStatus
+		retracks int
+	}{
+		{
+			name: "recent checkpoint",
+			tracking: Tracking{ID: "recent", Active: true, Tag: TagInTransit, Checkpoints: []Checkpoint{
+				{CheckpointTime: "2022-11-28T09:00:00+08:00"},
+			}},
+		},
+		{
+			name:     "delivered",
+			tracking: Tracking{ID: "delivered", Tag: TagDelivered, Checkpoints: []Checkpoint{{CheckpointTime: "2022-10-01T09:00:00"}}},
+		},
+		{
+			name: "expired",
+			tracking: Tracking{ID: "expired", Tag: TagExpired, Checkpoints: []Checkpoint{
+				{CheckpointTime: "2022-10-01T09:00:00"},
+			}},
+			stale:  true,
+			reason: StaleReasonExpired,
+			action: StaleActionRetrack,
+		},
+		{
+			name: "expired without retracks left",
+			tracking: Tracking{ID: "exhausted", Tag: TagExpired, ReturnToSender: true, Checkpoints: []Checkpoint{
+				{CheckpointTime: "2022-10-01T09:00:00"},
+			}},
+			stale:    true,
+			reason:   StaleReasonExpired,
+			action:   StaleActionComplete,
+			status:   TrackingCompletedStatusReturnedToSender,
+			retracks: 3,
+		},
+		{
+			name: "no recent checkpoint",
+			tracking: Tracking{ID: "quiet", Active: true, Tag: TagInTransit, Checkpoints: []Checkpoint{
+				{CheckpointTime: "2022-11-20T09:00:00"},
+				{CheckpointTime: "2022-11-22T09:00:00"},
+			}},
+			stale:  true,
+			reason: StaleReasonNoRecentCheckpoint,
+			action: StaleActionAlert,
+		},
+		{
+			name: "lost",
+			tracking: Tracking{ID: "lost", Active: true, Tag: TagInTransit, Checkpoints: []Checkpoint{
+				{CheckpointTime: "2022-10-20T09:00:00"},
+			}},
+			stale:  true,
+			reason: StaleReasonNoRecentCheckpoint,
+			action: StaleActionComplete,
+			status: TrackingCompletedStatusLost,
+		},
+		{
+			name:     "no checkpoints",
+			tracking: Tracking{ID: "empty", Active: true, Tag: TagPending, TrackedCount: 2, CreatedAt: &created},
+			stale:    true,
+			reason:   StaleReasonNoCheckpoints,
+			action:   StaleActionAlert,
+		},
+		{
+			name:     "no checkpoints yet",
+			tracking: Tracking{ID: "new", Active: true, Tag: TagPending, TrackedCount: 20, CreatedAt: &created},
+		},
+	}
+
+	for _, c := range cases {
+		decision, stale, err := p.Evaluate(context.Background(), c.tracking)
+		assert.Nil(t, err, c.name)
+		assert.Equal(t, c.stale, stale, c.name)
+		assert.Equal(t, c.reason, decision.Reason, c.name)
+		assert.Equal(t, c.action, decision.Action, c.name)
+		assert.Equal(t, c.status, decision.CompletedStatus, c.name)
+		assert.Equal(t, c.retracks, decision.Retracks, c.name)
+	}
+}
+
+func TestStalePolicyRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"page": 1, "limit": 100, "count": 3, "trackings": [
+			{"id": "expired", "tag": "Expired", "checkpoints": [{"checkpoint_time": "2022-11-10T09:00:00"}]},
+			{"id": "lost", "active": true, "tag": "InTransit", "checkpoints": [{"checkpoint_time": "2022-10-01T09:00:00"}]},
+			{"id": "active", "active": true, "tag": "InTransit", "checkpoints": [{"checkpoint_time": "2022-11-30T09:00:00"}]}
+		]}}`))
+	})
+
+	retracks := 0
+	mux.HandleFunc("/trackings/expired/retrack", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		retracks++
+		if retracks > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"meta": {"code": 4016, "type": "BadRequest", "message": "Retrack is not allowed."}, "data": {}}`))
+			return
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"id": "expired", "active": true, "tag": "Pending"}}}`))
+	})
+
+	var completed markAsCompletedRequest
+	mux.HandleFunc("/trackings/lost/mark-as-completed", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&completed)
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"id": "lost", "tag": "Expired"}}}`))
+	})
+
+	counter := NewMemoryRetrackCounter()
+	p := newTestStalePolicy(StalePolicyOptions{MaxRetracks: 1, Counter: counter})
+
+	var decisions []StaleDecision
+	err := p.Run(context.Background(), client, GetTrackingsParams{}, func(decision StaleDecision) {
+		decisions = append(decisions, decision)
+	})
+	assert.Nil(t, err)
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, StaleActionRetrack, decisions[0].Action)
+	assert.Nil(t, decisions[0].Err)
+	assert.Equal(t, TagPending, decisions[0].Tracking.Tag)
+	assert.Equal(t, StaleActionComplete, decisions[1].Action)
+	assert.Nil(t, decisions[1].Err)
+	assert.Equal(t, "LOST", completed.Reason)
+
+	count, _ := counter.Retracks(context.Background(), "expired")
+	assert.Equal(t, 1, count)
+
+	// The retrack cap is reached, the expired tracking is not retracked again
+	decisions = nil
+	err = p.Run(context.Background(), client, GetTrackingsParams{}, func(decision StaleDecision) {
+		decisions = append(decisions, decision)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, StaleActionAlert, decisions[0].Action)
+	assert.Equal(t, 1, decisions[0].Retracks)
+	assert.Equal(t, 1, retracks)
+}
+
+func TestStalePolicyDryRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"page": 1, "limit": 100, "count": 1, "trackings": [
+			{"id": "expired", "tag": "Expired", "checkpoints": [{"checkpoint_time": "2022-11-01T09:00:00"}]}
+		]}}`))
+	})
+	mux.HandleFunc("/trackings/expired/retrack", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected retrack")
+	})
+
+	p := newTestStalePolicy(StalePolicyOptions{DryRun: true})
+	var decisions []StaleDecision
+	err := p.Run(context.Background(), client, GetTrackingsParams{}, func(decision StaleDecision) {
+		decisions = append(decisions, decision)
+	})
+	assert.Nil(t, err)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, StaleActionRetrack, decisions[0].Action)
+}
+
+func TestStalePolicyRunPages(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// One tracking per page, filtered on the tag changed by the retracks
+	ids := []string{"a", "b", "c"}
+	tags := map[string]string{"a": TagExpired, "b": TagExpired, "c": TagExpired}
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, TagExpired, r.URL.Query().Get("tag"))
+		var matched []string
+		for _, id := range ids {
+			if tags[id] == TagExpired {
+				matched = append(matched, id)
+			}
+		}
+
+		page := r.URL.Query().Get("page")
+		trackings := ""
+		if i := int(page[0] - '1'); i < len(matched) {
+			trackings = fmt.Sprintf(`{"id": %q, "tag": "Expired", "checkpoints": [{"checkpoint_time": "2022-11-10T09:00:00"}]}`, matched[i])
+		}
+		fmt.Fprintf(w, `{"meta": {"code": 200}, "data": {"page": %s, "limit": 1, "count": %d, "trackings": [%s]}}`,
+			page, len(matched), trackings)
+	})
+	for _, id := range ids {
+		id := id
+		mux.HandleFunc("/trackings/"+id+"/retrack", func(w http.ResponseWriter, r *http.Request) {
+			tags[id] = TagPending
+			fmt.Fprintf(w, `{"meta": {"code": 200}, "data": {"tracking": {"id": %q, "active": true, "tag": "Pending"}}}`, id)
+		})
+	}
+
+	var retracked []string
+	p := newTestStalePolicy(StalePolicyOptions{})
+	err := p.Run(context.Background(), client, GetTrackingsParams{Tag: TagExpired, Limit: 1}, func(decision StaleDecision) {
+		assert.Nil(t, decision.Err)
+		retracked = append(retracked, decision.Tracking.ID)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ids, retracked)
+}
+
+func TestStalePolicyRetrackLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"page": 1, "limit": 100, "count": 1, "trackings": [
+			{"id": "expired", "tag": "Expired", "checkpoints": [{"checkpoint_time": "2022-11-10T09:00:00"}]}
+		]}}`))
+	})
+	retracks := 0
+	mux.HandleFunc("/trackings/expired/retrack", func(w http.ResponseWriter, r *http.Request) {
+		retracks++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"meta": {"code": 4016, "type": "BadRequest",
+			"message": "Retrack is not allowed. You can only retrack a tracking 3 times."}, "data": {}}`))
+	})
+
+	// The tracking was retracked by previous runs, with counts lost
+	counter := NewMemoryRetrackCounter()
+	p := newTestStalePolicy(StalePolicyOptions{Counter: counter})
+	var decisions []StaleDecision
+	err := p.Run(context.Background(), client, GetTrackingsParams{}, func(decision StaleDecision) {
+		decisions = append(decisions, decision)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, StaleActionRetrack, decisions[0].Action)
+	assert.NotNil(t, decisions[0].Err)
+	count, _ := counter.Retracks(context.Background(), "expired")
+	assert.Equal(t, MaxRetracks, count)
+
+	// The refused retrack is not tried again
+	decisions = nil
+	err = p.Run(context.Background(), client, GetTrackingsParams{}, func(decision StaleDecision) {
+		decisions = append(decisions, decision)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, StaleActionAlert, decisions[0].Action)
+	assert.Equal(t, 1, retracks)
+}
@@ -0,0 +1,275 @@
+package aftership
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StoredTracking is a tracking kept in a Store
+type StoredTracking struct {
+	Tracking Tracking  `json:"tracking"`
+	StoredAt time.Time `json:"stored_at"` // When the tracking was fetched from the API
+}
+
+// Store keeps trackings out of the API. A stored tracking can be got by TrackingID, by SlugTrackingNumber,
+// or listed by order ID. Putting a tracking replaces the previous one with the same ID.
+type Store interface {
+	// Get returns the stored tracking of identifier, and false when there is none
+	Get(ctx context.Context, identifier TrackingIdentifier) (StoredTracking, bool, error)
+
+	// Put stores a tracking
+	Put(ctx context.Context, stored StoredTracking) error
+
+	// Delete removes the tracking of identifier, if any
+	Delete(ctx context.Context, identifier TrackingIdentifier) error
+
+	// ListByOrderID returns the stored trackings of an order
+	ListByOrderID(ctx context.Context, orderID string) ([]StoredTracking, error)
+}
+
+// trackingKeys returns the keys of a tracking: its ID first when known, then its slug and tracking number.
+// Keys are the URI paths of the identifiers, so a TrackingID and a SlugTrackingNumber never collide.
+func trackingKeys(tracking Tracking) ([]string, error) {
+	var keys []string
+	if tracking.ID != "" {
+		key, _ := TrackingID(tracking.ID).URIPath()
+		keys = append(keys, key)
+	}
+	if key, err := (SlugTrackingNumber{Slug: tracking.Slug, TrackingNumber: tracking.TrackingNumber}).URIPath(); err == nil {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("tracking has no ID, nor slug and tracking number")
+	}
+	return keys, nil
+}
+
+// copyStoredTracking returns a deep copy of stored, sharing no slice with it, like the trackings read from files
+func copyStoredTracking(stored StoredTracking) (StoredTracking, error) {
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return StoredTracking{}, errors.Wrap(err, "error marshalling tracking")
+	}
+
+	var copied StoredTracking
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return StoredTracking{}, errors.Wrap(err, "error unmarshalling tracking")
+	}
+	return copied, nil
+}
+
+// storeIndex maps the keys of the stored trackings to their primary key, and orders to their trackings
+type storeIndex struct {
+	keys   map[string]string              // Key to primary key
+	orders map[string]map[string]struct{} // Order ID to primary keys
+	owned  map[string][]string            // Primary key to its keys
+	order  map[string]string              // Primary key to its order ID
+}
+
+func newStoreIndex() *storeIndex {
+	return &storeIndex{
+		keys:   make(map[string]string),
+		orders: make(map[string]map[string]struct{}),
+		owned:  make(map[string][]string),
+		order:  make(map[string]string),
+	}
+}
+
+// add indexes a tracking and returns its primary key, and the primary keys it replaces
+func (index *storeIndex) add(tracking Tracking) (string, []string, error) {
+	keys, err := trackingKeys(tracking)
+	if err != nil {
+		return "", nil, err
+	}
+
+	primary := keys[0]
+	replaced := map[string]bool{}
+	for _, key := range keys {
+		if previous, ok := index.keys[key]; ok && previous != primary {
+			replaced[previous] = true
+		}
+	}
+	var replacedKeys []string
+	for previous := range replaced {
+		index.remove(previous)
+		replacedKeys = append(replacedKeys, previous)
+	}
+	index.remove(primary)
+
+	for _, key := range keys {
+		index.keys[key] = primary
+	}
+	index.owned[primary] = keys
+	if tracking.OrderID != "" {
+		if index.orders[tracking.OrderID] == nil {
+			index.orders[tracking.OrderID] = make(map[string]struct{})
+		}
+		index.orders[tracking.OrderID][primary] = struct{}{}
+		index.order[primary] = tracking.OrderID
+	}
+	return primary, replacedKeys, nil
+}
+
+// remove removes the tracking of a primary key from the index
+func (index *storeIndex) remove(primary string) {
+	for _, key := range index.owned[primary] {
+		if index.keys[key] == primary {
+			delete(index.keys, key)
+		}
+	}
+	delete(index.owned, primary)
+
+	if orderID, ok := index.order[primary]; ok {
+		delete(index.orders[orderID], primary)
+		if len(index.orders[orderID]) == 0 {
+			delete(index.orders, orderID)
+		}
+		delete(index.order, primary)
+	}
+}
+
+// lookup returns the primary key of identifier
+func (index *storeIndex) lookup(identifier TrackingIdentifier) (string, bool, error) {
+	key, err := identifier.URIPath()
+	if err != nil {
+		return "", false, err
+	}
+	primary, ok := index.keys[key]
+	return primary, ok, nil
+}
+
+// list returns the sorted primary keys of the trackings of an order
+func (index *storeIndex) list(orderID string) []string {
+	var primaries []string
+	for primary := range index.orders[orderID] {
+		primaries = append(primaries, primary)
+	}
+	sort.Strings(primaries)
+	return primaries
+}
+
+// DefaultMemoryStoreSize is the capacity of a MemoryStore created with a size of 0
+const DefaultMemoryStoreSize = 1000
+
+// MemoryStore is a Store kept in memory, evicting the least recently used trackings beyond its capacity.
+// The trackings are copied in and out, so callers changing them do not change the store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // Of *StoredTracking, the most recently used first
+	entries map[string]*list.Element
+	index   *storeIndex
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty MemoryStore holding up to size trackings, or DefaultMemoryStoreSize when size is 0.
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = DefaultMemoryStoreSize
+	}
+	return &MemoryStore{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		index:   newStoreIndex(),
+	}
+}
+
+// Get returns the stored tracking of identifier, and false when there is none
+func (s *MemoryStore) Get(ctx context.Context, identifier TrackingIdentifier) (StoredTracking, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	primary, ok, err := s.index.lookup(identifier)
+	if !ok || err != nil {
+		return StoredTracking{}, false, err
+	}
+	element := s.entries[primary]
+	s.lru.MoveToFront(element)
+	stored, err := copyStoredTracking(*element.Value.(*StoredTracking))
+	if err != nil {
+		return StoredTracking{}, false, err
+	}
+	return stored, true, nil
+}
+
+// Put stores a tracking, evicting the least recently used one when the store is full
+func (s *MemoryStore) Put(ctx context.Context, stored StoredTracking) error {
+	stored, err := copyStoredTracking(stored)
+	if err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	primary, replaced, err := s.index.add(stored.Tracking)
+	if err != nil {
+		return errors.Wrap(err, "error storing tracking")
+	}
+	for _, key := range replaced {
+		s.lru.Remove(s.entries[key])
+		delete(s.entries, key)
+	}
+
+	if element, ok := s.entries[primary]; ok {
+		element.Value = &stored
+		s.lru.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[primary] = s.lru.PushFront(&stored)
+	for s.lru.Len() > s.size {
+		oldest := s.lru.Back()
+		keys, _ := trackingKeys(oldest.Value.(*StoredTracking).Tracking)
+		s.index.remove(keys[0])
+		delete(s.entries, keys[0])
+		s.lru.Remove(oldest)
+	}
+	return nil
+}
+
+// Delete removes the tracking of identifier, if any
+func (s *MemoryStore) Delete(ctx context.Context, identifier TrackingIdentifier) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	primary, ok, err := s.index.lookup(identifier)
+	if !ok || err != nil {
+		return err
+	}
+	s.index.remove(primary)
+	s.lru.Remove(s.entries[primary])
+	delete(s.entries, primary)
+	return nil
+}
+
+// ListByOrderID returns the stored trackings of an order
+func (s *MemoryStore) ListByOrderID(ctx context.Context, orderID string) ([]StoredTracking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var trackings []StoredTracking
+	for _, primary := range s.index.list(orderID) {
+		stored, err := copyStoredTracking(*s.entries[primary].Value.(*StoredTracking))
+		if err != nil {
+			return nil, err
+		}
+		trackings = append(trackings, stored)
+	}
+	return trackings, nil
+}
+
+// Len returns the number of stored trackings
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
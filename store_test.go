@@ -0,0 +1,164 @@
+package aftership
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var storeTestTime = time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+
+func storedTracking(id string, slug string, trackingNumber string, orderID string) StoredTracking {
+	return StoredTracking{
+		Tracking: Tracking{ID: id, Slug: slug, TrackingNumber: trackingNumber, OrderID: orderID},
+		StoredAt: storeTestTime,
+	}
+}
+
+// testStore checks the behavior shared by every Store
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	assert.Nil(t, store.Put(ctx, storedTracking("1", "ups", "1Z999AA10123456784", "1001")))
+	assert.Nil(t, store.Put(ctx, storedTracking("2", "usps", "9400100000000000000000", "1001")))
+	assert.Nil(t, store.Put(ctx, storedTracking("3", "dhl", "1234567890", "1002")))
+
+	stored, ok, err := store.Get(ctx, TrackingID("1"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1Z999AA10123456784", stored.Tracking.TrackingNumber)
+	assert.True(t, storeTestTime.Equal(stored.StoredAt))
+
+	stored, ok, err = store.Get(ctx, SlugTrackingNumber{Slug: "dhl", TrackingNumber: "1234567890"})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "3", stored.Tracking.ID)
+
+	_, ok, err = store.Get(ctx, TrackingID("4"))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, _, err = store.Get(ctx, TrackingID(""))
+	assert.NotNil(t, err)
+
+	order, err := store.ListByOrderID(ctx, "1001")
+	assert.Nil(t, err)
+	if assert.Len(t, order, 2) {
+		assert.Equal(t, "1", order[0].Tracking.ID)
+		assert.Equal(t, "2", order[1].Tracking.ID)
+	}
+
+	// Changing the slug and order of a tracking moves it
+	assert.Nil(t, store.Put(ctx, storedTracking("1", "fedex", "1Z999AA10123456784", "1002")))
+	_, ok, _ = store.Get(ctx, SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"})
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, SlugTrackingNumber{Slug: "fedex", TrackingNumber: "1Z999AA10123456784"})
+	assert.True(t, ok)
+	order, _ = store.ListByOrderID(ctx, "1001")
+	assert.Len(t, order, 1)
+	order, _ = store.ListByOrderID(ctx, "1002")
+	assert.Len(t, order, 2)
+
+	// A tracking stored without ID is replaced once its ID is known
+	assert.Nil(t, store.Put(ctx, storedTracking("", "royal-mail", "RR123456785GB", "")))
+	assert.Nil(t, store.Put(ctx, storedTracking("5", "royal-mail", "RR123456785GB", "")))
+	stored, ok, _ = store.Get(ctx, SlugTrackingNumber{Slug: "royal-mail", TrackingNumber: "RR123456785GB"})
+	assert.True(t, ok)
+	assert.Equal(t, "5", stored.Tracking.ID)
+
+	assert.Nil(t, store.Delete(ctx, SlugTrackingNumber{Slug: "dhl", TrackingNumber: "1234567890"}))
+	assert.Nil(t, store.Delete(ctx, TrackingID("4")))
+	_, ok, _ = store.Get(ctx, TrackingID("3"))
+	assert.False(t, ok)
+
+	assert.NotNil(t, store.Put(ctx, StoredTracking{}))
+
+	// Changing the put and got trackings does not change the stored tracking
+	put := storedTracking("6", "ups", "1Z999AA10123456785", "1003")
+	put.Tracking.Emails = []string{"a@example.com"}
+	put.Tracking.Checkpoints = []Checkpoint{{Message: "Picked up"}}
+	assert.Nil(t, store.Put(ctx, put))
+	put.Tracking.Emails[0] = "mutated"
+
+	got, _, _ := store.Get(ctx, TrackingID("6"))
+	got.Tracking.Emails[0] = "mutated"
+	got.Tracking.Checkpoints[0].Message = "mutated"
+	order, _ = store.ListByOrderID(ctx, "1003")
+	order[0].Tracking.Emails[0] = "mutated"
+
+	got, _, _ = store.Get(ctx, TrackingID("6"))
+	assert.Equal(t, []string{"a@example.com"}, got.Tracking.Emails)
+	assert.Equal(t, "Picked up", got.Tracking.Checkpoints[0].Message)
+	assert.Nil(t, store.Delete(ctx, TrackingID("6")))
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(0)
+	testStore(t, store)
+	assert.Equal(t, 3, store.Len())
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+	store.Put(ctx, storedTracking("1", "ups", "1", "1001"))
+	store.Put(ctx, storedTracking("2", "ups", "2", "1001"))
+
+	// Getting 1 makes 2 the least recently used
+	_, ok, _ := store.Get(ctx, TrackingID("1"))
+	assert.True(t, ok)
+	store.Put(ctx, storedTracking("3", "ups", "3", "1001"))
+
+	assert.Equal(t, 2, store.Len())
+	_, ok, _ = store.Get(ctx, SlugTrackingNumber{Slug: "ups", TrackingNumber: "2"})
+	assert.False(t, ok)
+	order, _ := store.ListByOrderID(ctx, "1001")
+	assert.Len(t, order, 2)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aftership-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	assert.Nil(t, err)
+	testStore(t, store)
+
+	// The trackings survive reopening the store
+	reopened, err := NewFileStore(dir)
+	assert.Nil(t, err)
+	stored, ok, err := reopened.Get(context.Background(), SlugTrackingNumber{Slug: "fedex", TrackingNumber: "1Z999AA10123456784"})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", stored.Tracking.ID)
+	order, err := reopened.ListByOrderID(context.Background(), "1001")
+	assert.Nil(t, err)
+	assert.Len(t, order, 1)
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 3)
+}
+
+func TestFileStoreFailedPut(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aftership-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, os.RemoveAll(dir))
+
+	// A tracking whose file could not be written is missing, not unreadable
+	ctx := context.Background()
+	assert.NotNil(t, store.Put(ctx, storedTracking("1", "ups", "1Z999AA10123456784", "1001")))
+	_, ok, err := store.Get(ctx, TrackingID("1"))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	order, err := store.ListByOrderID(ctx, "1001")
+	assert.Nil(t, err)
+	assert.Empty(t, order)
+}
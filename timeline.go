@@ -0,0 +1,289 @@
+package aftership
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ZoneSource is where the time zone of a TimelineCheckpoint comes from
+type ZoneSource string
+
+// Zone sources, from the most to the least reliable
+const (
+	ZoneSourceCheckpoint ZoneSource = "checkpoint" // CheckpointTime has a time zone
+	ZoneSourceNeighbor   ZoneSource = "neighbor"   // The nearest checkpoint with a time zone in the same country
+	ZoneSourceCountry    ZoneSource = "country"    // The time zone of the country of the checkpoint
+	ZoneSourceDefault    ZoneSource = "default"    // TimelineOptions.Location
+)
+
+// AnomalyType is the kind of a TimelineAnomaly
+type AnomalyType string
+
+// Anomaly types
+const (
+	// AnomalyDuplicate is a checkpoint equal to a previous one. It is removed from the timeline.
+	AnomalyDuplicate AnomalyType = "duplicate"
+
+	// AnomalyMissingTime is a checkpoint without a valid time. It is removed from the timeline.
+	AnomalyMissingTime AnomalyType = "missing_time"
+
+	// AnomalyTimeBackwards is a checkpoint sent by the carrier before a checkpoint with a later time
+	AnomalyTimeBackwards AnomalyType = "time_backwards"
+
+	// AnomalyTagRegression is a checkpoint whose tag goes back in the delivery, e.g. InTransit after Delivered
+	AnomalyTagRegression AnomalyType = "tag_regression"
+)
+
+// TimelineCheckpoint is a checkpoint with its time normalized to UTC
+type TimelineCheckpoint struct {
+	Checkpoint
+	Time       time.Time  `json:"time"`        // The time of the checkpoint in UTC
+	ZoneSource ZoneSource `json:"zone_source"` // Where the time zone of Time comes from
+}
+
+// TimelineAnomaly is an inconsistency found in the checkpoints of a tracking
+type TimelineAnomaly struct {
+	Type       AnomalyType `json:"type"`
+	Checkpoint Checkpoint  `json:"checkpoint"`
+	Message    string      `json:"message"`
+}
+
+// TimelineLeg is a stay of the shipment at a location, from its first checkpoint there to the first checkpoint at the next location
+type TimelineLeg struct {
+	Location    string               `json:"location"`
+	CountryISO3 string               `json:"country_iso3,omitempty"`
+	Arrived     time.Time            `json:"arrived"`
+	Departed    time.Time            `json:"departed"` // The arrival at the next location, or the last checkpoint of the last leg
+	Dwell       time.Duration        `json:"dwell"`
+	Checkpoints []TimelineCheckpoint `json:"checkpoints"`
+}
+
+// Timeline is the normalized checkpoint history of a tracking
+type Timeline struct {
+	Checkpoints []TimelineCheckpoint `json:"checkpoints"` // Sorted by time, without duplicates
+	Legs        []TimelineLeg        `json:"legs"`
+	Anomalies   []TimelineAnomaly    `json:"anomalies"`
+}
+
+// TimelineOptions configures NewTimeline. The zero value uses the defaults.
+type TimelineOptions struct {
+	// Location is the time zone of checkpoints whose time zone cannot be found otherwise. Defaults to UTC.
+	Location *time.Location
+}
+
+// countryTimeZones are the time zones of countries, to read the local CheckpointTime values without time zone.
+// Only countries with a single time zone, or a clearly dominant one, are listed.
+var countryTimeZones = map[string]string{
+	"ARE": "Asia/Dubai", "AUT": "Europe/Vienna", "BEL": "Europe/Brussels", "CHE": "Europe/Zurich",
+	"CHN": "Asia/Shanghai", "CZE": "Europe/Prague", "DEU": "Europe/Berlin", "DNK": "Europe/Copenhagen",
+	"ESP": "Europe/Madrid", "FIN": "Europe/Helsinki", "FRA": "Europe/Paris", "GBR": "Europe/London",
+	"GRC": "Europe/Athens", "HKG": "Asia/Hong_Kong", "HUN": "Europe/Budapest", "IND": "Asia/Kolkata",
+	"IRL": "Europe/Dublin", "ISR": "Asia/Jerusalem", "ITA": "Europe/Rome", "JPN": "Asia/Tokyo",
+	"KOR": "Asia/Seoul", "MAC": "Asia/Macau", "MYS": "Asia/Kuala_Lumpur", "NLD": "Europe/Amsterdam",
+	"NOR": "Europe/Oslo", "NZL": "Pacific/Auckland", "PHL": "Asia/Manila", "POL": "Europe/Warsaw",
+	"PRT": "Europe/Lisbon", "SGP": "Asia/Singapore", "SWE": "Europe/Stockholm", "THA": "Asia/Bangkok",
+	"TUR": "Europe/Istanbul", "TWN": "Asia/Taipei", "VNM": "Asia/Ho_Chi_Minh",
+}
+
+// tagRanks orders the tags of checkpoints along the delivery. Exception and Expired have no rank.
+var tagRanks = map[string]int{
+	TagPending:            1,
+	TagInfoReceived:       1,
+	TagInTransit:          2,
+	TagOutForDelivery:     3,
+	TagAttemptFail:        3,
+	TagAvailableForPickup: 4,
+	TagDelivered:          5,
+}
+
+// NewTimeline builds the timeline of checkpoints. Checkpoints are normalized to UTC, sorted by time
+// and de-duplicated, then grouped by location into legs. Duplicates, invalid times and times going backwards
+// are reported in the order the carrier sent the checkpoints, followed by the tag regressions of the sorted timeline.
+//
+// CheckpointTime values without a time zone are local to the checkpoint. Their zone is taken from the nearest
+// checkpoint with a time zone in the same country, then from the country, then from options.Location.
+func NewTimeline(checkpoints []Checkpoint, options TimelineOptions) Timeline {
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+
+	var timeline Timeline
+	normalized := make([]TimelineCheckpoint, 0, len(checkpoints))
+	seen := make(map[string]bool, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		t, source, ok := normalizeCheckpointTime(checkpoints, i, options.Location)
+		if !ok {
+			timeline.addAnomaly(AnomalyMissingTime, checkpoint, fmt.Sprintf("invalid checkpoint time %q", checkpoint.CheckpointTime))
+			continue
+		}
+
+		key := timelineKey(checkpoint, t)
+		if seen[key] {
+			timeline.addAnomaly(AnomalyDuplicate, checkpoint, "duplicate of a previous checkpoint")
+			continue
+		}
+		seen[key] = true
+
+		if n := len(normalized); n > 0 && t.Before(normalized[n-1].Time) {
+			timeline.addAnomaly(AnomalyTimeBackwards, checkpoint,
+				fmt.Sprintf("time is %s before the previous checkpoint", normalized[n-1].Time.Sub(t)))
+		}
+		normalized = append(normalized, TimelineCheckpoint{Checkpoint: checkpoint, Time: t, ZoneSource: source})
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return normalized[i].Time.Before(normalized[j].Time)
+	})
+	timeline.Checkpoints = normalized
+
+	highest := ""
+	for _, checkpoint := range normalized {
+		rank, ok := tagRanks[checkpoint.Tag]
+		if !ok {
+			continue
+		}
+		if highestRank := tagRanks[highest]; rank < highestRank && (highest == TagDelivered || rank == tagRanks[TagInfoReceived]) {
+			timeline.addAnomaly(AnomalyTagRegression, checkpoint.Checkpoint,
+				fmt.Sprintf("tag %s after %s", checkpoint.Tag, highest))
+			continue
+		}
+		if rank > tagRanks[highest] {
+			highest = checkpoint.Tag
+		}
+	}
+
+	timeline.Legs = timelineLegs(normalized)
+	return timeline
+}
+
+func (timeline *Timeline) addAnomaly(anomalyType AnomalyType, checkpoint Checkpoint, message string) {
+	timeline.Anomalies = append(timeline.Anomalies, TimelineAnomaly{
+		Type:       anomalyType,
+		Checkpoint: checkpoint,
+		Message:    message,
+	})
+}
+
+// normalizeCheckpointTime returns the UTC time of checkpoints[i] and where its time zone comes from
+func normalizeCheckpointTime(checkpoints []Checkpoint, i int, location *time.Location) (time.Time, ZoneSource, bool) {
+	checkpoint := checkpoints[i]
+	if t, err := time.Parse(time.RFC3339, checkpoint.CheckpointTime); err == nil {
+		return t.UTC(), ZoneSourceCheckpoint, true
+	}
+
+	local, err := time.Parse("2006-01-02T15:04:05", checkpoint.CheckpointTime)
+	if err != nil {
+		if checkpoint.CreatedAt == nil {
+			return time.Time{}, "", false
+		}
+		// AfterShip records every checkpoint shortly after the carrier scan
+		return checkpoint.CreatedAt.UTC(), ZoneSourceCheckpoint, true
+	}
+
+	if offset, ok := neighborOffset(checkpoints, i); ok {
+		return local.Add(-time.Duration(offset) * time.Second), ZoneSourceNeighbor, true
+	}
+
+	if name, ok := countryTimeZones[checkpoint.CountryISO3]; ok {
+		if zone, err := time.LoadLocation(name); err == nil {
+			return inLocation(local, zone).UTC(), ZoneSourceCountry, true
+		}
+	}
+
+	return inLocation(local, location).UTC(), ZoneSourceDefault, true
+}
+
+// neighborOffset returns the UTC offset in seconds of the nearest checkpoint with a time zone in the same country.
+// Checkpoints in UTC are skipped.
+func neighborOffset(checkpoints []Checkpoint, i int) (int, bool) {
+	country := checkpoints[i].CountryISO3
+	if country == "" {
+		return 0, false
+	}
+
+	for distance := 1; distance < len(checkpoints); distance++ {
+		for _, j := range []int{i - distance, i + distance} {
+			if j < 0 || j >= len(checkpoints) || checkpoints[j].CountryISO3 != country {
+				continue
+			}
+			// A UTC time tells nothing about the local time zone
+			if strings.HasSuffix(checkpoints[j].CheckpointTime, "Z") {
+				continue
+			}
+			if t, err := time.Parse(time.RFC3339, checkpoints[j].CheckpointTime); err == nil {
+				_, offset := t.Zone()
+				return offset, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// inLocation returns the time with the wall clock of local in location
+func inLocation(local time.Time, location *time.Location) time.Time {
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), location)
+}
+
+// DisplayLocation returns the location of checkpoint from its most precise field: Location,
+// else its city, state and country name, else its country ISO3 code.
+func (checkpoint Checkpoint) DisplayLocation() string {
+	if checkpoint.Location != "" {
+		return checkpoint.Location
+	}
+
+	var parts []string
+	for _, part := range []string{checkpoint.City, checkpoint.State, checkpoint.CountryName} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, ", ")
+	}
+	return checkpoint.CountryISO3
+}
+
+// timelineKey identifies duplicate checkpoints, ignoring the case and spacing of their text
+func timelineKey(checkpoint Checkpoint, t time.Time) string {
+	return strings.Join([]string{
+		t.Format(time.RFC3339), checkpoint.Tag, checkpoint.Subtag,
+		normalizeTimelineText(checkpoint.DisplayLocation()), normalizeTimelineText(checkpoint.Message),
+	}, "\x00")
+}
+
+func normalizeTimelineText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// timelineLegs groups consecutive checkpoints at the same location.
+// Checkpoints without a location belong to the current leg.
+func timelineLegs(checkpoints []TimelineCheckpoint) []TimelineLeg {
+	var legs []TimelineLeg
+	for _, checkpoint := range checkpoints {
+		location := checkpoint.Checkpoint.DisplayLocation()
+		n := len(legs)
+		if n > 0 && (location == "" || normalizeTimelineText(location) == normalizeTimelineText(legs[n-1].Location)) {
+			legs[n-1].Checkpoints = append(legs[n-1].Checkpoints, checkpoint)
+			continue
+		}
+
+		legs = append(legs, TimelineLeg{
+			Location:    location,
+			CountryISO3: checkpoint.CountryISO3,
+			Arrived:     checkpoint.Time,
+			Checkpoints: []TimelineCheckpoint{checkpoint},
+		})
+	}
+
+	for i := range legs {
+		if i+1 < len(legs) {
+			legs[i].Departed = legs[i+1].Arrived
+		} else {
+			legs[i].Departed = legs[i].Checkpoints[len(legs[i].Checkpoints)-1].Time
+		}
+		legs[i].Dwell = legs[i].Departed.Sub(legs[i].Arrived)
+	}
+	return legs
+}
@@ -0,0 +1,88 @@
+package aftership
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTimeline(t *testing.T) {
+	createdAt := time.Date(2022, 11, 3, 2, 0, 0, 0, time.UTC)
+	checkpoints := []Checkpoint{
+		{CheckpointTime: "2022-11-01T10:00:00", Tag: TagInfoReceived, Message: "Label created", CountryISO3: "HKG"},
+		{CheckpointTime: "2022-11-01T18:00:00+08:00", Tag: TagInTransit, Message: "Picked up", Location: "Hong Kong Hub", CountryISO3: "HKG"},
+		{CheckpointTime: "2022-11-01T18:00:00+08:00", Tag: TagInTransit, Message: "picked  up", Location: "HONG KONG HUB", CountryISO3: "HKG"},
+		{CheckpointTime: "2022-11-02T06:00:00+08:00", Tag: TagInTransit, Message: "Departed", Location: "Hong Kong Hub", CountryISO3: "HKG"},
+		{CheckpointTime: "2022-11-03T08:00:00", Tag: TagInTransit, Message: "Arrived", Location: "Sydney Gateway", CountryISO3: "AUS"},
+		{CheckpointTime: "2022-11-02T20:00:00", Tag: TagInTransit, Message: "Customs cleared", City: "Sydney", CountryISO3: "AUS"},
+		{CheckpointTime: "", Tag: TagInTransit, Message: "Sorted"},
+		{CheckpointTime: "tomorrow", Tag: TagOutForDelivery, Message: "Out for delivery", CreatedAt: &createdAt},
+		{CheckpointTime: "2022-11-03T14:00:00Z", Tag: TagDelivered, Message: "Delivered", City: "Sydney", CountryISO3: "AUS"},
+		{CheckpointTime: "2022-11-03T15:00:00Z", Tag: TagInTransit, Message: "Processed", City: "Sydney", CountryISO3: "AUS"},
+	}
+
+	timeline := NewTimeline(checkpoints, TimelineOptions{Location: time.FixedZone("AEDT", 11*60*60)})
+
+	var messages []string
+	var times []time.Time
+	var sources []ZoneSource
+	for _, checkpoint := range timeline.Checkpoints {
+		messages = append(messages, checkpoint.Message)
+		times = append(times, checkpoint.Time)
+		sources = append(sources, checkpoint.ZoneSource)
+	}
+	assert.Equal(t, []string{"Label created", "Picked up", "Departed", "Customs cleared", "Arrived", "Out for delivery", "Delivered", "Processed"}, messages)
+	assert.Equal(t, time.Date(2022, 11, 1, 2, 0, 0, 0, time.UTC), times[0])
+	assert.Equal(t, time.Date(2022, 11, 2, 9, 0, 0, 0, time.UTC), times[3])
+	assert.Equal(t, []ZoneSource{
+		ZoneSourceNeighbor, ZoneSourceCheckpoint, ZoneSourceCheckpoint, ZoneSourceDefault,
+		ZoneSourceDefault, ZoneSourceCheckpoint, ZoneSourceCheckpoint, ZoneSourceCheckpoint,
+	}, sources)
+
+	var anomalies []AnomalyType
+	for _, anomaly := range timeline.Anomalies {
+		anomalies = append(anomalies, anomaly.Type)
+	}
+	assert.Equal(t, []AnomalyType{AnomalyDuplicate, AnomalyTimeBackwards, AnomalyMissingTime, AnomalyTagRegression}, anomalies)
+	assert.Equal(t, "Customs cleared", timeline.Anomalies[1].Checkpoint.Message)
+	assert.Equal(t, "time is 12h0m0s before the previous checkpoint", timeline.Anomalies[1].Message)
+	assert.Equal(t, "tag InTransit after Delivered", timeline.Anomalies[3].Message)
+
+	var locations []string
+	var dwells []time.Duration
+	for _, leg := range timeline.Legs {
+		locations = append(locations, leg.Location)
+		dwells = append(dwells, leg.Dwell)
+	}
+	assert.Equal(t, []string{"HKG", "Hong Kong Hub", "Sydney", "Sydney Gateway", "Sydney"}, locations)
+	assert.Equal(t, []time.Duration{8 * time.Hour, 23 * time.Hour, 12 * time.Hour, 17 * time.Hour, time.Hour}, dwells)
+	assert.Len(t, timeline.Legs[3].Checkpoints, 2)
+	assert.Len(t, timeline.Legs[4].Checkpoints, 2)
+}
+
+func TestNewTimelineCountryZone(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	timeline := NewTimeline([]Checkpoint{
+		{CheckpointTime: "2022-11-01T09:00:00", Tag: TagInTransit, CountryISO3: "JPN"},
+	}, TimelineOptions{})
+	assert.Equal(t, ZoneSourceCountry, timeline.Checkpoints[0].ZoneSource)
+	assert.Equal(t, time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC), timeline.Checkpoints[0].Time)
+}
+
+func TestNewTimelineEmpty(t *testing.T) {
+	timeline := NewTimeline(nil, TimelineOptions{})
+	assert.Empty(t, timeline.Checkpoints)
+	assert.Empty(t, timeline.Legs)
+	assert.Empty(t, timeline.Anomalies)
+}
+
+func TestCheckpointDisplayLocation(t *testing.T) {
+	assert.Equal(t, "Louisville, KY", Checkpoint{Location: "Louisville, KY", City: "Louisville"}.DisplayLocation())
+	assert.Equal(t, "Louisville, KY, USA", Checkpoint{City: "Louisville", State: "KY", CountryName: "USA"}.DisplayLocation())
+	assert.Equal(t, "USA", Checkpoint{CountryISO3: "USA"}.DisplayLocation())
+	assert.Equal(t, "", Checkpoint{}.DisplayLocation())
+}
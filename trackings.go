@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/aftership/aftership-sdk-go/v2/checkdigit"
 	"github.com/pkg/errors"
 )
 
@@ -683,6 +684,11 @@ type GetTrackingsParams struct {
 	 */
 	Page int `url:"page,omitempty" json:"page,omitempty"`
 
+	/**
+	 * Cursor of the page to show, the NextCursor of the previous page. Date-versioned APIs only, replacing Page.
+	 */
+	Cursor string `url:"cursor,omitempty" json:"cursor,omitempty"`
+
 	/**
 	 * Select return to sender, the value should be true or false,
 	 * with optional comma separated.
@@ -729,6 +735,9 @@ type PagedTrackings struct {
 	Count     int        `json:"count"`     // Total number of matched trackings, max. number is 10,000
 	Page      int        `json:"page"`      // Page to show. (Default: 1)
 	Trackings []Tracking `json:"trackings"` // Array of Hash describes the tracking information.
+
+	NextCursor  string `json:"next_cursor,omitempty"`   // Cursor of the next page. Date-versioned APIs only.
+	HasNextPage bool   `json:"has_next_page,omitempty"` // Whether there is a next page. Date-versioned APIs only.
 }
 
 // trackingWrapper is a model for data part of the single tracking API responses
@@ -736,6 +745,19 @@ type trackingWrapper struct {
 	Tracking Tracking `json:"tracking"`
 }
 
+// Tags are the main delivery statuses of a tracking or a checkpoint
+const (
+	TagPending            = "Pending"
+	TagInfoReceived       = "InfoReceived"
+	TagInTransit          = "InTransit"
+	TagOutForDelivery     = "OutForDelivery"
+	TagAttemptFail        = "AttemptFail"
+	TagDelivered          = "Delivered"
+	TagAvailableForPickup = "AvailableForPickup"
+	TagException          = "Exception"
+	TagExpired            = "Expired"
+)
+
 // TrackingCompletedStatus is status to make the tracking as completed
 type TrackingCompletedStatus string
 
@@ -759,42 +781,71 @@ func (client *Client) CreateTracking(ctx context.Context, params CreateTrackingP
 		return Tracking{}, errors.New(errMissingTrackingNumber)
 	}
 
+	if client.Config.ValidateTrackingNumbers {
+		if err := checkdigit.Validate(params.Slug, params.TrackingNumber); err != nil {
+			return Tracking{}, &ValidationError{
+				Slug:   params.Slug,
+				Fields: []FieldError{{Field: "tracking_number", Message: err.Error()}},
+			}
+		}
+	}
+
+	emails, smses, err := client.normalizeContacts(params.Emails, params.SMSes)
+	if err != nil {
+		return Tracking{}, err
+	}
+	params.Emails, params.SMSes = emails, smses
+
+	var body interface{} = &createTrackingRequest{Tracking: params}
+	if client.Config.APIVersion.dated() {
+		body, err = datedTrackingBody(params, params.Emails, params.SMSes, params.CustomerName)
+		if err != nil {
+			return Tracking{}, err
+		}
+	}
+
 	var trackingWrapper trackingWrapper
-	err := client.makeRequest(ctx, http.MethodPost, "/trackings", nil,
-		&createTrackingRequest{Tracking: params}, &trackingWrapper)
+	err = client.makeRequest(ctx, http.MethodPost, "/trackings", nil, body, &trackingWrapper)
 	return trackingWrapper.Tracking, err
 }
 
 // DeleteTracking deletes a tracking.
 func (client *Client) DeleteTracking(ctx context.Context, identifier TrackingIdentifier) (Tracking, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error deleting tracking")
 	if err != nil {
-		return Tracking{}, errors.Wrap(err, "error deleting tracking")
+		return Tracking{}, err
 	}
 
 	uriPath = fmt.Sprintf("/trackings%s", uriPath)
 	var trackingWrapper trackingWrapper
 	err = client.makeRequest(ctx, http.MethodDelete, uriPath, nil, nil, &trackingWrapper)
+	if err == nil {
+		client.forgetTrackingID(identifier)
+	}
 	return trackingWrapper.Tracking, err
 }
 
 // GetTrackings gets tracking results of multiple trackings.
 func (client *Client) GetTrackings(ctx context.Context, params GetTrackingsParams) (PagedTrackings, error) {
+	if client.Config.APIVersion.dated() {
+		params.Page = 0
+	}
+
 	var pagedTrackings PagedTrackings
-	err := client.makeRequest(ctx, http.MethodGet, "/trackings", params, nil, &pagedTrackings)
+	err := client.makeReadRequest(ctx, "/trackings", params, &pagedTrackings)
 	return pagedTrackings, err
 }
 
 // GetTracking gets tracking results of a single tracking.
 func (client *Client) GetTracking(ctx context.Context, identifier TrackingIdentifier, params GetTrackingParams) (Tracking, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error getting tracking")
 	if err != nil {
-		return Tracking{}, errors.Wrap(err, "error getting tracking")
+		return Tracking{}, err
 	}
 
 	uriPath = fmt.Sprintf("/trackings%s", uriPath)
 	var trackingWrapper trackingWrapper
-	err = client.makeRequest(ctx, http.MethodGet, uriPath, params, nil, &trackingWrapper)
+	err = client.makeReadRequest(ctx, uriPath, params, &trackingWrapper)
 	return trackingWrapper.Tracking, err
 }
 
@@ -805,23 +856,38 @@ type updateTrackingRequest struct {
 
 // UpdateTracking updates a tracking.
 func (client *Client) UpdateTracking(ctx context.Context, identifier TrackingIdentifier, params UpdateTrackingParams) (Tracking, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error updating tracking")
+	if err != nil {
+		return Tracking{}, err
+	}
+
+	params.Emails, params.SMSes, err = client.normalizeContacts(params.Emails, params.SMSes)
 	if err != nil {
-		return Tracking{}, errors.Wrap(err, "error updating tracking")
+		return Tracking{}, err
 	}
 
 	uriPath = fmt.Sprintf("/trackings%s", uriPath)
+	var body interface{} = &updateTrackingRequest{params}
+	if client.Config.APIVersion.dated() {
+		body, err = datedTrackingBody(params, params.Emails, params.SMSes, params.CustomerName)
+		if err != nil {
+			return Tracking{}, err
+		}
+	}
+
 	var trackingWrapper trackingWrapper
-	err = client.makeRequest(ctx, http.MethodPut, uriPath, nil,
-		&updateTrackingRequest{params}, &trackingWrapper)
+	err = client.makeRequest(ctx, http.MethodPut, uriPath, nil, body, &trackingWrapper)
+	if err == nil && params.Slug != "" {
+		client.forgetTrackingID(identifier)
+	}
 	return trackingWrapper.Tracking, err
 }
 
 // RetrackTracking retracks an expired tracking. Max 3 times per tracking.
 func (client *Client) RetrackTracking(ctx context.Context, identifier TrackingIdentifier) (Tracking, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error retracking")
 	if err != nil {
-		return Tracking{}, errors.Wrap(err, "error retracking")
+		return Tracking{}, err
 	}
 
 	uriPath = fmt.Sprintf("/trackings%s/retrack", uriPath)
@@ -837,9 +903,9 @@ type markAsCompletedRequest struct {
 
 // MarkTrackingAsCompleted marks a tracking as completed. The tracking won't auto update until retrack it.
 func (client *Client) MarkTrackingAsCompleted(ctx context.Context, identifier TrackingIdentifier, status TrackingCompletedStatus) (Tracking, error) {
-	uriPath, err := identifier.URIPath()
+	uriPath, err := client.identifierPath(ctx, identifier, "error marking tracking as completed")
 	if err != nil {
-		return Tracking{}, errors.Wrap(err, "error marking tracking as completed")
+		return Tracking{}, err
 	}
 
 	uriPath = fmt.Sprintf("/trackings%s/mark-as-completed", uriPath)
@@ -163,6 +163,45 @@ func TestCreateTrackingError(t *testing.T) {
 	assert.Equal(t, errMissingTrackingNumber, err.Error())
 }
 
+func TestCreateTrackingValidateTrackingNumber(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/trackings", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"meta": {"code": 201}, "data": {"tracking": {"slug": "ups", "tracking_number": "1Z999AA10123456784"}}}`))
+	})
+
+	client.Config.ValidateTrackingNumbers = true
+
+	_, err := client.CreateTracking(context.Background(), CreateTrackingParams{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456785",
+	})
+	assert.Equal(t, &ValidationError{
+		Slug:   "ups",
+		Fields: []FieldError{{Field: "tracking_number", Message: "tracking number has an invalid check digit"}},
+	}, err)
+	assert.Equal(t, 0, calls)
+
+	res, err := client.CreateTracking(context.Background(), CreateTrackingParams{
+		Slug:           "ups",
+		TrackingNumber: "1Z999AA10123456784",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1Z999AA10123456784", res.TrackingNumber)
+	assert.Equal(t, 1, calls)
+
+	// auto detected couriers are not checked
+	_, err = client.CreateTracking(context.Background(), CreateTrackingParams{
+		TrackingNumber: "1Z999AA10123456785",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+}
+
 func TestDeleteTracking(t *testing.T) {
 	setup()
 	defer teardown()
@@ -0,0 +1,318 @@
+package aftership
+
+import (
+	"container/heap"
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TrackingEventType is the kind of change reported by a Watcher
+type TrackingEventType string
+
+// Tracking event types
+const (
+	// TrackingEventNewCheckpoint is emitted once for every checkpoint not seen in the previous poll
+	TrackingEventNewCheckpoint TrackingEventType = "new_checkpoint"
+
+	// TrackingEventTagChanged is emitted when the tag of the tracking changes
+	TrackingEventTagChanged TrackingEventType = "tag_changed"
+
+	// TrackingEventEstimatedDeliveryChanged is emitted when the latest estimated delivery changes
+	TrackingEventEstimatedDeliveryChanged TrackingEventType = "estimated_delivery_changed"
+
+	// TrackingEventStopped is emitted when the tracking reaches a terminal tag or becomes inactive.
+	// The tracking is not polled anymore.
+	TrackingEventStopped TrackingEventType = "stopped"
+
+	// TrackingEventError is emitted when polling the tracking fails. The tracking is polled again later.
+	TrackingEventError TrackingEventType = "error"
+)
+
+// TrackingEvent is a change of a watched tracking
+type TrackingEvent struct {
+	Type       TrackingEventType
+	Identifier TrackingIdentifier
+	Tracking   Tracking // The tracking as of the poll that found the change
+
+	Checkpoint *Checkpoint // The new checkpoint of TrackingEventNewCheckpoint
+
+	PreviousTag string // The tag before TrackingEventTagChanged
+
+	PreviousEstimatedDelivery LatestEstimatedDelivery // The estimated delivery before TrackingEventEstimatedDeliveryChanged
+
+	Err error // The error of TrackingEventError
+}
+
+// DefaultWatchIntervals are the default polling intervals by tag of a Watcher
+var DefaultWatchIntervals = map[string]time.Duration{
+	TagPending:            2 * time.Hour,
+	TagInfoReceived:       4 * time.Hour,
+	TagInTransit:          time.Hour,
+	TagOutForDelivery:     10 * time.Minute,
+	TagAttemptFail:        30 * time.Minute,
+	TagAvailableForPickup: 2 * time.Hour,
+	TagException:          time.Hour,
+}
+
+// DefaultWatchInterval is the polling interval of tags without an interval of their own
+const DefaultWatchInterval = time.Hour
+
+// WatcherOptions configures a Watcher. The zero value uses the defaults.
+type WatcherOptions struct {
+	// Intervals are the polling intervals by tag of the tracking. Defaults to DefaultWatchIntervals.
+	Intervals map[string]time.Duration
+
+	// DefaultInterval is the polling interval of tags missing from Intervals. Defaults to DefaultWatchInterval.
+	DefaultInterval time.Duration
+
+	// TerminalTags stop the polling of a tracking. Defaults to Delivered and Expired.
+	// Inactive trackings are always stopped.
+	TerminalTags []string
+
+	// MinRequestInterval is the minimum time between two API calls of the Watcher. Defaults to 200ms.
+	MinRequestInterval time.Duration
+
+	// RateLimitBackoff is the minimum wait before polling a tracking again after a TooManyRequestsError,
+	// the rate limit reset being waited for when known. Defaults to 1 second.
+	RateLimitBackoff time.Duration
+
+	// Params are the params of every GetTracking call. When Fields is set, it must include
+	// active, tag, checkpoints and latest_estimated_delivery for the changes to be detected.
+	Params GetTrackingParams
+}
+
+// watchedTracking is the polling state of a single tracking
+type watchedTracking struct {
+	identifier TrackingIdentifier
+	next       time.Time
+	polled     bool
+	tracking   Tracking
+}
+
+// watchQueue is a min-heap of watched trackings by next poll time
+type watchQueue []*watchedTracking
+
+func (q watchQueue) Len() int            { return len(q) }
+func (q watchQueue) Less(i, j int) bool  { return q[i].next.Before(q[j].next) }
+func (q watchQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *watchQueue) Push(x interface{}) { *q = append(*q, x.(*watchedTracking)) }
+func (q *watchQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Watcher polls trackings with GetTracking and reports their changes.
+// Polling adapts to the tag of each tracking and stays within the API rate limit.
+type Watcher struct {
+	api     TrackingsAPI
+	options WatcherOptions
+	now     func() time.Time
+}
+
+// rateLimiter is implemented by the APIs reporting their rate limit, such as Client
+type rateLimiter interface {
+	GetRateLimit() RateLimit
+}
+
+// NewWatcher returns a Watcher polling with api, usually a Client.
+// The rate limit is only followed when api has a GetRateLimit method, as Client does.
+func NewWatcher(api TrackingsAPI, options WatcherOptions) *Watcher {
+	if options.Intervals == nil {
+		options.Intervals = DefaultWatchIntervals
+	}
+	if options.DefaultInterval <= 0 {
+		options.DefaultInterval = DefaultWatchInterval
+	}
+	if options.TerminalTags == nil {
+		options.TerminalTags = []string{TagDelivered, TagExpired}
+	}
+	if options.MinRequestInterval <= 0 {
+		options.MinRequestInterval = 200 * time.Millisecond
+	}
+	if options.RateLimitBackoff <= 0 {
+		options.RateLimitBackoff = time.Second
+	}
+
+	return &Watcher{
+		api:     api,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// Watch runs the Watcher in a goroutine and delivers the events on the returned channel.
+// The channel is closed when every tracking is stopped or ctx is done.
+func (w *Watcher) Watch(ctx context.Context, identifiers []TrackingIdentifier) <-chan TrackingEvent {
+	events := make(chan TrackingEvent)
+	go func() {
+		defer close(events)
+		w.Run(ctx, identifiers, func(event TrackingEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return events
+}
+
+// Run polls identifiers and calls handler for every event, until every tracking is stopped or ctx is done.
+// All trackings are polled right away; the first poll of a tracking only records its state.
+// Run returns ctx.Err() when ctx is done, or nil when every tracking is stopped.
+func (w *Watcher) Run(ctx context.Context, identifiers []TrackingIdentifier, handler func(TrackingEvent)) error {
+	queue := make(watchQueue, 0, len(identifiers))
+	now := w.now()
+	for _, identifier := range identifiers {
+		queue = append(queue, &watchedTracking{identifier: identifier, next: now})
+	}
+	heap.Init(&queue)
+
+	var lastRequest time.Time
+	for queue.Len() > 0 {
+		item := queue[0]
+
+		due := item.next
+		if earliest := lastRequest.Add(w.options.MinRequestInterval); due.Before(earliest) {
+			due = earliest
+		}
+		if reset := w.rateLimitReset(); due.Before(reset) {
+			due = reset
+		}
+		if err := w.sleepUntil(ctx, due); err != nil {
+			return err
+		}
+
+		lastRequest = w.now()
+		tracking, err := w.api.GetTracking(ctx, item.identifier, w.options.Params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			handler(TrackingEvent{Type: TrackingEventError, Identifier: item.identifier, Tracking: item.tracking, Err: err})
+			item.next = w.now().Add(w.interval(item.tracking))
+			var tooManyRequests *TooManyRequestsError
+			if errors.As(err, &tooManyRequests) {
+				item.next = w.now().Add(w.options.RateLimitBackoff)
+			}
+			heap.Fix(&queue, 0)
+			continue
+		}
+
+		if item.polled {
+			w.compare(item, tracking, handler)
+		}
+		item.polled = true
+		item.tracking = tracking
+
+		if w.stopped(tracking) {
+			handler(TrackingEvent{Type: TrackingEventStopped, Identifier: item.identifier, Tracking: tracking})
+			heap.Pop(&queue)
+			continue
+		}
+
+		item.next = w.now().Add(w.interval(tracking))
+		heap.Fix(&queue, 0)
+	}
+
+	return nil
+}
+
+// compare emits the events of the changes from item.tracking to tracking
+func (w *Watcher) compare(item *watchedTracking, tracking Tracking, handler func(TrackingEvent)) {
+	previous := item.tracking
+
+	seen := make(map[string]bool, len(previous.Checkpoints))
+	for _, checkpoint := range previous.Checkpoints {
+		seen[checkpointKey(checkpoint)] = true
+	}
+	for i := range tracking.Checkpoints {
+		if seen[checkpointKey(tracking.Checkpoints[i])] {
+			continue
+		}
+		checkpoint := tracking.Checkpoints[i]
+		handler(TrackingEvent{
+			Type:       TrackingEventNewCheckpoint,
+			Identifier: item.identifier,
+			Tracking:   tracking,
+			Checkpoint: &checkpoint,
+		})
+	}
+
+	if previous.Tag != tracking.Tag {
+		handler(TrackingEvent{
+			Type:        TrackingEventTagChanged,
+			Identifier:  item.identifier,
+			Tracking:    tracking,
+			PreviousTag: previous.Tag,
+		})
+	}
+
+	if !reflect.DeepEqual(previous.LatestEstimatedDelivery, tracking.LatestEstimatedDelivery) {
+		handler(TrackingEvent{
+			Type:                      TrackingEventEstimatedDeliveryChanged,
+			Identifier:                item.identifier,
+			Tracking:                  tracking,
+			PreviousEstimatedDelivery: previous.LatestEstimatedDelivery,
+		})
+	}
+}
+
+// checkpointKey identifies a checkpoint across polls
+func checkpointKey(checkpoint Checkpoint) string {
+	return checkpoint.CheckpointTime + "\x00" + checkpoint.Tag + "\x00" + checkpoint.Subtag + "\x00" +
+		checkpoint.Location + "\x00" + checkpoint.Message
+}
+
+// stopped reports whether polling of tracking stops
+func (w *Watcher) stopped(tracking Tracking) bool {
+	for _, tag := range w.options.TerminalTags {
+		if tracking.Tag == tag {
+			return true
+		}
+	}
+	return !tracking.Active
+}
+
+func (w *Watcher) interval(tracking Tracking) time.Duration {
+	if interval, ok := w.options.Intervals[tracking.Tag]; ok && interval > 0 {
+		return interval
+	}
+	return w.options.DefaultInterval
+}
+
+// rateLimitReset returns when requests are allowed again, or the zero time when they are allowed now
+func (w *Watcher) rateLimitReset() time.Time {
+	limiter, ok := w.api.(rateLimiter)
+	if !ok {
+		return time.Time{}
+	}
+	rateLimit := limiter.GetRateLimit()
+	if rateLimit.Limit > 0 && rateLimit.Remaining == 0 && rateLimit.Reset > 0 {
+		// The reset timestamp has a one second resolution
+		return time.Unix(rateLimit.Reset+1, 0)
+	}
+	return time.Time{}
+}
+
+func (w *Watcher) sleepUntil(ctx context.Context, t time.Time) error {
+	wait := t.Sub(w.now())
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package aftership_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aftership/aftership-sdk-go/v2"
+)
+
+func ExampleWatcher_Watch() {
+	cli, err := aftership.NewClient(aftership.Config{
+		APIKey: "YOUR_API_KEY",
+	})
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
+	defer cancel()
+
+	// Poll out for delivery trackings every 5 minutes, and the others with the default intervals.
+	watcher := aftership.NewWatcher(cli, aftership.WatcherOptions{
+		Intervals: map[string]time.Duration{
+			aftership.TagOutForDelivery: 5 * time.Minute,
+		},
+	})
+
+	events := watcher.Watch(ctx, []aftership.TrackingIdentifier{
+		aftership.SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"},
+		aftership.TrackingID("5b74f4958776db0e00b6f5ed"),
+	})
+
+	for event := range events {
+		switch event.Type {
+		case aftership.TrackingEventNewCheckpoint:
+			fmt.Println(event.Tracking.TrackingNumber, event.Checkpoint.Message)
+		case aftership.TrackingEventTagChanged:
+			fmt.Println(event.Tracking.TrackingNumber, event.PreviousTag, "->", event.Tracking.Tag)
+		case aftership.TrackingEventError:
+			fmt.Println(event.Err)
+		}
+	}
+}
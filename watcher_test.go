@@ -0,0 +1,187 @@
+package aftership
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var watcherTestResponses = []string{
+	`{"meta": {"code": 200}, "data": {"tracking": {"active": true, "tag": "InfoReceived",
+		"checkpoints": [{"checkpoint_time": "2022-11-01T10:00:00", "tag": "InfoReceived", "message": "Label created"}]}}}`,
+	`{"meta": {"code": 500, "type": "InternalError", "message": "Something went wrong on AfterShip's end."}, "data": {}}`,
+	`{"meta": {"code": 200}, "data": {"tracking": {"active": true, "tag": "InTransit",
+		"latest_estimated_delivery": {"type": "specific", "datetime": "2022-11-05"},
+		"checkpoints": [{"checkpoint_time": "2022-11-01T10:00:00", "tag": "InfoReceived", "message": "Label created"},
+			{"checkpoint_time": "2022-11-02T08:00:00", "tag": "InTransit", "message": "Picked up"},
+			{"checkpoint_time": "2022-11-02T20:00:00", "tag": "InTransit", "message": "Departed facility"}]}}}`,
+	`{"meta": {"code": 200}, "data": {"tracking": {"active": true, "tag": "InTransit",
+		"latest_estimated_delivery": {"type": "specific", "datetime": "2022-11-05"},
+		"checkpoints": [{"checkpoint_time": "2022-11-01T10:00:00", "tag": "InfoReceived", "message": "Label created"},
+			{"checkpoint_time": "2022-11-02T08:00:00", "tag": "InTransit", "message": "Picked up"},
+			{"checkpoint_time": "2022-11-02T20:00:00", "tag": "InTransit", "message": "Departed facility"}]}}}`,
+	`{"meta": {"code": 200}, "data": {"tracking": {"active": false, "tag": "Delivered",
+		"latest_estimated_delivery": {"type": "specific", "datetime": "2022-11-04"},
+		"checkpoints": [{"checkpoint_time": "2022-11-01T10:00:00", "tag": "InfoReceived", "message": "Label created"},
+			{"checkpoint_time": "2022-11-02T08:00:00", "tag": "InTransit", "message": "Picked up"},
+			{"checkpoint_time": "2022-11-02T20:00:00", "tag": "InTransit", "message": "Departed facility"},
+			{"checkpoint_time": "2022-11-04T12:00:00", "tag": "Delivered", "message": "Delivered"}]}}}`,
+}
+
+func watcherTestOptions() WatcherOptions {
+	return WatcherOptions{
+		Intervals: map[string]time.Duration{
+			TagInfoReceived: 20 * time.Millisecond,
+			TagInTransit:    5 * time.Millisecond,
+		},
+		DefaultInterval:    time.Millisecond,
+		MinRequestInterval: time.Millisecond,
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls []time.Time
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		response := watcherTestResponses[len(calls)]
+		calls = append(calls, time.Now())
+		if len(calls) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(response))
+	})
+
+	var events []TrackingEvent
+	watcher := NewWatcher(client, watcherTestOptions())
+	err := watcher.Run(context.Background(), []TrackingIdentifier{TrackingID("5b74f4958776db0e00b6f5ed")}, func(event TrackingEvent) {
+		events = append(events, event)
+	})
+	assert.Nil(t, err)
+	assert.Len(t, calls, 5)
+
+	// InfoReceived is polled slower than InTransit
+	assert.True(t, calls[1].Sub(calls[0]) >= 20*time.Millisecond)
+
+	var types []TrackingEventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []TrackingEventType{
+		TrackingEventError,
+		TrackingEventNewCheckpoint,
+		TrackingEventNewCheckpoint,
+		TrackingEventTagChanged,
+		TrackingEventEstimatedDeliveryChanged,
+		TrackingEventNewCheckpoint,
+		TrackingEventTagChanged,
+		TrackingEventEstimatedDeliveryChanged,
+		TrackingEventStopped,
+	}, types)
+
+	assert.NotNil(t, events[0].Err)
+	assert.Equal(t, "Picked up", events[1].Checkpoint.Message)
+	assert.Equal(t, "Departed facility", events[2].Checkpoint.Message)
+	assert.Equal(t, TagInfoReceived, events[3].PreviousTag)
+	assert.Equal(t, TagInTransit, events[3].Tracking.Tag)
+	assert.Equal(t, "2022-11-05", events[7].PreviousEstimatedDelivery.Datetime)
+	assert.Equal(t, "2022-11-04", events[7].Tracking.LatestEstimatedDelivery.Datetime)
+	assert.Equal(t, TrackingID("5b74f4958776db0e00b6f5ed"), events[8].Identifier)
+}
+
+func TestWatcherWatch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings/ups/1Z999AA10123456784", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"active": true, "tag": "Expired"}}}`))
+	})
+
+	watcher := NewWatcher(client, watcherTestOptions())
+	events := watcher.Watch(context.Background(), []TrackingIdentifier{
+		SlugTrackingNumber{Slug: "ups", TrackingNumber: "1Z999AA10123456784"},
+	})
+
+	event := <-events
+	assert.Equal(t, TrackingEventStopped, event.Type)
+	assert.Equal(t, TagExpired, event.Tracking.Tag)
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestWatcherCancel(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"active": true, "tag": "InTransit"}}}`))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	watcher := NewWatcher(client, WatcherOptions{})
+	err := watcher.Run(ctx, []TrackingIdentifier{TrackingID("5b74f4958776db0e00b6f5ed")}, func(event TrackingEvent) {
+		t.Errorf("unexpected event %v", event.Type)
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestWatcherRateLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	reset := time.Now().Unix()
+	client.rateLimit = &RateLimit{Reset: reset, Limit: 10, Remaining: 0}
+
+	var calledAt time.Time
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		calledAt = time.Now()
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"active": false, "tag": "Delivered"}}}`))
+	})
+
+	watcher := NewWatcher(client, watcherTestOptions())
+	err := watcher.Run(context.Background(), []TrackingIdentifier{TrackingID("5b74f4958776db0e00b6f5ed")}, func(event TrackingEvent) {})
+	assert.Nil(t, err)
+	assert.True(t, calledAt.Unix() > reset)
+}
+
+func TestWatcherRateLimitBackoff(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls []time.Time
+	mux.HandleFunc("/trackings/5b74f4958776db0e00b6f5ed", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, time.Now())
+		if len(calls) == 1 {
+			// No rate limit headers to wait for
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"meta": {"code": 429, "type": "TooManyRequests", "message": "You have exceeded the API call rate limit."}, "data": {}}`))
+			return
+		}
+		w.Write([]byte(`{"meta": {"code": 200}, "data": {"tracking": {"active": false, "tag": "Delivered"}}}`))
+	})
+
+	options := watcherTestOptions()
+	options.RateLimitBackoff = 50 * time.Millisecond
+	watcher := NewWatcher(client, options)
+	var errs []error
+	err := watcher.Run(context.Background(), []TrackingIdentifier{TrackingID("5b74f4958776db0e00b6f5ed")}, func(event TrackingEvent) {
+		if event.Type == TrackingEventError {
+			errs = append(errs, event.Err)
+		}
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, errs, 1) && assert.Len(t, calls, 2) {
+		var tooManyRequests *TooManyRequestsError
+		assert.True(t, errors.As(errs[0], &tooManyRequests))
+		assert.True(t, calls[1].Sub(calls[0]) >= options.RateLimitBackoff)
+	}
+}
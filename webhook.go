@@ -0,0 +1,58 @@
+package aftership
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// HeaderWebhookSignature is the header of the HMAC-SHA256 signature of the webhooks sent by AfterShip
+const HeaderWebhookSignature = "aftership-hmac-sha256"
+
+// Webhook events
+const (
+	WebhookEventTrackingUpdate = "tracking_update"
+)
+
+// Webhook is the payload of an AfterShip tracking webhook
+type Webhook struct {
+	Event                   string   `json:"event"`
+	EventID                 string   `json:"event_id"`
+	IsTrackingFirstTracking bool     `json:"is_tracking_first_tracking"`
+	Msg                     Tracking `json:"msg"` // The tracking after the update
+	TS                      int64    `json:"ts"`  // The unix timestamp of the event
+}
+
+// WebhookSignature returns the signature of a webhook body: its base64 encoded HMAC-SHA256 with the webhook secret.
+func WebhookSignature(body []byte, secret string) string {
+	return GetHMACSignature(string(body), []byte(secret))
+}
+
+// VerifyWebhookSignature returns true when signature, the value of the HeaderWebhookSignature header,
+// is the signature of body with the webhook secret.
+func VerifyWebhookSignature(body []byte, signature string, secret string) bool {
+	received, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+// ParseWebhook verifies the signature of a webhook body and decodes it.
+func ParseWebhook(body []byte, signature string, secret string) (Webhook, error) {
+	if !VerifyWebhookSignature(body, signature, secret) {
+		return Webhook{}, errors.New("invalid webhook signature")
+	}
+
+	var webhook Webhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return Webhook{}, errors.Wrap(err, "error unmarshalling webhook")
+	}
+	return webhook, nil
+}
@@ -0,0 +1,53 @@
+package aftership
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"tracking_update","event_id":"e1","msg":{"id":"5b7658cec7c33c0e007de3c5","tag":"Delivered"},"ts":1667347200}`)
+	secret := "webhook-secret"
+
+	signature := WebhookSignature(body, secret)
+	assert.Equal(t, GetHMACSignature(string(body), []byte(secret)), signature)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		secret    string
+		valid     bool
+	}{
+		{"Valid", body, signature, secret, true},
+		{"Wrong secret", body, signature, "other-secret", false},
+		{"Tampered body", append([]byte(" "), body...), signature, secret, false},
+		{"Not base64", body, "not base64!", secret, false},
+		{"Empty signature", body, "", secret, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, VerifyWebhookSignature(tt.body, tt.signature, tt.secret))
+		})
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	body := []byte(`{"event":"tracking_update","event_id":"e1","is_tracking_first_tracking":true,"msg":{"id":"5b7658cec7c33c0e007de3c5","tag":"Delivered"},"ts":1667347200}`)
+
+	webhook, err := ParseWebhook(body, WebhookSignature(body, "secret"), "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, WebhookEventTrackingUpdate, webhook.Event)
+	assert.True(t, webhook.IsTrackingFirstTracking)
+	assert.Equal(t, "5b7658cec7c33c0e007de3c5", webhook.Msg.ID)
+	assert.Equal(t, TagDelivered, webhook.Msg.Tag)
+	assert.Equal(t, int64(1667347200), webhook.TS)
+
+	_, err = ParseWebhook(body, WebhookSignature(body, "secret"), "other")
+	assert.EqualError(t, err, "invalid webhook signature")
+
+	invalid := []byte(`{"event":`)
+	_, err = ParseWebhook(invalid, WebhookSignature(invalid, "secret"), "secret")
+	assert.NotNil(t, err)
+}